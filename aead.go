@@ -0,0 +1,98 @@
+package bcl
+
+/*
+int crypto_aead_xchacha20poly1305_ietf_encrypt(unsigned char *c, unsigned long long *clen_p, const unsigned char *m, unsigned long long mlen, const unsigned char *ad, unsigned long long adlen, const unsigned char *nsec, const unsigned char *npub, const unsigned char *k);
+int crypto_aead_xchacha20poly1305_ietf_decrypt(unsigned char *m, unsigned long long *mlen_p, unsigned char *nsec, const unsigned char *c, unsigned long long clen, const unsigned char *ad, unsigned long long adlen, const unsigned char *npub, const unsigned char *k);
+*/
+import "C"
+import (
+	"crypto/cipher"
+	"unsafe"
+)
+
+// xchacha20Poly1305 implements cipher.AEAD with libsodium's XChaCha20-Poly1305 (IETF) construction
+type xchacha20Poly1305 struct {
+	key SecretKey
+}
+
+// NewAEAD returns a cipher.AEAD backed by libsodium's XChaCha20-Poly1305 using the supplied secret
+// key. Its 24 byte nonces are large enough to be generated at random for every message
+func NewAEAD(secretKey SecretKey) (cipher.AEAD, error) {
+	if len(secretKey) != CryptoSecretBoxKeyBytes {
+		return nil, ErrBadSecretKeyLength
+	}
+	return &xchacha20Poly1305{key: append(SecretKey{}, secretKey...)}, nil
+}
+
+func (x *xchacha20Poly1305) NonceSize() int {
+	return CryptoAEADXChaCha20Poly1305IETFNPubBytes
+}
+
+func (x *xchacha20Poly1305) Overhead() int {
+	return CryptoAEADXChaCha20Poly1305IETFABytes
+}
+
+// Seal encrypts and authenticates plaintext and additionalData, appending the result to dst. Like
+// the standard library implementations, it panics if the nonce has the wrong length
+func (x *xchacha20Poly1305) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != x.NonceSize() {
+		panic("bcl: incorrect nonce length given to XChaCha20-Poly1305")
+	}
+	ret, out := sliceForAppend(dst, len(plaintext)+x.Overhead())
+	if inexactOverlap(out, plaintext) {
+		panic("bcl: invalid buffer overlap")
+	}
+	// encryption can only fail for messages longer than libsodium's limit, which a slice cannot reach
+	C.crypto_aead_xchacha20poly1305_ietf_encrypt(
+		ucharPtr(out),
+		nil,
+		ucharPtr(plaintext),
+		C.ulonglong(len(plaintext)),
+		ucharPtr(additionalData),
+		C.ulonglong(len(additionalData)),
+		nil,
+		ucharPtr(nonce),
+		ucharPtr(x.key),
+	)
+	return ret
+}
+
+// Open authenticates and decrypts ciphertext and additionalData, appending the plaintext to dst
+func (x *xchacha20Poly1305) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != x.NonceSize() {
+		panic("bcl: incorrect nonce length given to XChaCha20-Poly1305")
+	}
+	if len(ciphertext) < x.Overhead() {
+		return nil, ErrDecryptionFailed
+	}
+	ret, out := sliceForAppend(dst, len(ciphertext)-x.Overhead())
+	if inexactOverlap(out, ciphertext) {
+		panic("bcl: invalid buffer overlap")
+	}
+	rc := C.crypto_aead_xchacha20poly1305_ietf_decrypt(
+		ucharPtr(out),
+		nil,
+		nil,
+		ucharPtr(ciphertext),
+		C.ulonglong(len(ciphertext)),
+		ucharPtr(additionalData),
+		C.ulonglong(len(additionalData)),
+		ucharPtr(nonce),
+		ucharPtr(x.key),
+	)
+	if rc != 0 {
+		clear(out)
+		return nil, ErrDecryptionFailed
+	}
+	return ret, nil
+}
+
+// inexactOverlap reports whether x and y share memory at any position other than their start, in
+// which case in-place encryption would corrupt the input
+func inexactOverlap(x, y []byte) bool {
+	if len(x) == 0 || len(y) == 0 || &x[0] == &y[0] {
+		return false
+	}
+	return uintptr(unsafe.Pointer(&x[0])) <= uintptr(unsafe.Pointer(&y[len(y)-1])) &&
+		uintptr(unsafe.Pointer(&y[0])) <= uintptr(unsafe.Pointer(&x[len(x)-1]))
+}
@@ -0,0 +1,66 @@
+package bcl
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAEAD(t *testing.T) {
+	// draft-irtf-cfrg-xchacha-03 appendix A.3.1
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = 0x80 + byte(i)
+	}
+	nonce := make([]byte, 24)
+	for i := range nonce {
+		nonce[i] = 0x40 + byte(i)
+	}
+	ad, _ := hex.DecodeString("50515253c0c1c2c3c4c5c6c7")
+	plaintext := []byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it.")
+	expected, _ := hex.DecodeString("bd6d179d3e83d43b9576579493c0e939572a1700252bfaccbed2902c21396cbb731c7f1b0b4aa6440bf3a82f4eda7e39ae64c6708c54c216cb96b72e1213b4522f8c9ba40db5d945b11b69b982c1bb9e3f3fac2bc369488f76b2383565d3fff921f9664c97637da9768812f615c68b13b52ec0875924c1c7987947deafd8780acf49")
+
+	aead, err := NewAEAD(key)
+	assert.NoError(t, err)
+	assert.Equal(t, 24, aead.NonceSize())
+	assert.Equal(t, 16, aead.Overhead())
+
+	c := aead.Seal(nil, nonce, plaintext, ad)
+	assert.Equal(t, expected, c)
+	m, err := aead.Open(nil, nonce, c, ad)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, m)
+
+	// in-place encryption and decryption reuse the input's storage
+	buf := append([]byte{}, plaintext...)
+	sealed := aead.Seal(buf[:0], nonce, buf, ad)
+	assert.Equal(t, expected, sealed)
+	opened, err := aead.Open(sealed[:0], nonce, sealed, ad)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, opened)
+
+	tests := []struct {
+		name       string
+		nonce      []byte
+		ciphertext []byte
+		ad         []byte
+	}{
+		{name: "TestNewAEAD fail additional data", nonce: nonce, ciphertext: c, ad: ad[1:]},
+		{name: "TestNewAEAD fail ciphertext", nonce: nonce, ciphertext: append(append([]byte{}, c[:len(c)-1]...), c[len(c)-1]^0x01), ad: ad},
+		{name: "TestNewAEAD fail nonce", nonce: append([]byte{0x00}, nonce[1:]...), ciphertext: c, ad: ad},
+		{name: "TestNewAEAD fail short", nonce: nonce, ciphertext: c[:15], ad: ad},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := aead.Open(nil, tt.nonce, tt.ciphertext, tt.ad)
+			assert.EqualError(t, err, ErrDecryptionFailed.Error())
+		})
+	}
+
+	assert.Panics(t, func() { aead.Seal(nil, nonce[1:], plaintext, nil) })
+	big := make([]byte, 2*len(plaintext))
+	assert.Panics(t, func() { aead.Seal(big[1:1], nonce, big[:len(plaintext)], nil) })
+	_, err = NewAEAD(key[1:])
+	assert.EqualError(t, err, ErrBadSecretKeyLength.Error())
+}
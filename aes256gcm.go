@@ -0,0 +1,182 @@
+package bcl
+
+/*
+int crypto_aead_aes256gcm_is_available(void);
+int crypto_aead_aes256gcm_encrypt(unsigned char *c, unsigned long long *clen_p, const unsigned char *m, unsigned long long mlen, const unsigned char *ad, unsigned long long adlen, const unsigned char *nsec, const unsigned char *npub, const unsigned char *k);
+int crypto_aead_aes256gcm_decrypt(unsigned char *m, unsigned long long *mlen_p, unsigned char *nsec, const unsigned char *c, unsigned long long clen, const unsigned char *ad, unsigned long long adlen, const unsigned char *npub, const unsigned char *k);
+*/
+import "C"
+import (
+	"crypto/cipher"
+	"fmt"
+	"io"
+)
+
+// libsodium only provides AES-256-GCM through hardware instructions (AES-NI and PCLMUL on x86, the
+// ARMv8 crypto extensions on ARM), so every entry point checks for them first. AES-GCM nonces are
+// only 12 bytes, so no more than 2^32 messages should be encrypted under one key with random nonces
+
+// AES256GCMAvailable returns whether the CPU supports the instructions needed for AES-256-GCM
+func AES256GCMAvailable() bool {
+	return C.crypto_aead_aes256gcm_is_available() == 1
+}
+
+func aes256GCMSealInto(out, key, nonce, plaintext, additionalData []byte) error {
+	rc := C.crypto_aead_aes256gcm_encrypt(
+		ucharPtr(out),
+		nil,
+		ucharPtr(plaintext),
+		C.ulonglong(len(plaintext)),
+		ucharPtr(additionalData),
+		C.ulonglong(len(additionalData)),
+		nil,
+		ucharPtr(nonce),
+		ucharPtr(key),
+	)
+	if rc != 0 {
+		return fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+	}
+	return nil
+}
+
+func aes256GCMOpenInto(out, key, nonce, ciphertext, additionalData []byte) error {
+	rc := C.crypto_aead_aes256gcm_decrypt(
+		ucharPtr(out),
+		nil,
+		nil,
+		ucharPtr(ciphertext),
+		C.ulonglong(len(ciphertext)),
+		ucharPtr(additionalData),
+		C.ulonglong(len(additionalData)),
+		ucharPtr(nonce),
+		ucharPtr(key),
+	)
+	if rc != 0 {
+		return ErrDecryptionFailed
+	}
+	return nil
+}
+
+// NewAES256GCMNonce creates a new random nonce of length CryptoAEADAES256GCMNPubBytes for
+// AES256GCMEncrypt. Random 12 byte nonces should not be used for more than 2^32 messages per key
+func NewAES256GCMNonce() (Nonce, error) {
+	return NewAES256GCMNonceFromReader(nil)
+}
+
+// NewAES256GCMNonceFromReader creates a new AES-256-GCM nonce using randomness read from r, or from
+// the package-level random source if r is nil
+func NewAES256GCMNonceFromReader(r io.Reader) (Nonce, error) {
+	n := make([]byte, CryptoAEADAES256GCMNPubBytes)
+	if err := randomBytes(r, n); err != nil {
+		return nil, err
+	}
+	return Nonce(n), nil
+}
+
+// AES256GCMEncrypt encrypts a plaintext and authenticates it along with the additional data using
+// AES-256-GCM, returning the nonce followed by the ciphertext and tag. If nonce is nil, a random
+// nonce is generated; otherwise it must be CryptoAEADAES256GCMNPubBytes long
+func AES256GCMEncrypt(secretKey SecretKey, plaintext Plaintext, nonce Nonce, additionalData []byte) (Ciphertext, error) {
+	if !AES256GCMAvailable() {
+		return nil, ErrAES256GCMUnavailable
+	}
+	if len(secretKey) != CryptoSecretBoxKeyBytes {
+		return nil, ErrBadSecretKeyLength
+	}
+	if nonce != nil && len(nonce) != CryptoAEADAES256GCMNPubBytes {
+		return nil, ErrBadAES256GCMNonceLength
+	}
+
+	out := make([]byte, CryptoAEADAES256GCMNPubBytes+len(plaintext)+CryptoAEADAES256GCMABytes)
+	if nonce == nil {
+		if err := randomBytes(nil, out[:CryptoAEADAES256GCMNPubBytes]); err != nil {
+			return nil, err
+		}
+	} else {
+		copy(out, nonce)
+	}
+	err := aes256GCMSealInto(out[CryptoAEADAES256GCMNPubBytes:], secretKey, out[:CryptoAEADAES256GCMNPubBytes], plaintext, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	return Ciphertext(out), nil
+}
+
+// AES256GCMDecrypt authenticates and decrypts a ciphertext produced by AES256GCMEncrypt with the
+// same additional data
+func AES256GCMDecrypt(secretKey SecretKey, ciphertext Ciphertext, additionalData []byte) (Plaintext, error) {
+	if !AES256GCMAvailable() {
+		return nil, ErrAES256GCMUnavailable
+	}
+	if len(secretKey) != CryptoSecretBoxKeyBytes {
+		return nil, ErrBadSecretKeyLength
+	}
+	if len(ciphertext) < CryptoAEADAES256GCMNPubBytes+CryptoAEADAES256GCMABytes {
+		return nil, ErrBadAES256GCMCiphertextLength
+	}
+
+	nonce, body := ciphertext[:CryptoAEADAES256GCMNPubBytes], ciphertext[CryptoAEADAES256GCMNPubBytes:]
+	out := make([]byte, len(body)-CryptoAEADAES256GCMABytes)
+	if err := aes256GCMOpenInto(out, secretKey, nonce, body, additionalData); err != nil {
+		return nil, err
+	}
+	return Plaintext(out), nil
+}
+
+type aes256GCM struct {
+	key SecretKey
+}
+
+// NewAES256GCM returns a cipher.AEAD backed by libsodium's AES-256-GCM using the supplied secret key
+func NewAES256GCM(secretKey SecretKey) (cipher.AEAD, error) {
+	if !AES256GCMAvailable() {
+		return nil, ErrAES256GCMUnavailable
+	}
+	if len(secretKey) != CryptoSecretBoxKeyBytes {
+		return nil, ErrBadSecretKeyLength
+	}
+	return &aes256GCM{key: append(SecretKey{}, secretKey...)}, nil
+}
+
+func (a *aes256GCM) NonceSize() int {
+	return CryptoAEADAES256GCMNPubBytes
+}
+
+func (a *aes256GCM) Overhead() int {
+	return CryptoAEADAES256GCMABytes
+}
+
+// Seal encrypts and authenticates plaintext and additionalData, appending the result to dst. It
+// panics if the nonce has the wrong length or the plaintext exceeds the AES-GCM message limit
+func (a *aes256GCM) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != a.NonceSize() {
+		panic("bcl: incorrect nonce length given to AES-256-GCM")
+	}
+	ret, out := sliceForAppend(dst, len(plaintext)+a.Overhead())
+	if inexactOverlap(out, plaintext) {
+		panic("bcl: invalid buffer overlap")
+	}
+	if err := aes256GCMSealInto(out, a.key, nonce, plaintext, additionalData); err != nil {
+		panic("bcl: message too large for AES-256-GCM")
+	}
+	return ret
+}
+
+// Open authenticates and decrypts ciphertext and additionalData, appending the plaintext to dst
+func (a *aes256GCM) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != a.NonceSize() {
+		panic("bcl: incorrect nonce length given to AES-256-GCM")
+	}
+	if len(ciphertext) < a.Overhead() {
+		return nil, ErrDecryptionFailed
+	}
+	ret, out := sliceForAppend(dst, len(ciphertext)-a.Overhead())
+	if inexactOverlap(out, ciphertext) {
+		panic("bcl: invalid buffer overlap")
+	}
+	if err := aes256GCMOpenInto(out, a.key, nonce, ciphertext, additionalData); err != nil {
+		clear(out)
+		return nil, err
+	}
+	return ret, nil
+}
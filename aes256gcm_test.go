@@ -0,0 +1,119 @@
+package bcl
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAES256GCM(t *testing.T) {
+	if !AES256GCMAvailable() {
+		_, err := AES256GCMEncrypt(make([]byte, CryptoSecretBoxKeyBytes), nil, nil, nil)
+		assert.EqualError(t, err, ErrAES256GCMUnavailable.Error())
+		t.Skip("AES-256-GCM is not available on this CPU")
+	}
+
+	sk, err := NewSecretKey()
+	assert.NoError(t, err)
+	m := Plaintext("partner data exchange")
+	ad := []byte("header")
+
+	c, err := AES256GCMEncrypt(sk, m, nil, ad)
+	assert.NoError(t, err)
+	assert.Equal(t, CryptoAEADAES256GCMNPubBytes+len(m)+CryptoAEADAES256GCMABytes, len(c))
+
+	// the output is standard AES-GCM, as produced by crypto/cipher
+	block, err := aes.NewCipher(sk)
+	assert.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	assert.NoError(t, err)
+	nonce := c[:CryptoAEADAES256GCMNPubBytes]
+	assert.Equal(t, []byte(c[CryptoAEADAES256GCMNPubBytes:]), gcm.Seal(nil, nonce, m, ad))
+
+	fixed, err := AES256GCMEncrypt(sk, m, Nonce(nonce), ad)
+	assert.NoError(t, err)
+	assert.Equal(t, c, fixed)
+
+	d, err := AES256GCMDecrypt(sk, c, ad)
+	assert.NoError(t, err)
+	assert.Equal(t, m, d)
+
+	empty, err := AES256GCMEncrypt(sk, nil, nil, nil)
+	assert.NoError(t, err)
+	d, err = AES256GCMDecrypt(sk, empty, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, d)
+
+	tampered := append(Ciphertext{}, c...)
+	tampered[len(tampered)-1] ^= 0x01
+
+	tests := []struct {
+		name       string
+		secretKey  SecretKey
+		ciphertext Ciphertext
+		ad         []byte
+		err        error
+	}{
+		{name: "TestAES256GCM fail additional data", secretKey: sk, ciphertext: c, ad: nil, err: ErrDecryptionFailed},
+		{name: "TestAES256GCM fail tampered", secretKey: sk, ciphertext: tampered, ad: ad, err: ErrDecryptionFailed},
+		{name: "TestAES256GCM fail short", secretKey: sk, ciphertext: c[:CryptoAEADAES256GCMNPubBytes+CryptoAEADAES256GCMABytes-1], ad: ad, err: ErrBadAES256GCMCiphertextLength},
+		{name: "TestAES256GCM fail key length", secretKey: sk[1:], ciphertext: c, ad: ad, err: ErrBadSecretKeyLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := AES256GCMDecrypt(tt.secretKey, tt.ciphertext, tt.ad)
+			assert.EqualError(t, err, tt.err.Error())
+		})
+	}
+
+	// a 24 byte secretbox nonce is rejected with an error naming the 12 bytes AES-GCM needs
+	secretBoxNonce, err := NewNonce()
+	assert.NoError(t, err)
+	_, err = AES256GCMEncrypt(sk, m, secretBoxNonce, ad)
+	assert.EqualError(t, err, ErrBadAES256GCMNonceLength.Error())
+	assert.Contains(t, err.Error(), "need 12")
+
+	gcmNonce, err := NewAES256GCMNonce()
+	assert.NoError(t, err)
+	assert.Len(t, gcmNonce, CryptoAEADAES256GCMNPubBytes)
+	sealed, err := AES256GCMEncrypt(sk, m, gcmNonce, ad)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(gcmNonce), []byte(sealed[:CryptoAEADAES256GCMNPubBytes]))
+	opened, err := AES256GCMDecrypt(sk, sealed, ad)
+	assert.NoError(t, err)
+	assert.Equal(t, m, opened)
+}
+
+func TestNewAES256GCM(t *testing.T) {
+	if !AES256GCMAvailable() {
+		t.Skip("AES-256-GCM is not available on this CPU")
+	}
+	sk, err := NewSecretKey()
+	assert.NoError(t, err)
+	aead, err := NewAES256GCM(sk)
+	assert.NoError(t, err)
+	assert.Equal(t, 12, aead.NonceSize())
+	assert.Equal(t, 16, aead.Overhead())
+
+	block, err := aes.NewCipher(sk)
+	assert.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	assert.NoError(t, err)
+
+	nonce := bytes.Repeat([]byte{0x02}, 12)
+	m := []byte("message")
+	c := aead.Seal([]byte("x"), nonce, m, nil)
+	assert.Equal(t, append([]byte("x"), gcm.Seal(nil, nonce, m, nil)...), c)
+	d, err := aead.Open(nil, nonce, c[1:], nil)
+	assert.NoError(t, err)
+	assert.Equal(t, m, d)
+
+	_, err = aead.Open(nil, nonce, c[1:], []byte("ad"))
+	assert.EqualError(t, err, ErrDecryptionFailed.Error())
+	assert.Panics(t, func() { aead.Seal(nil, nonce[1:], m, nil) })
+	_, err = NewAES256GCM(sk[1:])
+	assert.EqualError(t, err, ErrBadSecretKeyLength.Error())
+}
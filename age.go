@@ -0,0 +1,404 @@
+package bcl
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"strings"
+)
+
+// This file implements the age file encryption format (https://age-encryption.org/v1) for X25519
+// recipients, so that bcl key pairs can encrypt and decrypt files interchangeably with age
+
+const (
+	ageVersionLine   = "age-encryption.org/v1"
+	ageX25519Label   = "age-encryption.org/v1/X25519"
+	ageRecipientHRP  = "age"
+	ageIdentityHRP   = "AGE-SECRET-KEY-"
+	ageFileKeySize   = 16
+	agePayloadNonce  = 16
+	ageChunkSize     = 64 * 1024
+	ageColumnsPerRow = 64
+)
+
+var ageBase64 = base64.RawStdEncoding
+
+// ToAgeRecipient encodes a public key as an age X25519 recipient (age1...)
+func (p PublicKey) ToAgeRecipient() string {
+	return bech32Encode(ageRecipientHRP, p)
+}
+
+// PublicKeyFromAgeRecipient decodes a public key from an age X25519 recipient (age1...)
+func PublicKeyFromAgeRecipient(arg string) (PublicKey, error) {
+	hrp, b, err := bech32Decode(arg)
+	if err != nil {
+		return nil, err
+	}
+	if hrp != ageRecipientHRP {
+		return nil, ErrBadBech32
+	}
+	return PublicKeyFromBytes(b)
+}
+
+// ToAgeIdentity encodes a secret key as an age X25519 identity (AGE-SECRET-KEY-1...)
+func (s SecretKey) ToAgeIdentity() string {
+	return strings.ToUpper(bech32Encode(ageIdentityHRP, s))
+}
+
+// SecretKeyFromAgeIdentity decodes a secret key from an age X25519 identity (AGE-SECRET-KEY-1...)
+func SecretKeyFromAgeIdentity(arg string) (SecretKey, error) {
+	hrp, b, err := bech32Decode(arg)
+	if err != nil {
+		return nil, err
+	}
+	if hrp != strings.ToLower(ageIdentityHRP) {
+		return nil, ErrBadBech32
+	}
+	return SecretKeyFromBytes(b)
+}
+
+// AgeEncrypt writes an age header for the supplied recipients to w and returns a writer that
+// encrypts the payload. Close must be called on the returned writer to write the final chunk, and
+// does not close w
+func AgeEncrypt(w io.Writer, recipients ...PublicKey) (io.WriteCloser, error) {
+	if len(recipients) == 0 {
+		return nil, ErrNoAgeRecipients
+	}
+	fileKey := make([]byte, ageFileKeySize)
+	if err := randomBytes(nil, fileKey); err != nil {
+		return nil, err
+	}
+
+	var header strings.Builder
+	header.WriteString(ageVersionLine + "\n")
+	for _, recipient := range recipients {
+		stanza, err := ageWrapX25519(recipient, fileKey)
+		if err != nil {
+			return nil, err
+		}
+		header.WriteString(stanza)
+	}
+	header.WriteString("---")
+	mac, err := ageHeaderMAC(fileKey, header.String())
+	if err != nil {
+		return nil, err
+	}
+	header.WriteString(" " + ageBase64.EncodeToString(mac) + "\n")
+	if _, err := io.WriteString(w, header.String()); err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, agePayloadNonce)
+	if err := randomBytes(nil, nonce); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(nonce); err != nil {
+		return nil, err
+	}
+	key, err := hkdfSHA256(fileKey, nonce, []byte("payload"), CryptoSecretBoxKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &ageWriter{w: w, key: key, buf: make([]byte, 0, ageChunkSize)}, nil
+}
+
+// AgeDecrypt reads an age header from r, unwraps its file key with one of the supplied identities
+// and returns a reader for the decrypted payload
+func AgeDecrypt(r io.Reader, identities ...SecretKey) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	stanzas, header, mac, err := ageReadHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	var fileKey []byte
+	for _, identity := range identities {
+		for _, stanza := range stanzas {
+			if fileKey, err = ageUnwrapX25519(identity, stanza); err != nil {
+				return nil, err
+			}
+			if fileKey != nil {
+				break
+			}
+		}
+		if fileKey != nil {
+			break
+		}
+	}
+	if fileKey == nil {
+		return nil, ErrNoAgeIdentityMatched
+	}
+	expected, err := ageHeaderMAC(fileKey, header)
+	if err != nil {
+		return nil, err
+	}
+	if !constantTimeEqual(expected, mac) {
+		return nil, ErrBadAgeHeader
+	}
+
+	nonce := make([]byte, agePayloadNonce)
+	if _, err := io.ReadFull(br, nonce); err != nil {
+		return nil, ErrBadAgePayload
+	}
+	key, err := hkdfSHA256(fileKey, nonce, []byte("payload"), CryptoSecretBoxKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &ageReader{r: br, key: key, in: make([]byte, ageChunkSize+CryptoAEADChaCha20Poly1305IETFABytes)}, nil
+}
+
+type ageStanza struct {
+	args []string
+	body []byte
+}
+
+func ageWrapX25519(recipient PublicKey, fileKey []byte) (string, error) {
+	if len(recipient) != CryptoBoxPublicKeyBytes {
+		return "", ErrBadPublicKeyLength
+	}
+	ephemeral, share, err := NewKeyPair()
+	if err != nil {
+		return "", err
+	}
+	shared, err := ScalarMult(ephemeral, recipient)
+	if err != nil {
+		return "", err
+	}
+	wrapKey, err := hkdfSHA256(shared, append(append([]byte{}, share...), recipient...), []byte(ageX25519Label), CryptoSecretBoxKeyBytes)
+	if err != nil {
+		return "", err
+	}
+	body, err := chacha20Poly1305Encrypt(wrapKey, make([]byte, CryptoAEADChaCha20Poly1305IETFNPubBytes), nil, fileKey)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("-> X25519 " + ageBase64.EncodeToString(share) + "\n")
+	encoded := ageBase64.EncodeToString(body)
+	for len(encoded) >= ageColumnsPerRow {
+		sb.WriteString(encoded[:ageColumnsPerRow] + "\n")
+		encoded = encoded[ageColumnsPerRow:]
+	}
+	sb.WriteString(encoded + "\n")
+	return sb.String(), nil
+}
+
+// ageUnwrapX25519 returns the file key wrapped in an X25519 stanza, or nil if the stanza is not
+// addressed to the supplied identity
+func ageUnwrapX25519(identity SecretKey, stanza ageStanza) ([]byte, error) {
+	if len(stanza.args) == 0 || stanza.args[0] != "X25519" {
+		return nil, nil
+	}
+	if len(stanza.args) != 2 {
+		return nil, ErrBadAgeHeader
+	}
+	share, err := ageBase64.Strict().DecodeString(stanza.args[1])
+	if err != nil || len(share) != CryptoBoxPublicKeyBytes {
+		return nil, ErrBadAgeHeader
+	}
+	if len(stanza.body) != ageFileKeySize+CryptoAEADChaCha20Poly1305IETFABytes {
+		return nil, ErrBadAgeHeader
+	}
+
+	publicKey, err := NewPublicKey(identity)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := ScalarMult(identity, share)
+	if err != nil {
+		return nil, ErrBadAgeHeader
+	}
+	wrapKey, err := hkdfSHA256(shared, append(append([]byte{}, share...), publicKey...), []byte(ageX25519Label), CryptoSecretBoxKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	fileKey, err := chacha20Poly1305Decrypt(wrapKey, make([]byte, CryptoAEADChaCha20Poly1305IETFNPubBytes), nil, stanza.body)
+	if err != nil {
+		return nil, nil
+	}
+	return fileKey, nil
+}
+
+func ageHeaderMAC(fileKey []byte, header string) ([]byte, error) {
+	key, err := hkdfSHA256(fileKey, nil, []byte("header"), CryptoSecretBoxKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return hmacSHA256(key, []byte(header))
+}
+
+// ageReadHeader parses an age header, returning its stanzas, the header text covered by the MAC
+// and the MAC itself
+func ageReadHeader(br *bufio.Reader) ([]ageStanza, string, []byte, error) {
+	var header strings.Builder
+	readLine := func() (string, error) {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return "", ErrBadAgeHeader
+		}
+		return line[:len(line)-1], nil
+	}
+
+	line, err := readLine()
+	if err != nil || line != ageVersionLine {
+		return nil, "", nil, ErrBadAgeHeader
+	}
+	header.WriteString(line + "\n")
+
+	var stanzas []ageStanza
+	for {
+		if line, err = readLine(); err != nil {
+			return nil, "", nil, err
+		}
+		if strings.HasPrefix(line, "---") {
+			break
+		}
+		if !strings.HasPrefix(line, "-> ") {
+			return nil, "", nil, ErrBadAgeHeader
+		}
+		header.WriteString(line + "\n")
+		stanza := ageStanza{args: strings.Split(line[3:], " ")}
+		for {
+			if line, err = readLine(); err != nil {
+				return nil, "", nil, err
+			}
+			header.WriteString(line + "\n")
+			if len(line) > ageColumnsPerRow {
+				return nil, "", nil, ErrBadAgeHeader
+			}
+			b, err := ageBase64.Strict().DecodeString(line)
+			if err != nil {
+				return nil, "", nil, ErrBadAgeHeader
+			}
+			stanza.body = append(stanza.body, b...)
+			if len(line) < ageColumnsPerRow {
+				break
+			}
+		}
+		stanzas = append(stanzas, stanza)
+	}
+
+	if !strings.HasPrefix(line, "--- ") {
+		return nil, "", nil, ErrBadAgeHeader
+	}
+	header.WriteString("---")
+	mac, err := ageBase64.Strict().DecodeString(line[4:])
+	if err != nil {
+		return nil, "", nil, ErrBadAgeHeader
+	}
+	return stanzas, header.String(), mac, nil
+}
+
+func ageNonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, CryptoAEADChaCha20Poly1305IETFNPubBytes)
+	binary.BigEndian.PutUint64(nonce[3:11], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+type ageWriter struct {
+	w       io.Writer
+	key     []byte
+	buf     []byte
+	counter uint64
+	closed  bool
+}
+
+func (a *ageWriter) Write(p []byte) (int, error) {
+	if a.closed {
+		return 0, ErrFileClosed
+	}
+	n := 0
+	for len(p) > 0 {
+		// a full chunk is only written once more data arrives, since the last chunk must be marked
+		if len(a.buf) == ageChunkSize {
+			if err := a.flush(false); err != nil {
+				return n, err
+			}
+		}
+		take := min(len(p), ageChunkSize-len(a.buf))
+		a.buf = append(a.buf, p[:take]...)
+		p = p[take:]
+		n += take
+	}
+	return n, nil
+}
+
+func (a *ageWriter) flush(last bool) error {
+	sealed, err := chacha20Poly1305Encrypt(a.key, ageNonce(a.counter, last), nil, a.buf)
+	if err != nil {
+		return err
+	}
+	if _, err := a.w.Write(sealed); err != nil {
+		return err
+	}
+	a.counter++
+	a.buf = a.buf[:0]
+	return nil
+}
+
+func (a *ageWriter) Close() error {
+	if a.closed {
+		return ErrFileClosed
+	}
+	if err := a.flush(true); err != nil {
+		return err
+	}
+	a.closed = true
+	return nil
+}
+
+type ageReader struct {
+	r       *bufio.Reader
+	key     []byte
+	in      []byte
+	pending []byte
+	counter uint64
+	last    bool
+	err     error
+}
+
+func (a *ageReader) Read(p []byte) (int, error) {
+	for len(a.pending) == 0 {
+		if a.err != nil {
+			return 0, a.err
+		}
+		if a.last {
+			return 0, io.EOF
+		}
+		a.pending, a.err = a.readChunk()
+	}
+	n := copy(p, a.pending)
+	a.pending = a.pending[n:]
+	return n, nil
+}
+
+func (a *ageReader) readChunk() ([]byte, error) {
+	n, err := io.ReadFull(a.r, a.in)
+	switch {
+	case err == io.ErrUnexpectedEOF:
+		a.last = true
+	case err == io.EOF:
+		return nil, ErrBadAgePayload
+	case err != nil:
+		return nil, err
+	default:
+		if _, err := a.r.Peek(1); err == io.EOF {
+			a.last = true
+		}
+	}
+
+	plaintext, err := chacha20Poly1305Decrypt(a.key, ageNonce(a.counter, a.last), nil, a.in[:n])
+	if err != nil {
+		return nil, ErrBadAgePayload
+	}
+	if a.last && len(plaintext) == 0 && a.counter > 0 {
+		return nil, ErrBadAgePayload
+	}
+	a.counter++
+	return plaintext, nil
+}
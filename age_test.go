@@ -0,0 +1,171 @@
+package bcl
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// produced by filippo.io/age for the identity below, encrypting "hello from age " three times
+const (
+	ageTestIdentity  = "AGE-SECRET-KEY-1QYQSZQGPQYQSZQGPQYQSZQGPQYQSZQGPQYQSZQGPQYQSZQGPQYQSZ9K4CN"
+	ageTestRecipient = "age15nsf9y4k28p83wth93tf7hafhvfajp45d2mge80ems45gz0c5gys3me64l"
+	ageTestVector    = "6167652d656e6372797074696f6e2e6f72672f76310a2d3e205832353531392069616939583953545856653848545377397a2f72554f6a385263386177746c4c796f75775853434d4c43410a6d56514c54647334464d64487176505a766a6d2f6d2b432b624932504f78436674786933613561506d46590a2d2d2d20795164476f4e554865637044636b4b71326c4c486c48547738744473777762312b4d6454327846306367590af1084d23b583a38e5be3ff72c37a425bd17c7ad75b460a151271756706151e4bba4e505a933473559ad7aa6c4a2ca96b0c03ce60efb82b387b816b2f5ec270cfc2132cbc04d9f37c468863cf97"
+)
+
+func ageEncrypt(t *testing.T, data []byte, recipients ...PublicKey) []byte {
+	var buf bytes.Buffer
+	w, err := AgeEncrypt(&buf, recipients...)
+	assert.NoError(t, err)
+	_, err = w.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestAgeKeyEncoding(t *testing.T) {
+	sk, err := SecretKeyFromAgeIdentity(ageTestIdentity)
+	assert.NoError(t, err)
+	assert.Equal(t, SecretKey(bytes.Repeat([]byte{0x01}, 32)), sk)
+	assert.Equal(t, ageTestIdentity, sk.ToAgeIdentity())
+
+	pk, err := PublicKeyFromAgeRecipient(ageTestRecipient)
+	assert.NoError(t, err)
+	assert.Equal(t, ageTestRecipient, pk.ToAgeRecipient())
+
+	tests := []struct {
+		name string
+		arg  string
+	}{
+		{name: "TestAgeKeyEncoding fail checksum", arg: ageTestRecipient[:len(ageTestRecipient)-1] + "q"},
+		{name: "TestAgeKeyEncoding fail hrp", arg: ageTestIdentity},
+		{name: "TestAgeKeyEncoding fail mixed case", arg: "Age" + ageTestRecipient[3:]},
+		{name: "TestAgeKeyEncoding fail empty", arg: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := PublicKeyFromAgeRecipient(tt.arg)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err = SecretKeyFromAgeIdentity(ageTestRecipient)
+	assert.Error(t, err)
+}
+
+func TestAgeDecryptVector(t *testing.T) {
+	sk, err := SecretKeyFromAgeIdentity(ageTestIdentity)
+	assert.NoError(t, err)
+	vector, err := hex.DecodeString(ageTestVector)
+	assert.NoError(t, err)
+
+	r, err := AgeDecrypt(bytes.NewReader(vector), sk)
+	assert.NoError(t, err)
+	m, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello from age hello from age hello from age ", string(m))
+}
+
+func TestAgeEncrypt(t *testing.T) {
+	sk1, pk1, err := NewKeyPair()
+	assert.NoError(t, err)
+	sk2, pk2, err := NewKeyPair()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		length int
+	}{
+		{name: "TestAgeEncrypt empty", length: 0},
+		{name: "TestAgeEncrypt short", length: 5},
+		{name: "TestAgeEncrypt full chunk", length: 64 * 1024},
+		{name: "TestAgeEncrypt many chunks", length: 2*64*1024 + 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := make([]byte, tt.length)
+			for i := range data {
+				data[i] = byte(i)
+			}
+			enc := ageEncrypt(t, data, pk1, pk2)
+
+			for _, sk := range []SecretKey{sk1, sk2} {
+				r, err := AgeDecrypt(bytes.NewReader(enc), sk)
+				assert.NoError(t, err)
+				dec, err := io.ReadAll(r)
+				assert.NoError(t, err)
+				assert.Equal(t, data, dec)
+			}
+		})
+	}
+
+	_, err = AgeEncrypt(io.Discard)
+	assert.EqualError(t, err, ErrNoAgeRecipients.Error())
+}
+
+func TestAgeDecrypt(t *testing.T) {
+	sk, pk, err := NewKeyPair()
+	assert.NoError(t, err)
+	other, _, err := NewKeyPair()
+	assert.NoError(t, err)
+	enc := ageEncrypt(t, []byte("attack at dawn"), pk)
+	headerEnd := bytes.Index(enc, []byte("\n---"))
+
+	tests := []struct {
+		name     string
+		identity SecretKey
+		mutate   func([]byte) []byte
+		err      error
+	}{
+		{
+			name:     "TestAgeDecrypt fail wrong identity",
+			identity: other,
+			mutate:   func(b []byte) []byte { return b },
+			err:      ErrNoAgeIdentityMatched,
+		},
+		{
+			name:     "TestAgeDecrypt fail tampered header",
+			identity: sk,
+			mutate: func(b []byte) []byte {
+				b[headerEnd-1] ^= 0x01
+				return b
+			},
+		},
+		{
+			name:     "TestAgeDecrypt fail tampered payload",
+			identity: sk,
+			mutate: func(b []byte) []byte {
+				b[len(b)-1] ^= 0x01
+				return b
+			},
+		},
+		{
+			name:     "TestAgeDecrypt fail truncated payload",
+			identity: sk,
+			mutate:   func(b []byte) []byte { return b[:len(b)-17] },
+		},
+		{
+			name:     "TestAgeDecrypt fail bad version",
+			identity: sk,
+			mutate:   func(b []byte) []byte { return append([]byte("age-encryption.org/v2"), b[21:]...) },
+			err:      ErrBadAgeHeader,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.mutate(append([]byte{}, enc...))
+			r, err := AgeDecrypt(bytes.NewReader(b), tt.identity)
+			if err == nil {
+				_, err = io.ReadAll(r)
+			}
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
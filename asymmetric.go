@@ -1,31 +1,29 @@
 package bcl
 
 /*
-int crypto_scalarmult_base(unsigned char *q, const unsigned char *n);
+int crypto_box_seed_keypair(unsigned char *pk, unsigned char *sk, const unsigned char *seed);
 int crypto_box_seal(unsigned char *c, const unsigned char *m, unsigned long long mlen, const unsigned char *pk);
 int crypto_box_seal_open(unsigned char *m, const unsigned char *c, unsigned long long clen, const unsigned char *pk, const unsigned char *sk);
 */
 import "C"
 import (
 	"fmt"
+	"io"
 	"unsafe"
 )
 
 // NewKeyPair returns a (secret key, public key) keypair for use in asymmetric encryption and decryption
 func NewKeyPair() (SecretKey, PublicKey, error) {
-	secretKey, err := NewSecretKey()
+	return NewKeyPairFromReader(nil)
+}
+
+// NewKeyPairFromReader returns a (secret key, public key) keypair using randomness read from r, or
+// from the package-level random source if r is nil
+func NewKeyPairFromReader(r io.Reader) (SecretKey, PublicKey, error) {
+	secretKey, err := NewSecretKeyFromReader(r)
 	if err != nil {
 		return nil, nil, err
 	}
-	out := make([]byte, CryptoScalarMultBytes)
-	rc := C.crypto_scalarmult_base(
-		(*C.uchar)(unsafe.Pointer(&out[0])),
-		(*C.uchar)(unsafe.Pointer(&secretKey[0])),
-	)
-	if rc != 0 {
-		return nil, nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
-	}
-
 	publicKey, err := NewPublicKey(secretKey)
 	if err != nil {
 		return nil, nil, err
@@ -34,19 +32,40 @@ func NewKeyPair() (SecretKey, PublicKey, error) {
 	return secretKey, publicKey, nil
 }
 
-// AsymmetricEncrypt encrypts a plaintext using the supplied public key
-func AsymmetricEncrypt(publicKey PublicKey, plaintext Plaintext) (Ciphertext, error) {
-	out := make([]byte, CryptoBoxSealBytes+len(plaintext))
-	rc := C.crypto_box_seal(
-		(*C.uchar)(unsafe.Pointer(&out[0])),
-		(*C.uchar)(unsafe.Pointer(&plaintext[0])),
-		(C.ulonglong)(len(plaintext)),
+// NewKeyPairFromSeed deterministically derives a (secret key, public key) keypair from a seed of
+// length CryptoBoxSeedBytes
+func NewKeyPairFromSeed(seed []byte) (SecretKey, PublicKey, error) {
+	if len(seed) != CryptoBoxSeedBytes {
+		return nil, nil, ErrBadSeedLength
+	}
+	publicKey := make([]byte, CryptoBoxPublicKeyBytes)
+	secretKey := make([]byte, CryptoSecretBoxKeyBytes)
+	rc := C.crypto_box_seed_keypair(
 		(*C.uchar)(unsafe.Pointer(&publicKey[0])),
+		(*C.uchar)(unsafe.Pointer(&secretKey[0])),
+		(*C.uchar)(unsafe.Pointer(&seed[0])),
 	)
 	if rc != 0 {
-		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+		return nil, nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
 	}
-	return CiphertextFromBytes(out)
+	return SecretKey(secretKey), PublicKey(publicKey), nil
+}
+
+// AsymmetricEncrypt encrypts a plaintext using the supplied public key. Empty plaintexts are
+// supported
+func AsymmetricEncrypt(publicKey PublicKey, plaintext Plaintext) (Ciphertext, error) {
+	if len(publicKey) != CryptoBoxPublicKeyBytes {
+		return nil, ErrBadPublicKeyLength
+	}
+	if uint64(len(plaintext)) > CryptoSecretBoxMessageBytesMax {
+		return nil, ErrBadPlaintextLength
+	}
+
+	out := make([]byte, CryptoBoxSealBytes+len(plaintext))
+	if err := boxSealInto(out, publicKey, plaintext); err != nil {
+		return nil, err
+	}
+	return Ciphertext(out), nil
 }
 
 // AsymmetricDecrypt decrypts a ciphertext using the supplied secret key
@@ -55,20 +74,44 @@ func AsymmetricDecrypt(secretKey SecretKey, ciphertext Ciphertext) (Plaintext, e
 		return nil, ErrBadCiphertextLength
 	}
 
-	publicKey, err := fromSecret(secretKey)
+	publicKey, err := ScalarMultBase(secretKey)
 	if err != nil {
 		return nil, err
 	}
-	out := make([]byte, max(1, len(ciphertext)-CryptoBoxSealBytes))
+	out := make([]byte, len(ciphertext)-CryptoBoxSealBytes)
+	if err := boxSealOpenInto(out, publicKey, secretKey, ciphertext); err != nil {
+		return nil, err
+	}
+	return Plaintext(out), nil
+}
+
+// boxSealInto writes a sealed box of a plaintext into dst, which must be exactly
+// CryptoBoxSealBytes+len(plaintext) bytes long
+func boxSealInto(dst []byte, publicKey PublicKey, plaintext []byte) error {
+	rc := C.crypto_box_seal(
+		ucharPtr(dst),
+		ucharPtr(plaintext),
+		C.ulonglong(len(plaintext)),
+		ucharPtr(publicKey),
+	)
+	if rc != 0 {
+		return fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+	}
+	return nil
+}
+
+// boxSealOpenInto opens a sealed box into dst, which must be exactly len(ciphertext)-CryptoBoxSealBytes
+// bytes long. The public key must be the one corresponding to the secret key
+func boxSealOpenInto(dst []byte, publicKey PublicKey, secretKey SecretKey, ciphertext []byte) error {
 	rc := C.crypto_box_seal_open(
-		(*C.uchar)(unsafe.Pointer(&out[0])),
-		(*C.uchar)(unsafe.Pointer(&ciphertext[0])),
-		(C.ulonglong)(len(ciphertext)),
-		(*C.uchar)(unsafe.Pointer(&publicKey[0])),
-		(*C.uchar)(unsafe.Pointer(&secretKey[0])),
+		ucharPtr(dst),
+		ucharPtr(ciphertext),
+		C.ulonglong(len(ciphertext)),
+		ucharPtr(publicKey),
+		ucharPtr(secretKey),
 	)
 	if rc != 0 {
-		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+		return ErrDecryptionFailed
 	}
-	return PlaintextFromBytes(out)
+	return nil
 }
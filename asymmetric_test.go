@@ -107,3 +107,91 @@ func TestAsymmetricDecrypt(t *testing.T) {
 		})
 	}
 }
+
+func TestNewKeyPairFromSeed(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x2a}, CryptoBoxSeedBytes)
+	sk, pk, err := NewKeyPairFromSeed(seed)
+	assert.NoError(t, err)
+	sk2, pk2, err := NewKeyPairFromSeed(seed)
+	assert.NoError(t, err)
+	assert.Equal(t, sk, sk2)
+	assert.Equal(t, pk, pk2)
+
+	derived, err := NewPublicKey(sk)
+	assert.NoError(t, err)
+	assert.Equal(t, derived, pk)
+
+	other, _, err := NewKeyPairFromSeed(bytes.Repeat([]byte{0x2b}, CryptoBoxSeedBytes))
+	assert.NoError(t, err)
+	assert.NotEqual(t, sk, other)
+
+	_, _, err = NewKeyPairFromSeed(seed[1:])
+	assert.EqualError(t, err, ErrBadSeedLength.Error())
+}
+
+func TestAsymmetricEdgeCases(t *testing.T) {
+	sk, pk, err := NewKeyPair()
+	assert.NoError(t, err)
+	other, _, err := NewKeyPair()
+	assert.NoError(t, err)
+	valid, err := AsymmetricEncrypt(pk, Plaintext("valid"))
+	assert.NoError(t, err)
+	tampered := append(Ciphertext{}, valid...)
+	tampered[len(tampered)-1] ^= 0x01
+
+	encryptTests := []struct {
+		name string
+		pk   PublicKey
+		msg  Plaintext
+		err  error
+	}{
+		{name: "TestAsymmetricEdgeCases encrypt success empty", pk: pk, msg: Plaintext{}, err: nil},
+		{name: "TestAsymmetricEdgeCases encrypt success nil", pk: pk, msg: nil, err: nil},
+		{name: "TestAsymmetricEdgeCases encrypt success one byte", pk: pk, msg: Plaintext{0x00}, err: nil},
+		{name: "TestAsymmetricEdgeCases encrypt fail nil key", pk: nil, msg: Plaintext("m"), err: ErrBadPublicKeyLength},
+		{name: "TestAsymmetricEdgeCases encrypt fail short key", pk: pk[1:], msg: Plaintext("m"), err: ErrBadPublicKeyLength},
+	}
+	for _, tt := range encryptTests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := AsymmetricEncrypt(tt.pk, tt.msg)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, CryptoBoxSealBytes+len(tt.msg), len(c))
+			d, err := AsymmetricDecrypt(sk, c)
+			assert.NoError(t, err)
+			assert.NotNil(t, d)
+			assert.Equal(t, string(tt.msg), string(d))
+		})
+	}
+
+	decryptTests := []struct {
+		name string
+		sk   SecretKey
+		c    Ciphertext
+		err  error
+	}{
+		{name: "TestAsymmetricEdgeCases decrypt success", sk: sk, c: valid, err: nil},
+		{name: "TestAsymmetricEdgeCases decrypt fail nil", sk: sk, c: nil, err: ErrBadCiphertextLength},
+		{name: "TestAsymmetricEdgeCases decrypt fail short", sk: sk, c: valid[:CryptoBoxSealBytes-1], err: ErrBadCiphertextLength},
+		{name: "TestAsymmetricEdgeCases decrypt fail truncated", sk: sk, c: valid[:CryptoBoxSealBytes], err: ErrDecryptionFailed},
+		{name: "TestAsymmetricEdgeCases decrypt fail tampered", sk: sk, c: tampered, err: ErrDecryptionFailed},
+		{name: "TestAsymmetricEdgeCases decrypt fail wrong key", sk: other, c: valid, err: ErrDecryptionFailed},
+		{name: "TestAsymmetricEdgeCases decrypt fail nil key", sk: nil, c: valid, err: ErrBadSecretKeyLength},
+		{name: "TestAsymmetricEdgeCases decrypt fail short key", sk: sk[1:], c: valid, err: ErrBadSecretKeyLength},
+	}
+	for _, tt := range decryptTests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := AsymmetricDecrypt(tt.sk, tt.c)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				assert.Nil(t, d)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "valid", string(d))
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package bcl
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// The batch functions encrypt or decrypt many items with a bounded pool of workers. All outputs of
+// a batch share a single preallocated buffer, each item is processed with a single call into
+// libsodium, and every item reports its own error so that one bad input does not fail the batch.
+// If workers is zero or negative, runtime.GOMAXPROCS(0) workers are used
+
+// batchChunkSize is the number of consecutive items a worker claims at a time
+const batchChunkSize = 64
+
+func runBatch(n, workers int, fn func(i int)) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, (n+batchChunkSize-1)/batchChunkSize)
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				start := int(next.Add(batchChunkSize)) - batchChunkSize
+				if start >= n {
+					return
+				}
+				for i := start; i < min(start+batchChunkSize, n); i++ {
+					fn(i)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// batchOutputs carves one output slice per item out of a single buffer, where size returns the
+// output length of an item or a negative value if the item is invalid
+func batchOutputs(n int, size func(i int) int) [][]byte {
+	total := 0
+	for i := 0; i < n; i++ {
+		if s := size(i); s > 0 {
+			total += s
+		}
+	}
+	buf := make([]byte, total)
+	outs := make([][]byte, n)
+	off := 0
+	for i := range outs {
+		s := size(i)
+		if s < 0 {
+			continue
+		}
+		outs[i] = buf[off : off+s : off+s]
+		off += s
+	}
+	return outs
+}
+
+func fillErrors(n int, err error) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+// SymmetricEncryptBatch encrypts each plaintext using the supplied secret key and a fresh random
+// nonce, returning the ciphertexts and a per-item error slice
+func SymmetricEncryptBatch(secretKey SecretKey, plaintexts []Plaintext, workers int) ([]Ciphertext, []error) {
+	n := len(plaintexts)
+	if len(secretKey) != CryptoSecretBoxKeyBytes {
+		return make([]Ciphertext, n), fillErrors(n, ErrBadSecretKeyLength)
+	}
+	nonces := make([]byte, n*CryptoSecretBoxNonceBytes)
+	if err := randomBytes(nil, nonces); err != nil {
+		return make([]Ciphertext, n), fillErrors(n, err)
+	}
+
+	overhead := CryptoSecretBoxNonceBytes + CryptoSecretBoxMacBytes
+	outs := batchOutputs(n, func(i int) int {
+		if uint64(len(plaintexts[i])) > CryptoSecretBoxMessageBytesMax {
+			return -1
+		}
+		return overhead + len(plaintexts[i])
+	})
+
+	ciphertexts := make([]Ciphertext, n)
+	errs := make([]error, n)
+	runBatch(n, workers, func(i int) {
+		if outs[i] == nil {
+			errs[i] = ErrBadPlaintextLength
+			return
+		}
+		nonce := nonces[i*CryptoSecretBoxNonceBytes : (i+1)*CryptoSecretBoxNonceBytes]
+		if errs[i] = secretBoxSealInto(outs[i], secretKey, nonce, plaintexts[i]); errs[i] == nil {
+			ciphertexts[i] = outs[i]
+		}
+	})
+	return ciphertexts, errs
+}
+
+// SymmetricDecryptBatch decrypts each ciphertext using the supplied secret key, returning the
+// plaintexts and a per-item error slice
+func SymmetricDecryptBatch(secretKey SecretKey, ciphertexts []Ciphertext, workers int) ([]Plaintext, []error) {
+	n := len(ciphertexts)
+	if len(secretKey) != CryptoSecretBoxKeyBytes {
+		return make([]Plaintext, n), fillErrors(n, ErrBadSecretKeyLength)
+	}
+
+	overhead := CryptoSecretBoxNonceBytes + CryptoSecretBoxMacBytes
+	outs := batchOutputs(n, func(i int) int {
+		return len(ciphertexts[i]) - overhead
+	})
+
+	plaintexts := make([]Plaintext, n)
+	errs := make([]error, n)
+	runBatch(n, workers, func(i int) {
+		if outs[i] == nil {
+			errs[i] = ErrBadSymmetricCiphertextLength
+			return
+		}
+		if errs[i] = secretBoxOpenInto(outs[i], secretKey, ciphertexts[i]); errs[i] == nil {
+			plaintexts[i] = outs[i]
+		}
+	})
+	return plaintexts, errs
+}
+
+// AsymmetricEncryptBatch encrypts each plaintext using the supplied public key, returning the
+// ciphertexts and a per-item error slice
+func AsymmetricEncryptBatch(publicKey PublicKey, plaintexts []Plaintext, workers int) ([]Ciphertext, []error) {
+	n := len(plaintexts)
+	if len(publicKey) != CryptoBoxPublicKeyBytes {
+		return make([]Ciphertext, n), fillErrors(n, ErrBadPublicKeyLength)
+	}
+
+	outs := batchOutputs(n, func(i int) int {
+		if uint64(len(plaintexts[i])) > CryptoSecretBoxMessageBytesMax {
+			return -1
+		}
+		return CryptoBoxSealBytes + len(plaintexts[i])
+	})
+
+	ciphertexts := make([]Ciphertext, n)
+	errs := make([]error, n)
+	runBatch(n, workers, func(i int) {
+		if outs[i] == nil {
+			errs[i] = ErrBadPlaintextLength
+			return
+		}
+		if errs[i] = boxSealInto(outs[i], publicKey, plaintexts[i]); errs[i] == nil {
+			ciphertexts[i] = outs[i]
+		}
+	})
+	return ciphertexts, errs
+}
+
+// AsymmetricDecryptBatch decrypts each ciphertext using the supplied secret key, returning the
+// plaintexts and a per-item error slice
+func AsymmetricDecryptBatch(secretKey SecretKey, ciphertexts []Ciphertext, workers int) ([]Plaintext, []error) {
+	n := len(ciphertexts)
+	// the public key is derived once for the whole batch rather than once per item
+	publicKey, err := ScalarMultBase(secretKey)
+	if err != nil {
+		return make([]Plaintext, n), fillErrors(n, err)
+	}
+
+	outs := batchOutputs(n, func(i int) int {
+		return len(ciphertexts[i]) - CryptoBoxSealBytes
+	})
+
+	plaintexts := make([]Plaintext, n)
+	errs := make([]error, n)
+	runBatch(n, workers, func(i int) {
+		if outs[i] == nil {
+			errs[i] = ErrBadCiphertextLength
+			return
+		}
+		if errs[i] = boxSealOpenInto(outs[i], publicKey, secretKey, ciphertexts[i]); errs[i] == nil {
+			plaintexts[i] = outs[i]
+		}
+	})
+	return plaintexts, errs
+}
@@ -0,0 +1,93 @@
+package bcl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func batchPlaintexts(n int) []Plaintext {
+	plaintexts := make([]Plaintext, n)
+	for i := range plaintexts {
+		plaintexts[i] = bytes.Repeat([]byte{byte(i)}, i%50)
+	}
+	return plaintexts
+}
+
+func TestSymmetricBatch(t *testing.T) {
+	sk, err := NewSecretKey()
+	assert.NoError(t, err)
+
+	for _, workers := range []int{0, 1, 3, 1000} {
+		plaintexts := batchPlaintexts(500)
+		ciphertexts, errs := SymmetricEncryptBatch(sk, plaintexts, workers)
+		assert.Len(t, ciphertexts, len(plaintexts))
+		for i := range plaintexts {
+			assert.NoError(t, errs[i])
+			// batch output is interchangeable with SymmetricEncrypt output
+			d, err := SymmetricDecrypt(sk, ciphertexts[i])
+			assert.NoError(t, err)
+			assert.Equal(t, []byte(plaintexts[i]), []byte(d))
+		}
+
+		decrypted, errs := SymmetricDecryptBatch(sk, ciphertexts, workers)
+		for i := range plaintexts {
+			assert.NoError(t, errs[i])
+			assert.Equal(t, []byte(plaintexts[i]), []byte(decrypted[i]))
+		}
+	}
+
+	c, err := SymmetricEncrypt(sk, Plaintext("single"), nil)
+	assert.NoError(t, err)
+	tampered := append(Ciphertext{}, c...)
+	tampered[len(tampered)-1] ^= 0x01
+	decrypted, errs := SymmetricDecryptBatch(sk, []Ciphertext{c, tampered, c[:10]}, 2)
+	assert.NoError(t, errs[0])
+	assert.Equal(t, "single", string(decrypted[0]))
+	assert.EqualError(t, errs[1], ErrDecryptionFailed.Error())
+	assert.Nil(t, decrypted[1])
+	assert.EqualError(t, errs[2], ErrBadSymmetricCiphertextLength.Error())
+
+	_, errs = SymmetricEncryptBatch(sk[1:], batchPlaintexts(3), 0)
+	for _, err := range errs {
+		assert.EqualError(t, err, ErrBadSecretKeyLength.Error())
+	}
+	ciphertexts, errs := SymmetricEncryptBatch(sk, nil, 0)
+	assert.Empty(t, ciphertexts)
+	assert.Empty(t, errs)
+}
+
+func TestAsymmetricBatch(t *testing.T) {
+	sk, pk, err := NewKeyPair()
+	assert.NoError(t, err)
+
+	plaintexts := batchPlaintexts(300)
+	ciphertexts, errs := AsymmetricEncryptBatch(pk, plaintexts, 4)
+	for i := range plaintexts {
+		assert.NoError(t, errs[i])
+	}
+	decrypted, errs := AsymmetricDecryptBatch(sk, ciphertexts, 4)
+	for i := range plaintexts {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, []byte(plaintexts[i]), []byte(decrypted[i]))
+	}
+
+	m, err := PlaintextFromString("single")
+	assert.NoError(t, err)
+	c, err := AsymmetricEncrypt(pk, m)
+	assert.NoError(t, err)
+	other, _, err := NewKeyPair()
+	assert.NoError(t, err)
+	decrypted, errs = AsymmetricDecryptBatch(sk, []Ciphertext{c, c[:CryptoBoxSealBytes-1]}, 0)
+	assert.NoError(t, errs[0])
+	assert.Equal(t, "single", string(decrypted[0]))
+	assert.EqualError(t, errs[1], ErrBadCiphertextLength.Error())
+
+	_, errs = AsymmetricDecryptBatch(other, []Ciphertext{c}, 0)
+	assert.EqualError(t, errs[0], ErrDecryptionFailed.Error())
+	_, errs = AsymmetricEncryptBatch(pk[1:], plaintexts[:2], 0)
+	assert.EqualError(t, errs[1], ErrBadPublicKeyLength.Error())
+	_, errs = AsymmetricDecryptBatch(sk[1:], ciphertexts[:2], 0)
+	assert.EqualError(t, errs[0], ErrBadSecretKeyLength.Error())
+}
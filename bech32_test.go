@@ -0,0 +1,39 @@
+package bcl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBech32(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		hrp  string
+		err  bool
+	}{
+		// valid and invalid strings from BIP-173
+		{name: "TestBech32 success minimal", s: "A12UEL5L", hrp: "a"},
+		{name: "TestBech32 success long hrp", s: "an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs", hrp: "an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio"},
+		{name: "TestBech32 success data", s: "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", hrp: "abcdef"},
+		{name: "TestBech32 fail no separator", s: "pzry9x0s0muk", err: true},
+		{name: "TestBech32 fail empty hrp", s: "1pzry9x0s0muk", err: true},
+		{name: "TestBech32 fail invalid character", s: "x1b4n0q5v", err: true},
+		{name: "TestBech32 fail short checksum", s: "li1dgmt3", err: true},
+		{name: "TestBech32 fail checksum", s: "A12UEL5M", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hrp, data, err := bech32Decode(tt.s)
+			if tt.err {
+				assert.EqualError(t, err, ErrBadBech32.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.hrp, hrp)
+			assert.Equal(t, strings.ToLower(tt.s), bech32Encode(hrp, data))
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package bcl
+
+/*
+int crypto_aead_chacha20poly1305_ietf_encrypt(unsigned char *c, unsigned long long *clen_p, const unsigned char *m, unsigned long long mlen, const unsigned char *ad, unsigned long long adlen, const unsigned char *nsec, const unsigned char *npub, const unsigned char *k);
+int crypto_aead_chacha20poly1305_ietf_decrypt(unsigned char *m, unsigned long long *mlen_p, unsigned char *nsec, const unsigned char *c, unsigned long long clen, const unsigned char *ad, unsigned long long adlen, const unsigned char *npub, const unsigned char *k);
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// ucharPtr returns a pointer to the first byte of b, or nil if b is empty, for passing possibly
+// empty buffers to libsodium
+func ucharPtr(b []byte) *C.uchar {
+	if len(b) == 0 {
+		return nil
+	}
+	return (*C.uchar)(unsafe.Pointer(&b[0]))
+}
+
+// chacha20Poly1305Encrypt encrypts a plaintext with the IETF ChaCha20-Poly1305 construction and
+// returns the ciphertext followed by its authentication tag
+func chacha20Poly1305Encrypt(key, nonce, ad, plaintext []byte) ([]byte, error) {
+	if len(key) != CryptoSecretBoxKeyBytes {
+		return nil, ErrBadSecretKeyLength
+	}
+	if len(nonce) != CryptoAEADChaCha20Poly1305IETFNPubBytes {
+		return nil, ErrBadNonceLength
+	}
+
+	out := make([]byte, len(plaintext)+CryptoAEADChaCha20Poly1305IETFABytes)
+	var outLen C.ulonglong
+	rc := C.crypto_aead_chacha20poly1305_ietf_encrypt(
+		ucharPtr(out),
+		&outLen,
+		ucharPtr(plaintext),
+		C.ulonglong(len(plaintext)),
+		ucharPtr(ad),
+		C.ulonglong(len(ad)),
+		nil,
+		ucharPtr(nonce),
+		ucharPtr(key),
+	)
+	if rc != 0 {
+		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+	}
+	return out[:outLen], nil
+}
+
+// chacha20Poly1305Decrypt authenticates and decrypts a ciphertext produced by chacha20Poly1305Encrypt
+func chacha20Poly1305Decrypt(key, nonce, ad, ciphertext []byte) ([]byte, error) {
+	if len(key) != CryptoSecretBoxKeyBytes {
+		return nil, ErrBadSecretKeyLength
+	}
+	if len(nonce) != CryptoAEADChaCha20Poly1305IETFNPubBytes {
+		return nil, ErrBadNonceLength
+	}
+	if len(ciphertext) < CryptoAEADChaCha20Poly1305IETFABytes {
+		return nil, ErrDecryptionFailed
+	}
+
+	out := make([]byte, len(ciphertext)-CryptoAEADChaCha20Poly1305IETFABytes)
+	var outLen C.ulonglong
+	rc := C.crypto_aead_chacha20poly1305_ietf_decrypt(
+		ucharPtr(out),
+		&outLen,
+		nil,
+		ucharPtr(ciphertext),
+		C.ulonglong(len(ciphertext)),
+		ucharPtr(ad),
+		C.ulonglong(len(ad)),
+		ucharPtr(nonce),
+		ucharPtr(key),
+	)
+	if rc != 0 {
+		return nil, ErrDecryptionFailed
+	}
+	return out[:outLen], nil
+}
@@ -4,7 +4,9 @@ import "encoding/base64"
 
 type Ciphertext []byte
 
-// CiphertextFromBytes casts a ciphertext from a byte slice
+// CiphertextFromBytes casts a ciphertext from a byte slice. It does not know which algorithm
+// produced the ciphertext, so it only rejects lengths no algorithm can produce; prefer
+// SymmetricCiphertextFromBytes or AsymmetricCiphertextFromBytes when the algorithm is known
 func CiphertextFromBytes(b []byte) (Ciphertext, error) {
 	if uint64(len(b)) > CryptoSecretBoxMessageBytesMax {
 		return nil, ErrBadPlaintextLength
@@ -29,6 +31,57 @@ func CiphertextFromBase64(arg string) (Ciphertext, error) {
 	return CiphertextFromBytes(b)
 }
 
+// SymmetricCiphertextFromBytes casts a ciphertext produced by SymmetricEncrypt from a byte slice,
+// checking that it is long enough to hold a nonce and a MAC
+func SymmetricCiphertextFromBytes(b []byte) (Ciphertext, error) {
+	if err := checkCiphertextLength(len(b), CryptoSecretBoxNonceBytes+CryptoSecretBoxMacBytes, ErrBadSymmetricCiphertextLength); err != nil {
+		return nil, err
+	}
+	return Ciphertext(b), nil
+}
+
+// SymmetricCiphertextFromBase64 casts a ciphertext produced by SymmetricEncrypt from a base64
+// encoded string
+func SymmetricCiphertextFromBase64(arg string) (Ciphertext, error) {
+	b, err := base64.StdEncoding.DecodeString(arg)
+	if err != nil {
+		return nil, err
+	}
+	return SymmetricCiphertextFromBytes(b)
+}
+
+// AsymmetricCiphertextFromBytes casts a sealed box produced by AsymmetricEncrypt from a byte
+// slice, checking that it is long enough to hold an ephemeral public key and a MAC
+func AsymmetricCiphertextFromBytes(b []byte) (Ciphertext, error) {
+	if err := checkCiphertextLength(len(b), CryptoBoxSealBytes, ErrBadCiphertextLength); err != nil {
+		return nil, err
+	}
+	return Ciphertext(b), nil
+}
+
+// AsymmetricCiphertextFromBase64 casts a sealed box produced by AsymmetricEncrypt from a base64
+// encoded string
+func AsymmetricCiphertextFromBase64(arg string) (Ciphertext, error) {
+	b, err := base64.StdEncoding.DecodeString(arg)
+	if err != nil {
+		return nil, err
+	}
+	return AsymmetricCiphertextFromBytes(b)
+}
+
+// checkCiphertextLength returns errShort if a ciphertext of length n cannot hold overhead bytes,
+// and ErrCiphertextTooLong if the message it carries exceeds CryptoSecretBoxMessageBytesMax. The
+// limit is checked on the message part because adding the overhead to it can overflow
+func checkCiphertextLength(n, overhead int, errShort error) error {
+	if n < overhead {
+		return errShort
+	}
+	if uint64(n-overhead) > CryptoSecretBoxMessageBytesMax {
+		return ErrCiphertextTooLong
+	}
+	return nil
+}
+
 // ToBase64 converts a ciphertext to a base64 encoded string
 func (c Ciphertext) ToBase64() string {
 	return base64.StdEncoding.EncodeToString(c)
@@ -92,3 +92,89 @@ func TestCiphertextFromBase64(t *testing.T) {
 		})
 	}
 }
+
+func TestSymmetricCiphertextFromBytes(t *testing.T) {
+	overhead := CryptoSecretBoxNonceBytes + CryptoSecretBoxMacBytes
+	tests := []struct {
+		name  string
+		input []byte
+		err   error
+	}{
+		{name: "TestSymmetricCiphertextFromBytes success", input: make([]byte, overhead+1), err: nil},
+		{name: "TestSymmetricCiphertextFromBytes success empty message", input: make([]byte, overhead), err: nil},
+		{name: "TestSymmetricCiphertextFromBytes fail nil", input: nil, err: ErrBadSymmetricCiphertextLength},
+		{name: "TestSymmetricCiphertextFromBytes fail short", input: make([]byte, overhead-1), err: ErrBadSymmetricCiphertextLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := SymmetricCiphertextFromBytes(tt.input)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, Ciphertext(tt.input), c)
+			}
+		})
+	}
+}
+
+func TestAsymmetricCiphertextFromBytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		err   error
+	}{
+		{name: "TestAsymmetricCiphertextFromBytes success", input: make([]byte, CryptoBoxSealBytes+1), err: nil},
+		{name: "TestAsymmetricCiphertextFromBytes success empty message", input: make([]byte, CryptoBoxSealBytes), err: nil},
+		{name: "TestAsymmetricCiphertextFromBytes fail nil", input: nil, err: ErrBadCiphertextLength},
+		{name: "TestAsymmetricCiphertextFromBytes fail short", input: make([]byte, CryptoBoxSealBytes-1), err: ErrBadCiphertextLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := AsymmetricCiphertextFromBytes(tt.input)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, Ciphertext(tt.input), c)
+			}
+		})
+	}
+}
+
+func TestCiphertextFromBase64Algorithms(t *testing.T) {
+	sk, pk, err := NewKeyPair()
+	assert.NoError(t, err)
+	symmetric, err := SymmetricEncrypt(sk, Plaintext("message"), nil)
+	assert.NoError(t, err)
+	sealed, err := AsymmetricEncrypt(pk, Plaintext("message"))
+	assert.NoError(t, err)
+
+	c, err := SymmetricCiphertextFromBase64(symmetric.ToBase64())
+	assert.NoError(t, err)
+	assert.Equal(t, symmetric, c)
+	c, err = AsymmetricCiphertextFromBase64(sealed.ToBase64())
+	assert.NoError(t, err)
+	assert.Equal(t, sealed, c)
+
+	_, err = SymmetricCiphertextFromBase64(base64.StdEncoding.EncodeToString(symmetric[:10]))
+	assert.EqualError(t, err, ErrBadSymmetricCiphertextLength.Error())
+	_, err = AsymmetricCiphertextFromBase64(base64.StdEncoding.EncodeToString(sealed[:10]))
+	assert.EqualError(t, err, ErrBadCiphertextLength.Error())
+	_, err = SymmetricCiphertextFromBase64("not base64!")
+	assert.Error(t, err)
+	_, err = AsymmetricCiphertextFromBase64("not base64!")
+	assert.Error(t, err)
+}
+
+func TestCiphertextLengthErrors(t *testing.T) {
+	// the error messages are built from libsodium's sizes, which must be known before errors.go is
+	// initialized
+	assert.Equal(t, "invalid symmetric ciphertext length, need >= 40", ErrBadSymmetricCiphertextLength.Error())
+	assert.Equal(t, "invalid ciphertext length, need >= 48", ErrBadCiphertextLength.Error())
+	assert.Equal(t, "invald nonce length, need 24", ErrBadNonceLength.Error())
+	assert.Equal(t, "invalid secret key length, need 32", ErrBadSecretKeyLength.Error())
+	assert.NotEqual(t, "invalid input message length, need <= 0", ErrBadPlaintextLength.Error())
+	assert.NoError(t, checkCiphertextLength(64, 40, ErrBadSymmetricCiphertextLength))
+	assert.EqualError(t, checkCiphertextLength(39, 40, ErrBadSymmetricCiphertextLength), ErrBadSymmetricCiphertextLength.Error())
+}
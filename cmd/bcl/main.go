@@ -0,0 +1,289 @@
+// Command bcl generates keys and encrypts or decrypts data from the command line.
+//
+// Keys and ciphertexts are read and written as base64, while plaintexts are read and written as
+// raw bytes. Input defaults to stdin and output defaults to stdout.
+//
+// Usage:
+//
+//	bcl keygen  [-out file]
+//	bcl keypair [-secret file] [-public file]
+//	bcl pubkey  -key file [-out file]
+//	bcl encrypt -key file [-in file] [-out file]
+//	bcl decrypt -key file [-in file] [-out file]
+//	bcl seal    -pubkey file [-in file] [-out file]
+//	bcl open    -key file [-in file] [-out file]
+package main
+
+import (
+	"bcl"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var errUsage = errors.New("usage: bcl <keygen|keypair|pubkey|encrypt|decrypt|seal|open> [flags]")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "keygen":
+		return keygen(args[1:], stdout)
+	case "keypair":
+		return keypair(args[1:], stdout)
+	case "pubkey":
+		return pubkey(args[1:], stdout)
+	case "encrypt":
+		return encrypt(args[1:], stdin, stdout)
+	case "decrypt":
+		return decrypt(args[1:], stdin, stdout)
+	case "seal":
+		return seal(args[1:], stdin, stdout)
+	case "open":
+		return open(args[1:], stdin, stdout)
+	default:
+		return errUsage
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// helpError carries a subcommand's usage, printed by main in answer to -h
+type helpError struct {
+	usage string
+}
+
+func (e helpError) Error() string {
+	return e.usage
+}
+
+func (e helpError) Unwrap() error {
+	return flag.ErrHelp
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		var b strings.Builder
+		fmt.Fprintf(&b, "usage: bcl %s [flags]\n", fs.Name())
+		fs.SetOutput(&b)
+		fs.PrintDefaults()
+		return helpError{usage: strings.TrimSuffix(b.String(), "\n")}
+	} else if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected argument %q", errUsage, fs.Arg(0))
+	}
+	return nil
+}
+
+func keygen(args []string, stdout io.Writer) error {
+	fs := newFlagSet("keygen")
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	s, err := bcl.NewSecretKey()
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, stdout, []byte(s.ToBase64()+"\n"))
+}
+
+func keypair(args []string, stdout io.Writer) error {
+	fs := newFlagSet("keypair")
+	secretOut := fs.String("secret", "", "output file for the secret key (default stdout)")
+	publicOut := fs.String("public", "", "output file for the public key (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	s, p, err := bcl.NewKeyPair()
+	if err != nil {
+		return err
+	}
+	if err := writeOutput(*secretOut, stdout, []byte(s.ToBase64()+"\n")); err != nil {
+		return err
+	}
+	return writeOutput(*publicOut, stdout, []byte(p.ToBase64()+"\n"))
+}
+
+func pubkey(args []string, stdout io.Writer) error {
+	fs := newFlagSet("pubkey")
+	key := fs.String("key", "", "file containing a base64 encoded secret key")
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	s, err := readSecretKey(*key)
+	if err != nil {
+		return err
+	}
+	p, err := bcl.NewPublicKey(s)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, stdout, []byte(p.ToBase64()+"\n"))
+}
+
+func encrypt(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := newFlagSet("encrypt")
+	key := fs.String("key", "", "file containing a base64 encoded secret key")
+	in := fs.String("in", "", "input file (default stdin)")
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	s, err := readSecretKey(*key)
+	if err != nil {
+		return err
+	}
+	m, err := readInput(*in, stdin)
+	if err != nil {
+		return err
+	}
+	c, err := bcl.SymmetricEncrypt(s, m, nil)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, stdout, []byte(c.ToBase64()+"\n"))
+}
+
+func decrypt(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := newFlagSet("decrypt")
+	key := fs.String("key", "", "file containing a base64 encoded secret key")
+	in := fs.String("in", "", "input file (default stdin)")
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	s, err := readSecretKey(*key)
+	if err != nil {
+		return err
+	}
+	b, err := readInput(*in, stdin)
+	if err != nil {
+		return err
+	}
+	c, err := bcl.SymmetricCiphertextFromBase64(strings.TrimSpace(string(b)))
+	if err != nil {
+		return err
+	}
+	m, err := bcl.SymmetricDecrypt(s, c)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, stdout, m)
+}
+
+func seal(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := newFlagSet("seal")
+	pubkey := fs.String("pubkey", "", "file containing a base64 encoded public key")
+	in := fs.String("in", "", "input file (default stdin)")
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	p, err := readPublicKey(*pubkey)
+	if err != nil {
+		return err
+	}
+	m, err := readInput(*in, stdin)
+	if err != nil {
+		return err
+	}
+	c, err := bcl.AsymmetricEncrypt(p, m)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, stdout, []byte(c.ToBase64()+"\n"))
+}
+
+func open(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := newFlagSet("open")
+	key := fs.String("key", "", "file containing a base64 encoded secret key")
+	in := fs.String("in", "", "input file (default stdin)")
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	s, err := readSecretKey(*key)
+	if err != nil {
+		return err
+	}
+	b, err := readInput(*in, stdin)
+	if err != nil {
+		return err
+	}
+	c, err := bcl.AsymmetricCiphertextFromBase64(strings.TrimSpace(string(b)))
+	if err != nil {
+		return err
+	}
+	m, err := bcl.AsymmetricDecrypt(s, c)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, stdout, m)
+}
+
+func readInput(path string, stdin io.Reader) ([]byte, error) {
+	if path == "" || path == "-" {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(path)
+}
+
+func writeOutput(path string, stdout io.Writer, b []byte) error {
+	if path == "" || path == "-" {
+		_, err := stdout.Write(b)
+		return err
+	}
+	return os.WriteFile(path, b, 0600)
+}
+
+func readKeyFile(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("%w: missing key file", errUsage)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func readSecretKey(path string) (bcl.SecretKey, error) {
+	b, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return bcl.SecretKeyFromBase64(b)
+}
+
+func readPublicKey(path string) (bcl.PublicKey, error) {
+	b, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return bcl.PublicKeyFromBase64(b)
+}
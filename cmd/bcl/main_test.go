@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunSymmetric(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	assert.NoError(t, run([]string{"keygen", "-out", keyFile}, nil, nil))
+
+	var enc bytes.Buffer
+	assert.NoError(t, run([]string{"encrypt", "-key", keyFile}, strings.NewReader("Hello!"), &enc))
+
+	var dec bytes.Buffer
+	assert.NoError(t, run([]string{"decrypt", "-key", keyFile}, &enc, &dec))
+	assert.Equal(t, "Hello!", dec.String())
+}
+
+func TestRunAsymmetric(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "secret")
+	publicFile := filepath.Join(dir, "public")
+	assert.NoError(t, run([]string{"keypair", "-secret", secretFile, "-public", publicFile}, nil, nil))
+
+	var pub bytes.Buffer
+	assert.NoError(t, run([]string{"pubkey", "-key", secretFile}, nil, &pub))
+	expected, err := os.ReadFile(publicFile)
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), pub.String())
+
+	plaintextFile := filepath.Join(dir, "plaintext")
+	ciphertextFile := filepath.Join(dir, "ciphertext")
+	assert.NoError(t, os.WriteFile(plaintextFile, []byte("Hi!"), 0600))
+	assert.NoError(t, run([]string{"seal", "-pubkey", publicFile, "-in", plaintextFile, "-out", ciphertextFile}, nil, nil))
+
+	var dec bytes.Buffer
+	assert.NoError(t, run([]string{"open", "-key", secretFile, "-in", ciphertextFile}, nil, &dec))
+	assert.Equal(t, "Hi!", dec.String())
+}
+
+func TestRunErrors(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	assert.NoError(t, os.WriteFile(keyFile, []byte("not a key"), 0600))
+
+	tests := []struct {
+		name  string
+		args  []string
+		usage bool
+	}{
+		{name: "TestRunErrors no command", args: []string{}, usage: true},
+		{name: "TestRunErrors unknown command", args: []string{"frobnicate"}, usage: true},
+		{name: "TestRunErrors unknown flag", args: []string{"keygen", "-bogus"}, usage: true},
+		{name: "TestRunErrors missing key", args: []string{"encrypt"}, usage: true},
+		{name: "TestRunErrors flag of another command", args: []string{"keygen", "-in", keyFile}, usage: true},
+		{name: "TestRunErrors key flag for seal", args: []string{"seal", "-key", keyFile}, usage: true},
+		{name: "TestRunErrors pubkey flag for open", args: []string{"open", "-pubkey", keyFile}, usage: true},
+		{name: "TestRunErrors unexpected argument", args: []string{"encrypt", "-key", keyFile, "extra"}, usage: true},
+		{name: "TestRunErrors bad key", args: []string{"encrypt", "-key", keyFile}, usage: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := run(tt.args, strings.NewReader("Hello!"), &bytes.Buffer{})
+			assert.Error(t, err)
+			assert.Equal(t, tt.usage, strings.HasPrefix(err.Error(), errUsage.Error()))
+		})
+	}
+}
+
+func TestRunHelp(t *testing.T) {
+	err := run([]string{"seal", "-h"}, nil, nil)
+	assert.ErrorIs(t, err, flag.ErrHelp)
+	assert.NotErrorIs(t, err, errUsage)
+	assert.True(t, strings.HasPrefix(err.Error(), "usage: bcl seal [flags]\n"))
+	assert.Contains(t, err.Error(), "-pubkey")
+	assert.Contains(t, err.Error(), "-in")
+	assert.NotContains(t, err.Error(), "-key ")
+}
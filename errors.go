@@ -6,5 +6,83 @@ var ErrBadNonceLength = fmt.Errorf("invald nonce length, need %d", CryptoSecretB
 var ErrBadSecretKeyLength = fmt.Errorf("invalid secret key length, need %d", CryptoSecretBoxKeyBytes)
 var ErrBadPublicKeyLength = fmt.Errorf("invalid public key length, need %d", CryptoBoxPublicKeyBytes)
 var ErrBadPlaintextLength = fmt.Errorf("invalid input message length, need <= %d", CryptoSecretBoxMessageBytesMax)
+// Deprecated: ErrBadDecryptionOutput is never returned. SymmetricDecrypt rejects short ciphertexts
+// with ErrBadSymmetricCiphertextLength before decrypting
 var ErrBadDecryptionOutput = fmt.Errorf("decryption output too short, need >= %d", CryptoSecretBoxZeroBytes)
 var ErrBadCiphertextLength = fmt.Errorf("invalid ciphertext length, need >= %d", CryptoBoxSealBytes)
+var ErrBadNoncePrefixLength = fmt.Errorf("invalid nonce prefix length")
+var ErrNonceSequencePrefixMismatch = fmt.Errorf("stored nonce does not match the nonce sequence prefix")
+var ErrNonceSequenceExhausted = fmt.Errorf("nonce sequence exhausted")
+var ErrBadBlockSize = fmt.Errorf("invalid padding block size")
+var ErrBadPadding = fmt.Errorf("invalid padding")
+var ErrHandshakeFailed = fmt.Errorf("secure connection handshake failed")
+var ErrBadSecureConfig = fmt.Errorf("secure connection config requires a secret key and a VerifyPeer function")
+var ErrUnknownPeer = fmt.Errorf("secure connection peer static key not allowed")
+var ErrBadRecord = fmt.Errorf("invalid secure connection record")
+var ErrDecryptionFailed = fmt.Errorf("decryption failed")
+var ErrNoiseBadPattern = fmt.Errorf("invalid noise handshake pattern")
+var ErrNoiseMissingKey = fmt.Errorf("noise handshake is missing a required key")
+var ErrNoiseOutOfOrder = fmt.Errorf("noise handshake message out of order")
+var ErrNoiseMessageTooLong = fmt.Errorf("noise message too long")
+var ErrNoiseShortMessage = fmt.Errorf("noise message too short")
+var ErrNoiseNonceExhausted = fmt.Errorf("noise cipher state nonce exhausted")
+var ErrBadFile = fmt.Errorf("encrypted file is corrupted or truncated")
+var ErrBadFileHeader = fmt.Errorf("invalid encrypted file header")
+var ErrBadChunkSize = fmt.Errorf("invalid encrypted file chunk size")
+var ErrBadOffset = fmt.Errorf("invalid offset")
+var ErrFileClosed = fmt.Errorf("encrypted file already closed")
+var ErrBadBech32 = fmt.Errorf("invalid bech32 string")
+var ErrNoAgeRecipients = fmt.Errorf("no age recipients supplied")
+var ErrNoAgeIdentityMatched = fmt.Errorf("no age identity matched any of the file's recipients")
+var ErrBadAgeHeader = fmt.Errorf("invalid age header")
+var ErrBadAgePayload = fmt.Errorf("invalid or truncated age payload")
+var ErrBadSigningKeyLength = fmt.Errorf("invalid signing key length")
+var ErrBadVerifyKeyLength = fmt.Errorf("invalid verify key length")
+var ErrBadSignature = fmt.Errorf("invalid signature")
+var ErrBadPEM = fmt.Errorf("invalid or unsupported PEM key")
+var ErrBadJWK = fmt.Errorf("invalid or unsupported JWK")
+var ErrBadOpenSSHKey = fmt.Errorf("invalid or unsupported OpenSSH public key")
+var ErrBadPwHashParams = fmt.Errorf("invalid argon2id parameters")
+var ErrBadKeyFile = fmt.Errorf("invalid encrypted key file")
+var ErrBadPassphrase = fmt.Errorf("wrong passphrase or corrupted key file")
+var ErrKeyFileLimitsTooHigh = fmt.Errorf("key file argon2id limits exceed the sensitive limits")
+var ErrBadVerifyKey = fmt.Errorf("verify key is not a valid Ed25519 public key")
+var ErrBadSeedLength = fmt.Errorf("invalid seed length")
+var ErrBadMnemonic = fmt.Errorf("invalid mnemonic")
+var ErrBadMnemonicChecksum = fmt.Errorf("invalid mnemonic checksum")
+var ErrAES256GCMUnavailable = fmt.Errorf("AES-256-GCM is not available on this CPU, which lacks hardware AES support")
+var ErrBadSymmetricCiphertextLength = fmt.Errorf("invalid symmetric ciphertext length, need >= %d", CryptoSecretBoxNonceBytes+CryptoSecretBoxMacBytes)
+var ErrBadAES256GCMNonceLength = fmt.Errorf("invalid AES-256-GCM nonce length, need %d", CryptoAEADAES256GCMNPubBytes)
+var ErrBadAES256GCMCiphertextLength = fmt.Errorf("invalid AES-256-GCM ciphertext length, need >= %d", CryptoAEADAES256GCMNPubBytes+CryptoAEADAES256GCMABytes)
+var ErrCiphertextTooLong = fmt.Errorf("invalid ciphertext length, need <= %d bytes beyond the algorithm's overhead", CryptoSecretBoxMessageBytesMax)
+var ErrLowOrderPublicKey = fmt.Errorf("invalid public key, X25519 output is all zeros")
+var ErrBadExpandLength = fmt.Errorf("invalid expand_message_xmd output length")
+var ErrBadRistrettoPoint = fmt.Errorf("invalid ristretto255 point encoding")
+var ErrBadRistrettoScalar = fmt.Errorf("invalid ristretto255 scalar encoding")
+var ErrBadRistrettoHashLength = fmt.Errorf("invalid ristretto255 hash length, need %d", CryptoCoreRistretto255HashBytes)
+var ErrRistrettoIdentity = fmt.Errorf("ristretto255 operation produced the identity element")
+var ErrRistrettoZeroScalar = fmt.Errorf("ristretto255 scalar is zero")
+var ErrBadOPRFMode = fmt.Errorf("invalid OPRF mode")
+var ErrBadOPRFBatch = fmt.Errorf("invalid OPRF batch, inputs, blinds and elements must be non-empty and of equal length")
+var ErrBadOPRFProof = fmt.Errorf("invalid OPRF proof encoding")
+var ErrOPRFInvalidInput = fmt.Errorf("OPRF input hashes to the identity element")
+var ErrOPRFVerifyFailed = fmt.Errorf("OPRF proof verification failed")
+var ErrOPRFDeriveKeyPair = fmt.Errorf("OPRF key pair derivation failed")
+var ErrBadOPAQUEConfig = fmt.Errorf("OPAQUE config requires nonzero argon2id limits")
+var ErrBadOPAQUEMessage = fmt.Errorf("invalid OPAQUE message")
+var ErrBadOPAQUERecord = fmt.Errorf("invalid OPAQUE registration record")
+var ErrBadOPAQUEOPRFSeed = fmt.Errorf("invalid OPAQUE OPRF seed length, need %d", OPAQUEOPRFSeedBytes)
+var ErrOPAQUEOutOfOrder = fmt.Errorf("OPAQUE method called out of order")
+var ErrOPAQUEEnvelopeRecovery = fmt.Errorf("OPAQUE envelope recovery failed, the password may be wrong")
+var ErrOPAQUEServerAuthentication = fmt.Errorf("OPAQUE server authentication failed")
+var ErrOPAQUEClientAuthentication = fmt.Errorf("OPAQUE client authentication failed")
+var ErrBadShamirParams = fmt.Errorf("invalid Shamir parameters, need 2 <= k <= n <= 255")
+var ErrBadShare = fmt.Errorf("invalid Shamir share")
+var ErrBadShareChecksum = fmt.Errorf("invalid Shamir share checksum")
+var ErrNotEnoughShares = fmt.Errorf("not enough Shamir shares to recover the secret")
+var ErrDuplicateShare = fmt.Errorf("duplicate Shamir share index")
+var ErrShareMismatch = fmt.Errorf("Shamir shares do not belong to the same secret")
+var ErrBadPartialDecryption = fmt.Errorf("invalid partial decryption")
+var ErrBadHPKEKeyMaterial = fmt.Errorf("HPKE key derivation requires at least 32 bytes of input key material")
+var ErrBadHPKEExportLength = fmt.Errorf("invalid HPKE export length")
+var ErrHPKEMessageLimit = fmt.Errorf("HPKE context message limit reached")
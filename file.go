@@ -0,0 +1,302 @@
+package bcl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sync"
+)
+
+// The encrypted file format consists of a header, a sequence of chunks and a footer:
+//
+//	header: magic "BCLF" | version (1 byte) | chunk size (uint32) | salt (16 bytes)
+//	chunk:  secretbox MAC | ciphertext of up to chunk size bytes
+//	footer: secretbox MAC | ciphertext of chunk count (uint64) and plaintext length (uint64)
+//
+// Every chunk and the footer are sealed with a file key derived from the secret key and the whole
+// header, and the nonce of each one encodes its index and whether it is the footer, so tampering
+// with the header, reordering chunks or truncating the file causes decryption to fail
+
+const (
+	fileVersion    = 1
+	fileHeaderSize = 4 + 1 + 4 + 16
+	fileFooterSize = 16
+
+	// DefaultFileChunkSize is the chunk size used by NewFileEncryptor when none is specified
+	DefaultFileChunkSize = 64 * 1024
+	// MaxFileChunkSize is the largest chunk size accepted by NewFileEncryptor and NewFileDecryptor
+	MaxFileChunkSize = 16 * 1024 * 1024
+)
+
+var fileMagic = []byte("BCLF")
+
+func fileKey(secretKey SecretKey, header []byte) (SecretKey, error) {
+	if len(secretKey) != CryptoSecretBoxKeyBytes {
+		return nil, ErrBadSecretKeyLength
+	}
+	return genericHash(CryptoSecretBoxKeyBytes, secretKey, header)
+}
+
+func fileNonce(index uint64, footer bool) Nonce {
+	nonce := make([]byte, CryptoSecretBoxNonceBytes)
+	binary.BigEndian.PutUint64(nonce, index)
+	if footer {
+		nonce[8] = 1
+	}
+	return nonce
+}
+
+func sealFileChunk(key SecretKey, index uint64, footer bool, plaintext []byte) ([]byte, error) {
+	sealed, err := SymmetricEncrypt(key, plaintext, fileNonce(index, footer))
+	if err != nil {
+		return nil, err
+	}
+	return sealed[CryptoSecretBoxNonceBytes:], nil
+}
+
+func openFileChunk(key SecretKey, index uint64, footer bool, sealed []byte) ([]byte, error) {
+	ciphertext := append([]byte(fileNonce(index, footer)), sealed...)
+	plaintext, err := SymmetricDecrypt(key, ciphertext)
+	if err != nil {
+		return nil, ErrBadFile
+	}
+	return plaintext, nil
+}
+
+// FileEncryptor is an io.WriteCloser that encrypts data into the chunked file format read by
+// FileDecryptor. Close must be called to write the final chunk and the footer
+type FileEncryptor struct {
+	w         io.Writer
+	key       SecretKey
+	chunkSize int
+	buf       []byte
+	index     uint64
+	length    uint64
+	closed    bool
+}
+
+// NewFileEncryptor writes an encrypted file header to w and returns a FileEncryptor that encrypts
+// data written to it using the supplied secret key. If chunkSize is zero, DefaultFileChunkSize is used
+func NewFileEncryptor(w io.Writer, secretKey SecretKey, chunkSize int) (*FileEncryptor, error) {
+	if chunkSize == 0 {
+		chunkSize = DefaultFileChunkSize
+	}
+	if chunkSize < 0 || chunkSize > MaxFileChunkSize {
+		return nil, ErrBadChunkSize
+	}
+
+	header := make([]byte, fileHeaderSize)
+	copy(header, fileMagic)
+	header[4] = fileVersion
+	binary.BigEndian.PutUint32(header[5:], uint32(chunkSize))
+	if err := randomBytes(nil, header[9:]); err != nil {
+		return nil, err
+	}
+	key, err := fileKey(secretKey, header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &FileEncryptor{
+		w:         w,
+		key:       key,
+		chunkSize: chunkSize,
+		buf:       make([]byte, 0, chunkSize),
+	}, nil
+}
+
+// Write encrypts and writes every complete chunk of data, buffering the remainder
+func (f *FileEncryptor) Write(p []byte) (int, error) {
+	if f.closed {
+		return 0, ErrFileClosed
+	}
+	n := 0
+	for len(p) > 0 {
+		take := min(len(p), f.chunkSize-len(f.buf))
+		f.buf = append(f.buf, p[:take]...)
+		p = p[take:]
+		n += take
+		if len(f.buf) == f.chunkSize {
+			if err := f.flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (f *FileEncryptor) flush() error {
+	sealed, err := sealFileChunk(f.key, f.index, false, f.buf)
+	if err != nil {
+		return err
+	}
+	if _, err := f.w.Write(sealed); err != nil {
+		return err
+	}
+	f.index++
+	f.length += uint64(len(f.buf))
+	f.buf = f.buf[:0]
+	return nil
+}
+
+// Close writes any buffered data as the final chunk followed by the footer. It does not close the
+// underlying writer
+func (f *FileEncryptor) Close() error {
+	if f.closed {
+		return ErrFileClosed
+	}
+	if len(f.buf) > 0 {
+		if err := f.flush(); err != nil {
+			return err
+		}
+	}
+
+	footer := make([]byte, 16)
+	binary.BigEndian.PutUint64(footer, f.index)
+	binary.BigEndian.PutUint64(footer[8:], f.length)
+	sealed, err := sealFileChunk(f.key, f.index, true, footer)
+	if err != nil {
+		return err
+	}
+	if _, err := f.w.Write(sealed); err != nil {
+		return err
+	}
+	f.closed = true
+	return nil
+}
+
+// FileDecryptor decrypts a file written by FileEncryptor. It implements io.ReaderAt over the
+// plaintext, decrypting only the chunks that each read touches
+type FileDecryptor struct {
+	r         io.ReaderAt
+	key       SecretKey
+	chunkSize int64
+	chunks    uint64
+	length    int64
+
+	mu         sync.Mutex
+	cacheIndex uint64
+	cache      []byte
+}
+
+// NewFileDecryptor reads and authenticates the header and footer of an encrypted file of the given
+// size, returning a FileDecryptor for random access to its plaintext
+func NewFileDecryptor(r io.ReaderAt, size int64, secretKey SecretKey) (*FileDecryptor, error) {
+	macBytes := int64(CryptoSecretBoxMacBytes)
+	if size < fileHeaderSize+fileFooterSize+macBytes {
+		return nil, ErrBadFile
+	}
+	header := make([]byte, fileHeaderSize)
+	if err := readFullAt(r, header, 0); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header[:4], fileMagic) || header[4] != fileVersion {
+		return nil, ErrBadFileHeader
+	}
+	chunkSize := int64(binary.BigEndian.Uint32(header[5:]))
+	if chunkSize == 0 || chunkSize > MaxFileChunkSize {
+		return nil, ErrBadChunkSize
+	}
+	key, err := fileKey(secretKey, header)
+	if err != nil {
+		return nil, err
+	}
+
+	// the chunk count follows from the file size, and the footer only authenticates if it is correct
+	body := size - fileHeaderSize - fileFooterSize - macBytes
+	chunks := body / (chunkSize + macBytes)
+	if rem := body % (chunkSize + macBytes); rem > 0 {
+		if rem <= macBytes {
+			return nil, ErrBadFile
+		}
+		chunks++
+	}
+
+	sealedFooter := make([]byte, fileFooterSize+macBytes)
+	if err := readFullAt(r, sealedFooter, size-int64(len(sealedFooter))); err != nil {
+		return nil, err
+	}
+	footer, err := openFileChunk(key, uint64(chunks), true, sealedFooter)
+	if err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint64(footer[8:])
+	if binary.BigEndian.Uint64(footer) != uint64(chunks) || length != uint64(body-chunks*macBytes) {
+		return nil, ErrBadFile
+	}
+
+	return &FileDecryptor{
+		r:         r,
+		key:       key,
+		chunkSize: chunkSize,
+		chunks:    uint64(chunks),
+		length:    int64(length),
+	}, nil
+}
+
+// Size returns the length of the plaintext
+func (f *FileDecryptor) Size() int64 {
+	return f.length
+}
+
+// ReadAt reads len(p) bytes of plaintext starting at offset off
+func (f *FileDecryptor) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrBadOffset
+	}
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		if pos >= f.length {
+			return n, io.EOF
+		}
+		chunk, err := f.chunk(uint64(pos / f.chunkSize))
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], chunk[pos%f.chunkSize:])
+	}
+	return n, nil
+}
+
+func (f *FileDecryptor) chunk(index uint64) ([]byte, error) {
+	f.mu.Lock()
+	if f.cache != nil && f.cacheIndex == index {
+		chunk := f.cache
+		f.mu.Unlock()
+		return chunk, nil
+	}
+	f.mu.Unlock()
+
+	macBytes := int64(CryptoSecretBoxMacBytes)
+	start := fileHeaderSize + int64(index)*(f.chunkSize+macBytes)
+	size := f.chunkSize
+	if index == f.chunks-1 {
+		size = f.length - int64(index)*f.chunkSize
+	}
+	sealed := make([]byte, size+macBytes)
+	if err := readFullAt(f.r, sealed, start); err != nil {
+		return nil, err
+	}
+	chunk, err := openFileChunk(f.key, index, false, sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	f.cacheIndex, f.cache = index, chunk
+	f.mu.Unlock()
+	return chunk, nil
+}
+
+// readFullAt fills p from r at off, accepting the io.EOF a ReaderAt may return alongside a full read
+// that ends at the end of its input
+func readFullAt(r io.ReaderAt, p []byte, off int64) error {
+	n, err := r.ReadAt(p, off)
+	if err == io.EOF && n == len(p) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,218 @@
+package bcl
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encryptFile(t *testing.T, secretKey SecretKey, data []byte, chunkSize int) []byte {
+	var buf bytes.Buffer
+	f, err := NewFileEncryptor(&buf, secretKey, chunkSize)
+	assert.NoError(t, err)
+	_, err = f.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	return buf.Bytes()
+}
+
+func TestFileEncryptor(t *testing.T) {
+	sk, err := NewSecretKey()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		length    int
+		chunkSize int
+	}{
+		{name: "TestFileEncryptor empty", length: 0, chunkSize: 16},
+		{name: "TestFileEncryptor partial chunk", length: 10, chunkSize: 16},
+		{name: "TestFileEncryptor exact chunks", length: 64, chunkSize: 16},
+		{name: "TestFileEncryptor many chunks", length: 1000, chunkSize: 16},
+		{name: "TestFileEncryptor default chunk size", length: 200000, chunkSize: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := make([]byte, tt.length)
+			for i := range data {
+				data[i] = byte(i)
+			}
+			enc := encryptFile(t, sk, data, tt.chunkSize)
+
+			f, err := NewFileDecryptor(bytes.NewReader(enc), int64(len(enc)), sk)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(tt.length), f.Size())
+
+			dec, err := io.ReadAll(io.NewSectionReader(f, 0, f.Size()))
+			assert.NoError(t, err)
+			assert.Equal(t, data, dec)
+		})
+	}
+}
+
+func TestFileDecryptorReadAt(t *testing.T) {
+	sk, err := NewSecretKey()
+	assert.NoError(t, err)
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	enc := encryptFile(t, sk, data, 64)
+	f, err := NewFileDecryptor(bytes.NewReader(enc), int64(len(enc)), sk)
+	assert.NoError(t, err)
+
+	buf := make([]byte, 100)
+	n, err := f.ReadAt(buf, 500)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, n)
+	assert.Equal(t, data[500:600], buf)
+
+	n, err = f.ReadAt(buf, 950)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 50, n)
+	assert.Equal(t, data[950:], buf[:n])
+
+	_, err = f.ReadAt(buf, -1)
+	assert.EqualError(t, err, ErrBadOffset.Error())
+}
+
+// eofReaderAt returns io.EOF alongside any read that reaches the end of its data, as the
+// io.ReaderAt contract permits
+type eofReaderAt struct {
+	r *bytes.Reader
+}
+
+func (e eofReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := e.r.ReadAt(p, off)
+	if err == nil && off+int64(n) == e.r.Size() {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func TestFileDecryptorEOFReaderAt(t *testing.T) {
+	sk, err := NewSecretKey()
+	assert.NoError(t, err)
+	data := make([]byte, 200)
+	for i := range data {
+		data[i] = byte(i * 3)
+	}
+	enc := encryptFile(t, sk, data, 64)
+	f, err := NewFileDecryptor(eofReaderAt{bytes.NewReader(enc)}, int64(len(enc)), sk)
+	assert.NoError(t, err)
+
+	buf := make([]byte, len(data))
+	n, err := f.ReadAt(buf, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, len(data), n)
+	assert.Equal(t, data, buf)
+}
+
+func TestFileDecryptorTamper(t *testing.T) {
+	sk, err := NewSecretKey()
+	assert.NoError(t, err)
+	data := bytes.Repeat([]byte("bcl"), 100)
+	chunkSize := 64
+	sealedChunk := chunkSize + CryptoSecretBoxMacBytes
+
+	tests := []struct {
+		name     string
+		wrongKey bool
+		modify   func(enc []byte) []byte
+		openErr  error
+		readErr  error
+	}{
+		{
+			name:     "TestFileDecryptorTamper wrong key",
+			wrongKey: true,
+			modify: func(enc []byte) []byte {
+				return enc
+			},
+			openErr: ErrBadFile,
+		},
+		{
+			name: "TestFileDecryptorTamper header",
+			modify: func(enc []byte) []byte {
+				enc[fileHeaderSize-1] ^= 0x01
+				return enc
+			},
+			openErr: ErrBadFile,
+		},
+		{
+			name: "TestFileDecryptorTamper magic",
+			modify: func(enc []byte) []byte {
+				enc[0] = 'X'
+				return enc
+			},
+			openErr: ErrBadFileHeader,
+		},
+		{
+			name: "TestFileDecryptorTamper truncated chunk",
+			modify: func(enc []byte) []byte {
+				return append(enc[:fileHeaderSize+sealedChunk], enc[len(enc)-fileFooterSize-CryptoSecretBoxMacBytes:]...)
+			},
+			openErr: ErrBadFile,
+		},
+		{
+			name: "TestFileDecryptorTamper truncated footer",
+			modify: func(enc []byte) []byte {
+				return enc[:len(enc)-1]
+			},
+			openErr: ErrBadFile,
+		},
+		{
+			name: "TestFileDecryptorTamper reordered chunks",
+			modify: func(enc []byte) []byte {
+				first := append([]byte{}, enc[fileHeaderSize:fileHeaderSize+sealedChunk]...)
+				copy(enc[fileHeaderSize:], enc[fileHeaderSize+sealedChunk:fileHeaderSize+2*sealedChunk])
+				copy(enc[fileHeaderSize+sealedChunk:], first)
+				return enc
+			},
+			readErr: ErrBadFile,
+		},
+		{
+			name: "TestFileDecryptorTamper chunk",
+			modify: func(enc []byte) []byte {
+				enc[fileHeaderSize+10] ^= 0x01
+				return enc
+			},
+			readErr: ErrBadFile,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := sk
+			if tt.wrongKey {
+				key, err = NewSecretKey()
+				assert.NoError(t, err)
+			}
+			enc := tt.modify(encryptFile(t, sk, data, chunkSize))
+			f, err := NewFileDecryptor(bytes.NewReader(enc), int64(len(enc)), key)
+			if tt.openErr != nil {
+				assert.EqualError(t, err, tt.openErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			_, err = f.ReadAt(make([]byte, 10), 0)
+			assert.EqualError(t, err, tt.readErr.Error())
+		})
+	}
+}
+
+func TestFileEncryptorErrors(t *testing.T) {
+	sk, err := NewSecretKey()
+	assert.NoError(t, err)
+
+	_, err = NewFileEncryptor(&bytes.Buffer{}, sk, -1)
+	assert.EqualError(t, err, ErrBadChunkSize.Error())
+	_, err = NewFileEncryptor(&bytes.Buffer{}, sk[:8], 0)
+	assert.EqualError(t, err, ErrBadSecretKeyLength.Error())
+
+	f, err := NewFileEncryptor(&bytes.Buffer{}, sk, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	_, err = f.Write([]byte("late"))
+	assert.EqualError(t, err, ErrFileClosed.Error())
+}
@@ -0,0 +1,104 @@
+package bcl
+
+/*
+int crypto_generichash(unsigned char *out, size_t outlen, const unsigned char *in, unsigned long long inlen, const unsigned char *key, size_t keylen);
+int crypto_hash_sha256(unsigned char *out, const unsigned char *in, unsigned long long inlen);
+int crypto_hash_sha512(unsigned char *out, const unsigned char *in, unsigned long long inlen);
+int sodium_memcmp(const void * const b1_, const void * const b2_, size_t len);
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// genericHash computes the (optionally keyed) BLAKE2b hash of the concatenated inputs, with an
+// output of the given size
+func genericHash(size int, key []byte, inputs ...[]byte) ([]byte, error) {
+	var in []byte
+	for _, input := range inputs {
+		in = append(in, input...)
+	}
+
+	out := make([]byte, size)
+	rc := C.crypto_generichash(
+		ucharPtr(out),
+		C.size_t(size),
+		ucharPtr(in),
+		C.ulonglong(len(in)),
+		ucharPtr(key),
+		C.size_t(len(key)),
+	)
+	if rc != 0 {
+		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+	}
+	return out, nil
+}
+
+// sha256Sum computes the SHA-256 hash of the concatenated inputs
+func sha256Sum(inputs ...[]byte) []byte {
+	var in []byte
+	for _, input := range inputs {
+		in = append(in, input...)
+	}
+	out := make([]byte, 32)
+	// crypto_hash_sha256 cannot fail
+	C.crypto_hash_sha256(ucharPtr(out), ucharPtr(in), C.ulonglong(len(in)))
+	return out
+}
+
+// sha512Sum computes the SHA-512 hash of the concatenated inputs
+func sha512Sum(inputs ...[]byte) []byte {
+	var in []byte
+	for _, input := range inputs {
+		in = append(in, input...)
+	}
+	out := make([]byte, 64)
+	// crypto_hash_sha512 cannot fail
+	C.crypto_hash_sha512(ucharPtr(out), ucharPtr(in), C.ulonglong(len(in)))
+	return out
+}
+
+// expandMessageXMD implements expand_message_xmd from RFC 9380, section 5.3.1, with SHA-512,
+// producing length uniformly random bytes from a message and a domain separation tag
+func expandMessageXMD(msg, dst []byte, length int) ([]byte, error) {
+	const hashSize, blockSize = 64, 128
+	ell := (length + hashSize - 1) / hashSize
+	if length <= 0 || ell > 255 || length > 65535 {
+		return nil, ErrBadExpandLength
+	}
+	if len(dst) > 255 {
+		dst = sha512Sum([]byte("H2C-OVERSIZE-DST-"), dst)
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	b0 := sha512Sum(make([]byte, blockSize), msg, []byte{byte(length >> 8), byte(length), 0}, dstPrime)
+	out := make([]byte, 0, ell*hashSize)
+	bi := sha512Sum(b0, []byte{1}, dstPrime)
+	out = append(out, bi...)
+	for i := 2; i <= ell; i++ {
+		x := make([]byte, hashSize)
+		for j := range x {
+			x[j] = b0[j] ^ bi[j]
+		}
+		bi = sha512Sum(x, []byte{byte(i)}, dstPrime)
+		out = append(out, bi...)
+	}
+	return out[:length], nil
+}
+
+// constantTimeEqual returns whether two byte slices are equal, in time that depends only on their length
+func constantTimeEqual(a, b []byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if len(a) == 0 {
+		return true
+	}
+	rc := C.sodium_memcmp(
+		unsafe.Pointer(&a[0]),
+		unsafe.Pointer(&b[0]),
+		C.size_t(len(a)),
+	)
+	return rc == 0
+}
@@ -0,0 +1,337 @@
+package bcl
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Hybrid Public Key Encryption as specified in RFC 9180, with the single cipher suite
+// DHKEM(X25519, HKDF-SHA256), HKDF-SHA256 and ChaCha20Poly1305, in the base and auth modes. The
+// sender encapsulates a fresh shared secret to the recipient's X25519 public key, optionally
+// authenticated with its own key pair, and both sides derive a context that encrypts a sequence of
+// messages and exports further secrets. Unlike sealed boxes, the output interoperates with other
+// HPKE implementations, such as those used by MLS and Oblivious HTTP
+
+const (
+	hpkeModeBase = 0x00
+	hpkeModeAuth = 0x02
+
+	hpkeKEMID  = 0x0020
+	hpkeKDFID  = 0x0001
+	hpkeAEADID = 0x0003
+
+	hpkeHashBytes  = 32
+	hpkeNonceBytes = 12
+)
+
+// HPKEMaxExportLength is the largest secret that can be exported from an HPKE context
+var HPKEMaxExportLength = 255 * hpkeHashBytes
+
+var (
+	hpkeKEMSuiteID = []byte{'K', 'E', 'M', hpkeKEMID >> 8, hpkeKEMID & 0xff}
+	hpkeSuiteID    = []byte{'H', 'P', 'K', 'E', hpkeKEMID >> 8, hpkeKEMID & 0xff, hpkeKDFID >> 8, hpkeKDFID & 0xff, hpkeAEADID >> 8, hpkeAEADID & 0xff}
+)
+
+// hpkeLabeledExtract is LabeledExtract from RFC 9180, section 4
+func hpkeLabeledExtract(suiteID, salt []byte, label string, ikm []byte) ([]byte, error) {
+	labeled := append([]byte("HPKE-v1"), suiteID...)
+	labeled = append(append(labeled, label...), ikm...)
+	return hkdfSHA256Extract(salt, labeled)
+}
+
+// hpkeLabeledExpand is LabeledExpand from RFC 9180, section 4
+func hpkeLabeledExpand(suiteID, prk []byte, label string, info []byte, length int) ([]byte, error) {
+	labeled := binary.BigEndian.AppendUint16(nil, uint16(length))
+	labeled = append(append(labeled, "HPKE-v1"...), suiteID...)
+	labeled = append(append(labeled, label...), info...)
+	return hkdfSHA256Expand(prk, labeled, length)
+}
+
+// DeriveHPKEKeyPair deterministically derives an X25519 key pair from at least 32 bytes of input
+// key material, as DeriveKeyPair in RFC 9180, section 7.1.3
+func DeriveHPKEKeyPair(ikm []byte) (SecretKey, PublicKey, error) {
+	if len(ikm) < hpkeHashBytes {
+		return nil, nil, ErrBadHPKEKeyMaterial
+	}
+	prk, err := hpkeLabeledExtract(hpkeKEMSuiteID, nil, "dkp_prk", ikm)
+	if err != nil {
+		return nil, nil, err
+	}
+	sk, err := hpkeLabeledExpand(hpkeKEMSuiteID, prk, "sk", nil, CryptoSecretBoxKeyBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	pk, err := ScalarMultBase(sk)
+	if err != nil {
+		return nil, nil, err
+	}
+	return SecretKey(sk), pk, nil
+}
+
+// hpkeExtractAndExpand derives the KEM shared secret from the Diffie-Hellman outputs and the
+// KEM context
+func hpkeExtractAndExpand(dh, kemContext []byte) ([]byte, error) {
+	prk, err := hpkeLabeledExtract(hpkeKEMSuiteID, nil, "eae_prk", dh)
+	if err != nil {
+		return nil, err
+	}
+	return hpkeLabeledExpand(hpkeKEMSuiteID, prk, "shared_secret", kemContext, hpkeHashBytes)
+}
+
+// hpkeDH concatenates the X25519 outputs of each secret key with the corresponding public key
+func hpkeDH(secretKeys []SecretKey, publicKeys []PublicKey) ([]byte, error) {
+	var dh []byte
+	for i := range secretKeys {
+		shared, err := ScalarMult(secretKeys[i], publicKeys[i])
+		if err != nil {
+			return nil, err
+		}
+		dh = append(dh, shared...)
+	}
+	return dh, nil
+}
+
+// hpkeContext holds the keys and sequence number shared by the sender and recipient contexts
+type hpkeContext struct {
+	key            []byte
+	baseNonce      []byte
+	exporterSecret []byte
+	seq            uint64
+}
+
+// hpkeKeySchedule derives the encryption context for a mode from the KEM shared secret and info,
+// as KeySchedule in RFC 9180, section 5.1, without a pre-shared key
+func hpkeKeySchedule(mode byte, sharedSecret, info []byte) (hpkeContext, error) {
+	pskIDHash, err := hpkeLabeledExtract(hpkeSuiteID, nil, "psk_id_hash", nil)
+	if err != nil {
+		return hpkeContext{}, err
+	}
+	infoHash, err := hpkeLabeledExtract(hpkeSuiteID, nil, "info_hash", info)
+	if err != nil {
+		return hpkeContext{}, err
+	}
+	keyScheduleContext := append(append([]byte{mode}, pskIDHash...), infoHash...)
+	secret, err := hpkeLabeledExtract(hpkeSuiteID, sharedSecret, "secret", nil)
+	if err != nil {
+		return hpkeContext{}, err
+	}
+	defer clear(secret)
+
+	var ctx hpkeContext
+	if ctx.key, err = hpkeLabeledExpand(hpkeSuiteID, secret, "key", keyScheduleContext, CryptoSecretBoxKeyBytes); err != nil {
+		return hpkeContext{}, err
+	}
+	if ctx.baseNonce, err = hpkeLabeledExpand(hpkeSuiteID, secret, "base_nonce", keyScheduleContext, hpkeNonceBytes); err != nil {
+		return hpkeContext{}, err
+	}
+	if ctx.exporterSecret, err = hpkeLabeledExpand(hpkeSuiteID, secret, "exp", keyScheduleContext, hpkeHashBytes); err != nil {
+		return hpkeContext{}, err
+	}
+	return ctx, nil
+}
+
+// nonce returns the base nonce XORed with the big-endian sequence number
+func (c *hpkeContext) nonce() []byte {
+	nonce := append([]byte{}, c.baseNonce...)
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], c.seq)
+	for i := range seq {
+		nonce[hpkeNonceBytes-8+i] ^= seq[i]
+	}
+	return nonce
+}
+
+// Export derives a secret of the given length, up to HPKEMaxExportLength, bound to the context
+// and exporterContext. Both sides of a context export the same secrets
+func (c *hpkeContext) Export(exporterContext []byte, length int) ([]byte, error) {
+	if length < 0 || length > HPKEMaxExportLength {
+		return nil, ErrBadHPKEExportLength
+	}
+	return hpkeLabeledExpand(hpkeSuiteID, c.exporterSecret, "sec", exporterContext, length)
+}
+
+// HPKESenderContext encrypts a sequence of messages to the recipient of an HPKE setup. It is not
+// safe for concurrent use
+type HPKESenderContext struct {
+	hpkeContext
+}
+
+// HPKERecipientContext decrypts the sequence of messages from the sender of an HPKE setup, in
+// order. It is not safe for concurrent use
+type HPKERecipientContext struct {
+	hpkeContext
+}
+
+// Seal encrypts and authenticates the next message with its additional data
+func (c *HPKESenderContext) Seal(aad []byte, plaintext Plaintext) (Ciphertext, error) {
+	if c.seq == math.MaxUint64 {
+		return nil, ErrHPKEMessageLimit
+	}
+	ciphertext, err := chacha20Poly1305Encrypt(c.key, c.nonce(), aad, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	c.seq++
+	return Ciphertext(ciphertext), nil
+}
+
+// Open authenticates and decrypts the next message with its additional data. A message that fails
+// to decrypt does not advance the sequence
+func (c *HPKERecipientContext) Open(aad []byte, ciphertext Ciphertext) (Plaintext, error) {
+	if c.seq == math.MaxUint64 {
+		return nil, ErrHPKEMessageLimit
+	}
+	plaintext, err := chacha20Poly1305Decrypt(c.key, c.nonce(), aad, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	c.seq++
+	return Plaintext(plaintext), nil
+}
+
+// hpkeSetupSender encapsulates to publicKey with the supplied ephemeral secret key, authenticating
+// with senderKey in the auth mode
+func hpkeSetupSender(mode byte, publicKey PublicKey, info []byte, senderKey, ephemeral SecretKey) (PublicKey, *HPKESenderContext, error) {
+	enc, err := ScalarMultBase(ephemeral)
+	if err != nil {
+		return nil, nil, err
+	}
+	secretKeys, publicKeys := []SecretKey{ephemeral}, []PublicKey{publicKey}
+	kemContext := append(append([]byte{}, enc...), publicKey...)
+	if mode == hpkeModeAuth {
+		senderPublicKey, err := ScalarMultBase(senderKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		secretKeys, publicKeys = append(secretKeys, senderKey), append(publicKeys, publicKey)
+		kemContext = append(kemContext, senderPublicKey...)
+	}
+	dh, err := hpkeDH(secretKeys, publicKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer clear(dh)
+	sharedSecret, err := hpkeExtractAndExpand(dh, kemContext)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer clear(sharedSecret)
+	ctx, err := hpkeKeySchedule(mode, sharedSecret, info)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, &HPKESenderContext{ctx}, nil
+}
+
+// hpkeSetupSenderRandom generates a fresh ephemeral key pair and sets up a sender context
+func hpkeSetupSenderRandom(mode byte, publicKey PublicKey, info []byte, senderKey SecretKey) (PublicKey, *HPKESenderContext, error) {
+	if len(publicKey) != CryptoBoxPublicKeyBytes {
+		return nil, nil, ErrBadPublicKeyLength
+	}
+	ephemeral, _, err := NewKeyPair()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer clear(ephemeral)
+	return hpkeSetupSender(mode, publicKey, info, senderKey, ephemeral)
+}
+
+// hpkeSetupRecipient decapsulates enc with secretKey, checking the sender's public key in the auth
+// mode
+func hpkeSetupRecipient(mode byte, enc PublicKey, secretKey SecretKey, info []byte, senderPublicKey PublicKey) (*HPKERecipientContext, error) {
+	publicKey, err := ScalarMultBase(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	secretKeys, publicKeys := []SecretKey{secretKey}, []PublicKey{enc}
+	kemContext := append(append([]byte{}, enc...), publicKey...)
+	if mode == hpkeModeAuth {
+		secretKeys, publicKeys = append(secretKeys, secretKey), append(publicKeys, senderPublicKey)
+		kemContext = append(kemContext, senderPublicKey...)
+	}
+	dh, err := hpkeDH(secretKeys, publicKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(dh)
+	sharedSecret, err := hpkeExtractAndExpand(dh, kemContext)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(sharedSecret)
+	ctx, err := hpkeKeySchedule(mode, sharedSecret, info)
+	if err != nil {
+		return nil, err
+	}
+	return &HPKERecipientContext{ctx}, nil
+}
+
+// HPKESetupBaseSender encapsulates a fresh shared secret to the recipient's public key and returns
+// the encapsulated key, which must be sent to the recipient, and a context for sealing messages
+func HPKESetupBaseSender(publicKey PublicKey, info []byte) (PublicKey, *HPKESenderContext, error) {
+	return hpkeSetupSenderRandom(hpkeModeBase, publicKey, info, nil)
+}
+
+// HPKESetupBaseRecipient decapsulates the encapsulated key from HPKESetupBaseSender and returns a
+// context for opening the sender's messages
+func HPKESetupBaseRecipient(enc PublicKey, secretKey SecretKey, info []byte) (*HPKERecipientContext, error) {
+	return hpkeSetupRecipient(hpkeModeBase, enc, secretKey, info, nil)
+}
+
+// HPKESetupAuthSender is HPKESetupBaseSender, additionally authenticating the sender with its
+// secret key, so that only a sender holding it can produce messages the recipient accepts
+func HPKESetupAuthSender(publicKey PublicKey, info []byte, senderKey SecretKey) (PublicKey, *HPKESenderContext, error) {
+	return hpkeSetupSenderRandom(hpkeModeAuth, publicKey, info, senderKey)
+}
+
+// HPKESetupAuthRecipient is HPKESetupBaseRecipient for the auth mode. Messages open only if they
+// were sent by the holder of the secret key for senderPublicKey
+func HPKESetupAuthRecipient(enc PublicKey, secretKey SecretKey, info []byte, senderPublicKey PublicKey) (*HPKERecipientContext, error) {
+	return hpkeSetupRecipient(hpkeModeAuth, enc, secretKey, info, senderPublicKey)
+}
+
+// HPKESeal encrypts a single message to the recipient's public key in the base mode, returning the
+// encapsulated key and the ciphertext
+func HPKESeal(publicKey PublicKey, info, aad []byte, plaintext Plaintext) (PublicKey, Ciphertext, error) {
+	enc, ctx, err := HPKESetupBaseSender(publicKey, info)
+	if err != nil {
+		return nil, nil, err
+	}
+	ciphertext, err := ctx.Seal(aad, plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, ciphertext, nil
+}
+
+// HPKEOpen decrypts a single message produced by HPKESeal
+func HPKEOpen(enc PublicKey, secretKey SecretKey, info, aad []byte, ciphertext Ciphertext) (Plaintext, error) {
+	ctx, err := HPKESetupBaseRecipient(enc, secretKey, info)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.Open(aad, ciphertext)
+}
+
+// HPKEAuthSeal encrypts a single message to the recipient's public key in the auth mode
+func HPKEAuthSeal(publicKey PublicKey, info, aad []byte, plaintext Plaintext, senderKey SecretKey) (PublicKey, Ciphertext, error) {
+	enc, ctx, err := HPKESetupAuthSender(publicKey, info, senderKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	ciphertext, err := ctx.Seal(aad, plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, ciphertext, nil
+}
+
+// HPKEAuthOpen decrypts a single message produced by HPKEAuthSeal from the holder of the secret
+// key for senderPublicKey
+func HPKEAuthOpen(enc PublicKey, secretKey SecretKey, info, aad []byte, ciphertext Ciphertext, senderPublicKey PublicKey) (Plaintext, error) {
+	ctx, err := HPKESetupAuthRecipient(enc, secretKey, info, senderPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.Open(aad, ciphertext)
+}
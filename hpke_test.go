@@ -0,0 +1,272 @@
+package bcl
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 9180, appendix A.2: DHKEM(X25519, HKDF-SHA256), HKDF-SHA256, ChaCha20Poly1305
+var hpkeVectors = []struct {
+	name           string
+	mode           byte
+	ikmE, ikmR     string
+	ikmS           string
+	skRm, pkRm     string
+	skSm, pkSm     string
+	enc            string
+	key            string
+	baseNonce      string
+	exporterSecret string
+	// encryptions at sequence numbers 0, 1 and 256, all of the same plaintext
+	nonces, aads, cts [3]string
+	exports           [3]string
+}{
+	{
+		name:           "TestHPKE success RFC 9180 A.2.1 base",
+		mode:           hpkeModeBase,
+		ikmE:           "909a9b35d3dc4713a5e72a4da274b55d3d3821a37e5d099e74a647db583a904b",
+		ikmR:           "1ac01f181fdf9f352797655161c58b75c656a6cc2716dcb66372da835542e1df",
+		skRm:           "8057991eef8f1f1af18f4a9491d16a1ce333f695d4db8e38da75975c4478e0fb",
+		pkRm:           "4310ee97d88cc1f088a5576c77ab0cf5c3ac797f3d95139c6c84b5429c59662a",
+		enc:            "1afa08d3dec047a643885163f1180476fa7ddb54c6a8029ea33f95796bf2ac4a",
+		key:            "ad2744de8e17f4ebba575b3f5f5a8fa1f69c2a07f6e7500bc60ca6e3e3ec1c91",
+		baseNonce:      "5c4d98150661b848853b547f",
+		exporterSecret: "a3b010d4994890e2c6968a36f64470d3c824c8f5029942feb11e7a74b2921922",
+		nonces:         [3]string{"5c4d98150661b848853b547f", "5c4d98150661b848853b547e", "5c4d98150661b848853b557f"},
+		aads:           [3]string{"436f756e742d30", "436f756e742d31", "436f756e742d323536"},
+		cts: [3]string{
+			"1c5250d8034ec2b784ba2cfd69dbdb8af406cfe3ff938e131f0def8c8b60b4db21993c62ce81883d2dd1b51a28",
+			"6b53c051e4199c518de79594e1c4ab18b96f081549d45ce015be002090bb119e85285337cc95ba5f59992dc98c",
+			"7a4a13e9ef23978e2c520fd4d2e757514ae160cd0cd05e556ef692370ca53076214c0c40d4c728d6ed9e727a5b",
+		},
+		exports: [3]string{
+			"4bbd6243b8bb54cec311fac9df81841b6fd61f56538a775e7c80a9f40160606e",
+			"8c1df14732580e5501b00f82b10a1647b40713191b7c1240ac80e2b68808ba69",
+			"5acb09211139c43b3090489a9da433e8a30ee7188ba8b0a9a1ccf0c229283e53",
+		},
+	},
+	{
+		name:           "TestHPKE success RFC 9180 A.2.3 auth",
+		mode:           hpkeModeAuth,
+		ikmE:           "938d3daa5a8904540bc24f48ae90eed3f4f7f11839560597b55e7c9598c996c0",
+		ikmR:           "64835d5ee64aa7aad57c6f2e4f758f7696617f8829e70bc9ac7a5ef95d1c756c",
+		ikmS:           "9d8f94537d5a3ddef71234c0baedfad4ca6861634d0b94c3007fed557ad17df6",
+		skRm:           "3ca22a6d1cda1bb9480949ec5329d3bf0b080ca4c45879c95eddb55c70b80b82",
+		pkRm:           "1a478716d63cb2e16786ee93004486dc151e988b34b475043d3e0175bdb01c44",
+		skSm:           "2def0cb58ffcf83d1062dd085c8aceca7f4c0c3fd05912d847b61f3e54121f05",
+		pkSm:           "f0f4f9e96c54aeed3f323de8534fffd7e0577e4ce269896716bcb95643c8712b",
+		enc:            "f7674cc8cd7baa5872d1f33dbaffe3314239f6197ddf5ded1746760bfc847e0e",
+		key:            "b071fd1136680600eb447a845a967d35e9db20749cdf9ce098bcc4deef4b1356",
+		baseNonce:      "d20577dff16d7cea2c4bf780",
+		exporterSecret: "be2d93b82071318cdb88510037cf504344151f2f9b9da8ab48974d40a2251dd7",
+		nonces:         [3]string{"d20577dff16d7cea2c4bf780", "d20577dff16d7cea2c4bf781", "d20577dff16d7cea2c4bf680"},
+		aads:           [3]string{"436f756e742d30", "436f756e742d31", "436f756e742d323536"},
+		cts: [3]string{
+			"ab1a13c9d4f01a87ec3440dbd756e2677bd2ecf9df0ce7ed73869b98e00c09be111cb9fdf077347aeb88e61bdf",
+			"3265c7807ffff7fdace21659a2c6ccffee52a26d270c76468ed74202a65478bfaedfff9c2b7634e24f10b71016",
+			"3be14e8b3bbd1028cf2b7d0a691dbbeff71321e7dec92d3c2cfb30a0994ab246af76168480285a60037b4ba13a",
+		},
+		exports: [3]string{
+			"070cffafd89b67b7f0eeb800235303a223e6ff9d1e774dce8eac585c8688c872",
+			"2852e728568d40ddb0edde284d36a4359c56558bb2fb8837cd3d92e46a3a14a8",
+			"1df39dc5dd60edcbf5f9ae804e15ada66e885b28ed7929116f768369a3f950ee",
+		},
+	},
+}
+
+func TestHPKE(t *testing.T) {
+	info := mustDecodeHex("4f6465206f6e2061204772656369616e2055726e")
+	plaintext := Plaintext(mustDecodeHex("4265617574792069732074727574682c20747275746820626561757479"))
+	exporterContexts := [][]byte{nil, {0x00}, []byte("TestContext")}
+	sequences := []uint64{0, 1, 256}
+
+	for _, tt := range hpkeVectors {
+		t.Run(tt.name, func(t *testing.T) {
+			skE, _, err := DeriveHPKEKeyPair(mustDecodeHex(tt.ikmE))
+			assert.NoError(t, err)
+			skR, pkR, err := DeriveHPKEKeyPair(mustDecodeHex(tt.ikmR))
+			assert.NoError(t, err)
+			assert.Equal(t, mustDecodeHex(tt.skRm), []byte(skR))
+			assert.Equal(t, mustDecodeHex(tt.pkRm), []byte(pkR))
+			var skS SecretKey
+			var pkS PublicKey
+			if tt.mode == hpkeModeAuth {
+				skS, pkS, err = DeriveHPKEKeyPair(mustDecodeHex(tt.ikmS))
+				assert.NoError(t, err)
+				assert.Equal(t, mustDecodeHex(tt.skSm), []byte(skS))
+				assert.Equal(t, mustDecodeHex(tt.pkSm), []byte(pkS))
+			}
+
+			enc, sender, err := hpkeSetupSender(tt.mode, pkR, info, skS, skE)
+			assert.NoError(t, err)
+			assert.Equal(t, mustDecodeHex(tt.enc), []byte(enc))
+			assert.Equal(t, mustDecodeHex(tt.key), sender.key)
+			assert.Equal(t, mustDecodeHex(tt.baseNonce), sender.baseNonce)
+			assert.Equal(t, mustDecodeHex(tt.exporterSecret), sender.exporterSecret)
+
+			recipient, err := hpkeSetupRecipient(tt.mode, enc, skR, info, pkS)
+			assert.NoError(t, err)
+			assert.Equal(t, sender.hpkeContext, recipient.hpkeContext)
+
+			for i, seq := range sequences {
+				sender.seq, recipient.seq = seq, seq
+				assert.Equal(t, mustDecodeHex(tt.nonces[i]), sender.nonce())
+				ciphertext, err := sender.Seal(mustDecodeHex(tt.aads[i]), plaintext)
+				assert.NoError(t, err)
+				assert.Equal(t, mustDecodeHex(tt.cts[i]), []byte(ciphertext))
+				decrypted, err := recipient.Open(mustDecodeHex(tt.aads[i]), ciphertext)
+				assert.NoError(t, err)
+				assert.Equal(t, plaintext, decrypted)
+				assert.Equal(t, seq+1, recipient.seq)
+			}
+
+			for i, exporterContext := range exporterContexts {
+				exported, err := sender.Export(exporterContext, 32)
+				assert.NoError(t, err)
+				assert.Equal(t, mustDecodeHex(tt.exports[i]), exported)
+				exported, err = recipient.Export(exporterContext, 32)
+				assert.NoError(t, err)
+				assert.Equal(t, mustDecodeHex(tt.exports[i]), exported)
+			}
+		})
+	}
+}
+
+func TestHPKESetup(t *testing.T) {
+	skR, pkR, err := NewKeyPair()
+	assert.NoError(t, err)
+	skS, pkS, err := NewKeyPair()
+	assert.NoError(t, err)
+	info := []byte("bcl HPKE test")
+
+	t.Run("TestHPKESetup success base", func(t *testing.T) {
+		enc, sender, err := HPKESetupBaseSender(pkR, info)
+		assert.NoError(t, err)
+		recipient, err := HPKESetupBaseRecipient(enc, skR, info)
+		assert.NoError(t, err)
+		for _, m := range []string{"first", "", "third"} {
+			ciphertext, err := sender.Seal([]byte("aad"), Plaintext(m))
+			assert.NoError(t, err)
+			decrypted, err := recipient.Open([]byte("aad"), ciphertext)
+			assert.NoError(t, err)
+			assert.Equal(t, Plaintext(m), decrypted)
+		}
+		senderSecret, err := sender.Export([]byte("label"), 64)
+		assert.NoError(t, err)
+		recipientSecret, err := recipient.Export([]byte("label"), 64)
+		assert.NoError(t, err)
+		assert.Equal(t, senderSecret, recipientSecret)
+	})
+
+	t.Run("TestHPKESetup success auth", func(t *testing.T) {
+		enc, sender, err := HPKESetupAuthSender(pkR, info, skS)
+		assert.NoError(t, err)
+		recipient, err := HPKESetupAuthRecipient(enc, skR, info, pkS)
+		assert.NoError(t, err)
+		ciphertext, err := sender.Seal(nil, Plaintext("hello"))
+		assert.NoError(t, err)
+		decrypted, err := recipient.Open(nil, ciphertext)
+		assert.NoError(t, err)
+		assert.Equal(t, Plaintext("hello"), decrypted)
+	})
+
+	t.Run("TestHPKESetup fail out of order", func(t *testing.T) {
+		enc, sender, err := HPKESetupBaseSender(pkR, info)
+		assert.NoError(t, err)
+		recipient, err := HPKESetupBaseRecipient(enc, skR, info)
+		assert.NoError(t, err)
+		first, err := sender.Seal(nil, Plaintext("first"))
+		assert.NoError(t, err)
+		second, err := sender.Seal(nil, Plaintext("second"))
+		assert.NoError(t, err)
+		_, err = recipient.Open(nil, second)
+		assert.ErrorIs(t, err, ErrDecryptionFailed)
+		// the failed message did not advance the sequence
+		decrypted, err := recipient.Open(nil, first)
+		assert.NoError(t, err)
+		assert.Equal(t, Plaintext("first"), decrypted)
+	})
+
+	t.Run("TestHPKESetup fail message limit", func(t *testing.T) {
+		_, sender, err := HPKESetupBaseSender(pkR, info)
+		assert.NoError(t, err)
+		sender.seq = math.MaxUint64
+		_, err = sender.Seal(nil, Plaintext("too many"))
+		assert.ErrorIs(t, err, ErrHPKEMessageLimit)
+	})
+
+	t.Run("TestHPKESetup fail export length", func(t *testing.T) {
+		_, sender, err := HPKESetupBaseSender(pkR, info)
+		assert.NoError(t, err)
+		_, err = sender.Export(nil, HPKEMaxExportLength+1)
+		assert.ErrorIs(t, err, ErrBadHPKEExportLength)
+		exported, err := sender.Export(nil, HPKEMaxExportLength)
+		assert.NoError(t, err)
+		assert.Len(t, exported, HPKEMaxExportLength)
+	})
+}
+
+func TestHPKESeal(t *testing.T) {
+	skR, pkR, err := NewKeyPair()
+	assert.NoError(t, err)
+	skS, pkS, err := NewKeyPair()
+	assert.NoError(t, err)
+	_, otherPublicKey, err := NewKeyPair()
+	assert.NoError(t, err)
+	otherSecretKey, _, err := NewKeyPair()
+	assert.NoError(t, err)
+	info, aad := []byte("info"), []byte("aad")
+	plaintext := Plaintext("Hello, HPKE!")
+
+	enc, ciphertext, err := HPKESeal(pkR, info, aad, plaintext)
+	assert.NoError(t, err)
+	decrypted, err := HPKEOpen(enc, skR, info, aad, ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	authEnc, authCiphertext, err := HPKEAuthSeal(pkR, info, aad, plaintext, skS)
+	assert.NoError(t, err)
+	decrypted, err = HPKEAuthOpen(authEnc, skR, info, aad, authCiphertext, pkS)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	tampered := append(Ciphertext{}, ciphertext...)
+	tampered[0] ^= 0x01
+	lowOrder := make(PublicKey, CryptoBoxPublicKeyBytes)
+
+	tests := []struct {
+		name string
+		open func() (Plaintext, error)
+		err  error
+	}{
+		{name: "TestHPKESeal fail tampered ciphertext", open: func() (Plaintext, error) { return HPKEOpen(enc, skR, info, aad, tampered) }, err: ErrDecryptionFailed},
+		{name: "TestHPKESeal fail wrong info", open: func() (Plaintext, error) { return HPKEOpen(enc, skR, []byte("other"), aad, ciphertext) }, err: ErrDecryptionFailed},
+		{name: "TestHPKESeal fail wrong aad", open: func() (Plaintext, error) { return HPKEOpen(enc, skR, info, nil, ciphertext) }, err: ErrDecryptionFailed},
+		{name: "TestHPKESeal fail wrong recipient", open: func() (Plaintext, error) { return HPKEOpen(enc, otherSecretKey, info, aad, ciphertext) }, err: ErrDecryptionFailed},
+		{name: "TestHPKESeal fail short ciphertext", open: func() (Plaintext, error) { return HPKEOpen(enc, skR, info, aad, ciphertext[:15]) }, err: ErrDecryptionFailed},
+		{name: "TestHPKESeal fail low order enc", open: func() (Plaintext, error) { return HPKEOpen(lowOrder, skR, info, aad, ciphertext) }, err: ErrLowOrderPublicKey},
+		{name: "TestHPKESeal fail bad enc", open: func() (Plaintext, error) { return HPKEOpen(enc[:31], skR, info, aad, ciphertext) }, err: ErrBadPublicKeyLength},
+		{name: "TestHPKESeal fail auth wrong sender", open: func() (Plaintext, error) {
+			return HPKEAuthOpen(authEnc, skR, info, aad, authCiphertext, otherPublicKey)
+		}, err: ErrDecryptionFailed},
+		{name: "TestHPKESeal fail auth opened as base", open: func() (Plaintext, error) { return HPKEOpen(authEnc, skR, info, aad, authCiphertext) }, err: ErrDecryptionFailed},
+		{name: "TestHPKESeal fail auth bad sender key", open: func() (Plaintext, error) {
+			return HPKEAuthOpen(authEnc, skR, info, aad, authCiphertext, pkS[:31])
+		}, err: ErrBadPublicKeyLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.open()
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	_, _, err = HPKESeal(pkR[:31], info, aad, plaintext)
+	assert.ErrorIs(t, err, ErrBadPublicKeyLength)
+	_, _, err = HPKEAuthSeal(pkR, info, aad, plaintext, skS[:31])
+	assert.ErrorIs(t, err, ErrBadSecretKeyLength)
+	_, _, err = DeriveHPKEKeyPair(make([]byte, 31))
+	assert.ErrorIs(t, err, ErrBadHPKEKeyMaterial)
+}
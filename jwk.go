@@ -0,0 +1,160 @@
+package bcl
+
+import (
+	"encoding/base64"
+)
+
+// Keys are represented as JSON Web Keys of type "OKP" (RFC 8037), with the curve "X25519" for
+// encryption keys and "Ed25519" for signing keys
+
+const (
+	jwkTypeOKP     = "OKP"
+	jwkCurveX25519 = "X25519"
+	jwkCurveEd     = "Ed25519"
+)
+
+var jwkBase64 = base64.RawURLEncoding
+
+// JWK is a JSON Web Key holding an X25519 or Ed25519 key. The private part D is only present for
+// secret and signing keys
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	X   string `json:"x"`
+	D   string `json:"d,omitempty"`
+}
+
+// JWKSet is a JSON Web Key Set, as served from a JWKS endpoint
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// ToJWK converts a public key to a JWK
+func (p PublicKey) ToJWK() (JWK, error) {
+	if len(p) != CryptoBoxPublicKeyBytes {
+		return JWK{}, ErrBadPublicKeyLength
+	}
+	return JWK{Kty: jwkTypeOKP, Crv: jwkCurveX25519, X: jwkBase64.EncodeToString(p)}, nil
+}
+
+// ToJWK converts a secret key to a JWK that also contains its public key
+func (s SecretKey) ToJWK() (JWK, error) {
+	p, err := NewPublicKey(s)
+	if err != nil {
+		return JWK{}, err
+	}
+	j, err := p.ToJWK()
+	if err != nil {
+		return JWK{}, err
+	}
+	j.D = jwkBase64.EncodeToString(s)
+	return j, nil
+}
+
+// ToJWK converts a verify key to a JWK
+func (v VerifyKey) ToJWK() (JWK, error) {
+	if len(v) != CryptoSignPublicKeyBytes {
+		return JWK{}, ErrBadVerifyKeyLength
+	}
+	return JWK{Kty: jwkTypeOKP, Crv: jwkCurveEd, X: jwkBase64.EncodeToString(v)}, nil
+}
+
+// ToJWK converts a signing key to a JWK that also contains its verify key
+func (s SigningKey) ToJWK() (JWK, error) {
+	seed, err := s.Seed()
+	if err != nil {
+		return JWK{}, err
+	}
+	v, err := s.VerifyKey()
+	if err != nil {
+		return JWK{}, err
+	}
+	j, err := v.ToJWK()
+	if err != nil {
+		return JWK{}, err
+	}
+	j.D = jwkBase64.EncodeToString(seed)
+	return j, nil
+}
+
+// Public returns a copy of a JWK without its private part
+func (j JWK) Public() JWK {
+	j.D = ""
+	return j
+}
+
+func (j JWK) decode(crv string, size int) ([]byte, []byte, error) {
+	if j.Kty != jwkTypeOKP || j.Crv != crv {
+		return nil, nil, ErrBadJWK
+	}
+	x, err := jwkBase64.DecodeString(j.X)
+	if err != nil || len(x) != size {
+		return nil, nil, ErrBadJWK
+	}
+	if j.D == "" {
+		return x, nil, nil
+	}
+	d, err := jwkBase64.DecodeString(j.D)
+	if err != nil || len(d) != size {
+		return nil, nil, ErrBadJWK
+	}
+	return x, d, nil
+}
+
+// PublicKey returns the X25519 public key held by a JWK
+func (j JWK) PublicKey() (PublicKey, error) {
+	x, _, err := j.decode(jwkCurveX25519, CryptoBoxPublicKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return PublicKey(x), nil
+}
+
+// SecretKey returns the X25519 secret key held by a JWK, checking that it matches the public key
+func (j JWK) SecretKey() (SecretKey, error) {
+	x, d, err := j.decode(jwkCurveX25519, CryptoSecretBoxKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrBadJWK
+	}
+	p, err := NewPublicKey(d)
+	if err != nil {
+		return nil, err
+	}
+	if !constantTimeEqual(p, x) {
+		return nil, ErrBadJWK
+	}
+	return SecretKey(d), nil
+}
+
+// VerifyKey returns the Ed25519 verify key held by a JWK
+func (j JWK) VerifyKey() (VerifyKey, error) {
+	x, _, err := j.decode(jwkCurveEd, CryptoSignPublicKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return VerifyKey(x), nil
+}
+
+// SigningKey returns the Ed25519 signing key held by a JWK, checking that it matches the verify key
+func (j JWK) SigningKey() (SigningKey, error) {
+	x, d, err := j.decode(jwkCurveEd, CryptoSignSeedBytes)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrBadJWK
+	}
+	sk, vk, err := NewSigningKeyPairFromSeed(d)
+	if err != nil {
+		return nil, err
+	}
+	if !constantTimeEqual(vk, x) {
+		return nil, ErrBadJWK
+	}
+	return sk, nil
+}
@@ -0,0 +1,130 @@
+package bcl
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWKVectors(t *testing.T) {
+	// RFC 8037 appendix A.1 and A.2
+	var ed JWK
+	assert.NoError(t, json.Unmarshal([]byte(`{"kty":"OKP","crv":"Ed25519","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`), &ed))
+	signingKey, err := ed.SigningKey()
+	assert.NoError(t, err)
+	seed, err := signingKey.Seed()
+	assert.NoError(t, err)
+	assert.Equal(t, "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60", hex.EncodeToString(seed))
+	verifyKey, err := ed.VerifyKey()
+	assert.NoError(t, err)
+	derivedKey, err := signingKey.VerifyKey()
+	assert.NoError(t, err)
+	assert.Equal(t, verifyKey, derivedKey)
+	signing, err := signingKey.ToJWK()
+	assert.NoError(t, err)
+	assert.Equal(t, ed, signing)
+	verify, err := verifyKey.ToJWK()
+	assert.NoError(t, err)
+	assert.Equal(t, ed.Public(), verify)
+
+	// RFC 8037 appendix A.6, with the key of RFC 7748 section 6.1
+	sk, err := hex.DecodeString("5dab087e624a8a4b79e17f8b83800ee66f3bb1292618b6fd1c2f8b27ff88e0eb")
+	assert.NoError(t, err)
+	x := JWK{Kty: "OKP", Crv: "X25519", X: "3p7bfXt9wbTTW2HC7OQ1Nz-DQ8hbeGdNrfx-FG-IK08"}
+	j, err := SecretKey(sk).ToJWK()
+	assert.NoError(t, err)
+	assert.Equal(t, x, j.Public())
+	pk, err := x.PublicKey()
+	assert.NoError(t, err)
+	public, err := pk.ToJWK()
+	assert.NoError(t, err)
+	assert.Equal(t, x, public)
+	sk2, err := j.SecretKey()
+	assert.NoError(t, err)
+	assert.Equal(t, SecretKey(sk), sk2)
+}
+
+func TestJWKSet(t *testing.T) {
+	_, pk, err := NewKeyPair()
+	assert.NoError(t, err)
+	_, vk, err := NewSigningKeyPair()
+	assert.NoError(t, err)
+
+	public, err := pk.ToJWK()
+	assert.NoError(t, err)
+	verify, err := vk.ToJWK()
+	assert.NoError(t, err)
+	set := JWKSet{Keys: []JWK{public, verify}}
+	set.Keys[0].Kid, set.Keys[1].Kid = "enc", "sig"
+	b, err := json.Marshal(set)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), `"d"`)
+
+	var parsed JWKSet
+	assert.NoError(t, json.Unmarshal(b, &parsed))
+	assert.Equal(t, set, parsed)
+	pk2, err := parsed.Keys[0].PublicKey()
+	assert.NoError(t, err)
+	assert.Equal(t, pk, pk2)
+	vk2, err := parsed.Keys[1].VerifyKey()
+	assert.NoError(t, err)
+	assert.Equal(t, vk, vk2)
+}
+
+func TestJWKFail(t *testing.T) {
+	sk, _, err := NewKeyPair()
+	assert.NoError(t, err)
+	secret, err := sk.ToJWK()
+	assert.NoError(t, err)
+	signingKey, _, err := NewSigningKeyPair()
+	assert.NoError(t, err)
+	signing, err := signingKey.ToJWK()
+	assert.NoError(t, err)
+
+	mismatched := secret
+	mismatched.X = signing.X
+	short := secret
+	short.X = short.X[:10]
+
+	tests := []struct {
+		name  string
+		parse func() error
+	}{
+		{name: "TestJWKFail wrong curve", parse: func() error { _, err := signing.PublicKey(); return err }},
+		{name: "TestJWKFail wrong type", parse: func() error { j := secret; j.Kty = "EC"; _, err := j.PublicKey(); return err }},
+		{name: "TestJWKFail short key", parse: func() error { _, err := short.PublicKey(); return err }},
+		{name: "TestJWKFail missing secret", parse: func() error { _, err := secret.Public().SecretKey(); return err }},
+		{name: "TestJWKFail missing signing key", parse: func() error { _, err := signing.Public().SigningKey(); return err }},
+		{name: "TestJWKFail mismatched public key", parse: func() error { _, err := mismatched.SecretKey(); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, tt.parse(), ErrBadJWK.Error())
+		})
+	}
+}
+
+func TestJWKKeyLength(t *testing.T) {
+	sk, pk, err := NewKeyPair()
+	assert.NoError(t, err)
+	signingKey, verifyKey, err := NewSigningKeyPair()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		convert func() error
+		err     error
+	}{
+		{name: "TestJWKKeyLength fail public key", convert: func() error { _, err := pk[1:].ToJWK(); return err }, err: ErrBadPublicKeyLength},
+		{name: "TestJWKKeyLength fail secret key", convert: func() error { _, err := sk[1:].ToJWK(); return err }, err: ErrBadSecretKeyLength},
+		{name: "TestJWKKeyLength fail verify key", convert: func() error { _, err := verifyKey[1:].ToJWK(); return err }, err: ErrBadVerifyKeyLength},
+		{name: "TestJWKKeyLength fail signing key", convert: func() error { _, err := signingKey[1:].ToJWK(); return err }, err: ErrBadSigningKeyLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, tt.convert(), tt.err.Error())
+		})
+	}
+}
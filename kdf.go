@@ -0,0 +1,172 @@
+package bcl
+
+/*
+#include <stddef.h>
+int crypto_kdf_hkdf_sha256_extract(unsigned char *prk, const unsigned char *salt, size_t salt_len, const unsigned char *ikm, size_t ikm_len);
+int crypto_kdf_hkdf_sha256_expand(unsigned char *out, size_t out_len, const char *ctx, size_t ctx_len, const unsigned char *prk);
+size_t crypto_kdf_hkdf_sha256_keybytes(void);
+size_t crypto_auth_hmacsha256_statebytes(void);
+int crypto_auth_hmacsha256_init(void *state, const unsigned char *key, size_t keylen);
+int crypto_auth_hmacsha256_update(void *state, const unsigned char *in, unsigned long long inlen);
+int crypto_auth_hmacsha256_final(void *state, unsigned char *out);
+size_t crypto_auth_hmacsha256_bytes(void);
+int crypto_kdf_hkdf_sha512_extract(unsigned char *prk, const unsigned char *salt, size_t salt_len, const unsigned char *ikm, size_t ikm_len);
+int crypto_kdf_hkdf_sha512_expand(unsigned char *out, size_t out_len, const char *ctx, size_t ctx_len, const unsigned char *prk);
+size_t crypto_kdf_hkdf_sha512_keybytes(void);
+size_t crypto_auth_hmacsha512_statebytes(void);
+int crypto_auth_hmacsha512_init(void *state, const unsigned char *key, size_t keylen);
+int crypto_auth_hmacsha512_update(void *state, const unsigned char *in, unsigned long long inlen);
+int crypto_auth_hmacsha512_final(void *state, unsigned char *out);
+size_t crypto_auth_hmacsha512_bytes(void);
+int crypto_pwhash_argon2id(unsigned char * const out, unsigned long long outlen, const char * const passwd, unsigned long long passwdlen, const unsigned char * const salt, unsigned long long opslimit, size_t memlimit, int alg);
+int crypto_pwhash_argon2id_alg_argon2id13(void);
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// hkdfSHA256Extract computes the HKDF-SHA-256 pseudorandom key for the supplied salt and input
+// key material
+func hkdfSHA256Extract(salt, ikm []byte) ([]byte, error) {
+	prk := make([]byte, int(C.crypto_kdf_hkdf_sha256_keybytes()))
+	rc := C.crypto_kdf_hkdf_sha256_extract(
+		ucharPtr(prk),
+		ucharPtr(salt),
+		C.size_t(len(salt)),
+		ucharPtr(ikm),
+		C.size_t(len(ikm)),
+	)
+	if rc != 0 {
+		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+	}
+	return prk, nil
+}
+
+// hkdfSHA256Expand expands an HKDF-SHA-256 pseudorandom key into length bytes of output bound to info
+func hkdfSHA256Expand(prk, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	rc := C.crypto_kdf_hkdf_sha256_expand(
+		ucharPtr(out),
+		C.size_t(length),
+		(*C.char)(unsafe.Pointer(ucharPtr(info))),
+		C.size_t(len(info)),
+		ucharPtr(prk),
+	)
+	if rc != 0 {
+		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+	}
+	return out, nil
+}
+
+// hkdfSHA256 runs HKDF-SHA-256 extract and expand in one step
+func hkdfSHA256(ikm, salt, info []byte, length int) ([]byte, error) {
+	prk, err := hkdfSHA256Extract(salt, ikm)
+	if err != nil {
+		return nil, err
+	}
+	return hkdfSHA256Expand(prk, info, length)
+}
+
+// hmacSHA256 computes HMAC-SHA-256 over the concatenated inputs with a key of any length
+func hmacSHA256(key []byte, inputs ...[]byte) ([]byte, error) {
+	state := make([]byte, int(C.crypto_auth_hmacsha256_statebytes()))
+	rc := C.crypto_auth_hmacsha256_init(unsafe.Pointer(&state[0]), ucharPtr(key), C.size_t(len(key)))
+	if rc != 0 {
+		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+	}
+	for _, input := range inputs {
+		rc = C.crypto_auth_hmacsha256_update(unsafe.Pointer(&state[0]), ucharPtr(input), C.ulonglong(len(input)))
+		if rc != 0 {
+			return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+		}
+	}
+	out := make([]byte, int(C.crypto_auth_hmacsha256_bytes()))
+	rc = C.crypto_auth_hmacsha256_final(unsafe.Pointer(&state[0]), ucharPtr(out))
+	if rc != 0 {
+		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+	}
+	return out, nil
+}
+
+// hkdfSHA512Extract computes the HKDF-SHA-512 pseudorandom key for the supplied salt and input
+// key material
+func hkdfSHA512Extract(salt, ikm []byte) ([]byte, error) {
+	prk := make([]byte, int(C.crypto_kdf_hkdf_sha512_keybytes()))
+	rc := C.crypto_kdf_hkdf_sha512_extract(
+		ucharPtr(prk),
+		ucharPtr(salt),
+		C.size_t(len(salt)),
+		ucharPtr(ikm),
+		C.size_t(len(ikm)),
+	)
+	if rc != 0 {
+		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+	}
+	return prk, nil
+}
+
+// hkdfSHA512Expand expands an HKDF-SHA-512 pseudorandom key into length bytes of output bound to info
+func hkdfSHA512Expand(prk, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	rc := C.crypto_kdf_hkdf_sha512_expand(
+		ucharPtr(out),
+		C.size_t(length),
+		(*C.char)(unsafe.Pointer(ucharPtr(info))),
+		C.size_t(len(info)),
+		ucharPtr(prk),
+	)
+	if rc != 0 {
+		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+	}
+	return out, nil
+}
+
+// hmacSHA512 computes HMAC-SHA-512 over the concatenated inputs with a key of any length
+func hmacSHA512(key []byte, inputs ...[]byte) ([]byte, error) {
+	state := make([]byte, int(C.crypto_auth_hmacsha512_statebytes()))
+	rc := C.crypto_auth_hmacsha512_init(unsafe.Pointer(&state[0]), ucharPtr(key), C.size_t(len(key)))
+	if rc != 0 {
+		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+	}
+	for _, input := range inputs {
+		rc = C.crypto_auth_hmacsha512_update(unsafe.Pointer(&state[0]), ucharPtr(input), C.ulonglong(len(input)))
+		if rc != 0 {
+			return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+		}
+	}
+	out := make([]byte, int(C.crypto_auth_hmacsha512_bytes()))
+	rc = C.crypto_auth_hmacsha512_final(unsafe.Pointer(&state[0]), ucharPtr(out))
+	if rc != 0 {
+		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+	}
+	return out, nil
+}
+
+// argon2id derives length bytes from a passphrase and salt with Argon2id using the supplied limits
+func argon2id(passphrase, salt []byte, opsLimit, memLimit uint64, length int) ([]byte, error) {
+	if len(salt) != CryptoPwHashSaltBytes {
+		return nil, ErrBadPwHashParams
+	}
+	if opsLimit < CryptoPwHashOpsLimitMin || opsLimit > CryptoPwHashOpsLimitMax ||
+		memLimit < CryptoPwHashMemLimitMin || memLimit > CryptoPwHashMemLimitMax {
+		return nil, ErrBadPwHashParams
+	}
+	out := make([]byte, length)
+	rc := C.crypto_pwhash_argon2id(
+		ucharPtr(out),
+		C.ulonglong(length),
+		(*C.char)(unsafe.Pointer(ucharPtr(passphrase))),
+		C.ulonglong(len(passphrase)),
+		ucharPtr(salt),
+		C.ulonglong(opsLimit),
+		C.size_t(memLimit),
+		C.crypto_pwhash_argon2id_alg_argon2id13(),
+	)
+	if rc != 0 {
+		// libsodium only fails here when it cannot allocate memLimit bytes
+		return nil, fmt.Errorf("argon2id key derivation failed: %d", int(rc))
+	}
+	return out, nil
+}
@@ -0,0 +1,167 @@
+package bcl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"os"
+)
+
+// An encrypted key file is a PEM block of type "BCL ENCRYPTED SECRET KEY" whose contents are:
+//
+//	magic "BCLK" | version (1 byte) | opslimit (uint64) | memlimit (uint64) | salt | sealed key
+//
+// where the sealed key is the output of SymmetricEncrypt under a key derived by hashing the whole
+// header with the Argon2id output for the passphrase, stored limits and salt, so tampering with the
+// header causes decryption to fail
+
+const (
+	keyFileVersion  = 1
+	keyFilePEMType  = "BCL ENCRYPTED SECRET KEY"
+	keyFileMaxBytes = 64 * 1024
+)
+
+var keyFileMagic = []byte("BCLK")
+
+// KeyFileParams holds the Argon2id limits used to derive the key that protects a key file
+type KeyFileParams struct {
+	OpsLimit uint64
+	MemLimit uint64
+}
+
+// DefaultKeyFileParams returns the moderate Argon2id limits recommended by libsodium, which take
+// around a second and 256MiB of memory to derive a key
+func DefaultKeyFileParams() KeyFileParams {
+	return KeyFileParams{OpsLimit: CryptoPwHashOpsLimitModerate, MemLimit: CryptoPwHashMemLimitModerate}
+}
+
+// keyFileKey derives the key protecting a key file from the passphrase and header. The limits come
+// from an untrusted file, so anything above libsodium's sensitive limits is rejected before any
+// work is done
+func keyFileKey(passphrase, header []byte) (SecretKey, error) {
+	opsLimit := binary.BigEndian.Uint64(header[5:])
+	memLimit := binary.BigEndian.Uint64(header[13:])
+	if opsLimit > CryptoPwHashOpsLimitSensitive || memLimit > CryptoPwHashMemLimitSensitive {
+		return nil, ErrKeyFileLimitsTooHigh
+	}
+	stretched, err := argon2id(passphrase, header[21:], opsLimit, memLimit, CryptoSecretBoxKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return genericHash(CryptoSecretBoxKeyBytes, stretched, header)
+}
+
+// EncryptSecretKey encrypts a secret key with a passphrase, returning the PEM encoded key file. If
+// params is nil, DefaultKeyFileParams is used
+func EncryptSecretKey(secretKey SecretKey, passphrase []byte, params *KeyFileParams) ([]byte, error) {
+	if len(secretKey) != CryptoSecretBoxKeyBytes {
+		return nil, ErrBadSecretKeyLength
+	}
+	p := DefaultKeyFileParams()
+	if params != nil {
+		p = *params
+	}
+
+	header := make([]byte, 0, len(keyFileMagic)+17+CryptoPwHashSaltBytes)
+	header = append(header, keyFileMagic...)
+	header = append(header, keyFileVersion)
+	header = binary.BigEndian.AppendUint64(header, p.OpsLimit)
+	header = binary.BigEndian.AppendUint64(header, p.MemLimit)
+	salt := make([]byte, CryptoPwHashSaltBytes)
+	if err := randomBytes(nil, salt); err != nil {
+		return nil, err
+	}
+	header = append(header, salt...)
+
+	key, err := keyFileKey(passphrase, header)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := SymmetricEncrypt(key, Plaintext(secretKey), nil)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: keyFilePEMType, Bytes: append(header, sealed...)}), nil
+}
+
+// DecryptSecretKey decrypts a key file produced by EncryptSecretKey, returning the secret key and
+// its public key
+func DecryptSecretKey(data []byte, passphrase []byte) (SecretKey, PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != keyFilePEMType {
+		return nil, nil, ErrBadKeyFile
+	}
+	b := block.Bytes
+	headerSize := len(keyFileMagic) + 17 + CryptoPwHashSaltBytes
+	if len(b) != headerSize+CryptoSecretBoxNonceBytes+CryptoSecretBoxMacBytes+CryptoSecretBoxKeyBytes {
+		return nil, nil, ErrBadKeyFile
+	}
+	if !bytes.Equal(b[:4], keyFileMagic) || b[4] != keyFileVersion {
+		return nil, nil, ErrBadKeyFile
+	}
+
+	key, err := keyFileKey(passphrase, b[:headerSize])
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := SymmetricDecrypt(key, Ciphertext(b[headerSize:]))
+	if err != nil {
+		return nil, nil, ErrBadPassphrase
+	}
+	secretKey := SecretKey(plaintext)
+	publicKey, err := NewPublicKey(secretKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return secretKey, publicKey, nil
+}
+
+// WriteKeyFile writes a secret key encrypted with a passphrase to w
+func WriteKeyFile(w io.Writer, secretKey SecretKey, passphrase []byte, params *KeyFileParams) error {
+	data, err := EncryptSecretKey(secretKey, passphrase, params)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// ReadKeyFile reads a key file written by WriteKeyFile from r, returning the secret key and its
+// public key
+func ReadKeyFile(r io.Reader, passphrase []byte) (SecretKey, PublicKey, error) {
+	data, err := io.ReadAll(io.LimitReader(r, keyFileMaxBytes))
+	if err != nil {
+		return nil, nil, err
+	}
+	return DecryptSecretKey(data, passphrase)
+}
+
+// SaveKeyFile writes a secret key encrypted with a passphrase to a new file at path that is only
+// readable by its owner. It fails rather than overwrite an existing file
+func SaveKeyFile(path string, secretKey SecretKey, passphrase []byte, params *KeyFileParams) error {
+	data, err := EncryptSecretKey(secretKey, passphrase, params)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// LoadKeyFile reads a key file saved by SaveKeyFile, returning the secret key and its public key
+func LoadKeyFile(path string, passphrase []byte) (SecretKey, PublicKey, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	return ReadKeyFile(f, passphrase)
+}
@@ -0,0 +1,125 @@
+package bcl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testKeyFileParams() *KeyFileParams {
+	return &KeyFileParams{OpsLimit: CryptoPwHashOpsLimitMin, MemLimit: CryptoPwHashMemLimitMin}
+}
+
+func TestEncryptSecretKey(t *testing.T) {
+	sk, pk, err := NewKeyPair()
+	assert.NoError(t, err)
+	passphrase := []byte("correct horse battery staple")
+
+	data, err := EncryptSecretKey(sk, passphrase, testKeyFileParams())
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(data, []byte("-----BEGIN BCL ENCRYPTED SECRET KEY-----")))
+	assert.NotContains(t, string(data), sk.ToBase64())
+
+	sk2, pk2, err := DecryptSecretKey(data, passphrase)
+	assert.NoError(t, err)
+	assert.Equal(t, sk, sk2)
+	assert.Equal(t, pk, pk2)
+
+	_, err = EncryptSecretKey(sk[1:], passphrase, testKeyFileParams())
+	assert.EqualError(t, err, ErrBadSecretKeyLength.Error())
+	_, err = EncryptSecretKey(sk, passphrase, &KeyFileParams{OpsLimit: 0, MemLimit: CryptoPwHashMemLimitMin})
+	assert.EqualError(t, err, ErrBadPwHashParams.Error())
+	_, err = EncryptSecretKey(sk, passphrase, &KeyFileParams{OpsLimit: CryptoPwHashOpsLimitSensitive + 1, MemLimit: CryptoPwHashMemLimitMin})
+	assert.EqualError(t, err, ErrKeyFileLimitsTooHigh.Error())
+}
+
+func TestDecryptSecretKey(t *testing.T) {
+	sk, err := NewSecretKey()
+	assert.NoError(t, err)
+	passphrase := []byte("passphrase")
+	data, err := EncryptSecretKey(sk, passphrase, testKeyFileParams())
+	assert.NoError(t, err)
+	block, _ := pem.Decode(data)
+	plainPEM, err := sk.ToPEM()
+	assert.NoError(t, err)
+
+	tamper := func(i int) []byte {
+		b := append([]byte{}, block.Bytes...)
+		b[i] ^= 0x01
+		return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: b})
+	}
+	withLimits := func(opsLimit, memLimit uint64) []byte {
+		b := append([]byte{}, block.Bytes...)
+		binary.BigEndian.PutUint64(b[5:], opsLimit)
+		binary.BigEndian.PutUint64(b[13:], memLimit)
+		return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: b})
+	}
+
+	tests := []struct {
+		name       string
+		data       []byte
+		passphrase []byte
+		err        error
+	}{
+		{name: "TestDecryptSecretKey success", data: data, passphrase: passphrase, err: nil},
+		{name: "TestDecryptSecretKey fail passphrase", data: data, passphrase: []byte("Passphrase"), err: ErrBadPassphrase},
+		{name: "TestDecryptSecretKey fail not PEM", data: []byte("not a key file"), passphrase: passphrase, err: ErrBadKeyFile},
+		{name: "TestDecryptSecretKey fail PEM type", data: plainPEM, passphrase: passphrase, err: ErrBadKeyFile},
+		{name: "TestDecryptSecretKey fail magic", data: tamper(0), passphrase: passphrase, err: ErrBadKeyFile},
+		{name: "TestDecryptSecretKey fail version", data: tamper(4), passphrase: passphrase, err: ErrBadKeyFile},
+		{name: "TestDecryptSecretKey fail opslimit", data: tamper(12), passphrase: passphrase, err: ErrBadPwHashParams},
+		{name: "TestDecryptSecretKey fail memlimit", data: tamper(20), passphrase: passphrase, err: ErrBadPassphrase},
+		{name: "TestDecryptSecretKey fail oversized opslimit", data: withLimits(CryptoPwHashOpsLimitMax, CryptoPwHashMemLimitMin), passphrase: passphrase, err: ErrKeyFileLimitsTooHigh},
+		{name: "TestDecryptSecretKey fail oversized memlimit", data: withLimits(CryptoPwHashOpsLimitMin, CryptoPwHashMemLimitMax), passphrase: passphrase, err: ErrKeyFileLimitsTooHigh},
+		{name: "TestDecryptSecretKey fail salt", data: tamper(21), passphrase: passphrase, err: ErrBadPassphrase},
+		{name: "TestDecryptSecretKey fail sealed key", data: tamper(len(block.Bytes) - 1), passphrase: passphrase, err: ErrBadPassphrase},
+		{
+			name:       "TestDecryptSecretKey fail truncated",
+			data:       pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: block.Bytes[:len(block.Bytes)-1]}),
+			passphrase: passphrase,
+			err:        ErrBadKeyFile,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := DecryptSecretKey(tt.data, tt.passphrase)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, sk, got)
+			}
+		})
+	}
+}
+
+func TestSaveKeyFile(t *testing.T) {
+	sk, pk, err := NewKeyPair()
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "key.pem")
+	passphrase := []byte("passphrase")
+
+	assert.NoError(t, SaveKeyFile(path, sk, passphrase, testKeyFileParams()))
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	sk2, pk2, err := LoadKeyFile(path, passphrase)
+	assert.NoError(t, err)
+	assert.Equal(t, sk, sk2)
+	assert.Equal(t, pk, pk2)
+
+	// an existing key file is never overwritten
+	assert.Error(t, SaveKeyFile(path, sk, passphrase, testKeyFileParams()))
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteKeyFile(&buf, sk, passphrase, testKeyFileParams()))
+	sk3, _, err := ReadKeyFile(&buf, passphrase)
+	assert.NoError(t, err)
+	assert.Equal(t, sk, sk3)
+}
@@ -11,24 +11,87 @@ size_t crypto_secretbox_boxzerobytes(void);
 size_t crypto_secretbox_noncebytes(void);
 size_t crypto_secretbox_messagebytes_max(void);
 size_t crypto_secretbox_keybytes(void);
+size_t crypto_secretbox_macbytes(void);
 size_t crypto_box_sealbytes(void);
 size_t crypto_box_publickeybytes(void);
+size_t crypto_box_seedbytes(void);
+size_t randombytes_seedbytes(void);
 size_t crypto_scalarmult_bytes(void);
+size_t crypto_aead_chacha20poly1305_ietf_abytes(void);
+size_t crypto_aead_chacha20poly1305_ietf_npubbytes(void);
+size_t crypto_aead_xchacha20poly1305_ietf_abytes(void);
+size_t crypto_aead_xchacha20poly1305_ietf_npubbytes(void);
+size_t crypto_aead_aes256gcm_abytes(void);
+size_t crypto_aead_aes256gcm_npubbytes(void);
+size_t crypto_sign_publickeybytes(void);
+size_t crypto_sign_secretkeybytes(void);
+size_t crypto_sign_seedbytes(void);
+size_t crypto_sign_bytes(void);
+size_t crypto_pwhash_argon2id_saltbytes(void);
+size_t crypto_pwhash_argon2id_opslimit_min(void);
+size_t crypto_pwhash_argon2id_opslimit_interactive(void);
+size_t crypto_pwhash_argon2id_opslimit_moderate(void);
+size_t crypto_pwhash_argon2id_opslimit_sensitive(void);
+size_t crypto_pwhash_argon2id_opslimit_max(void);
+size_t crypto_pwhash_argon2id_memlimit_min(void);
+size_t crypto_pwhash_argon2id_memlimit_interactive(void);
+size_t crypto_pwhash_argon2id_memlimit_moderate(void);
+size_t crypto_pwhash_argon2id_memlimit_sensitive(void);
+size_t crypto_pwhash_argon2id_memlimit_max(void);
+size_t crypto_core_ristretto255_bytes(void);
+size_t crypto_core_ristretto255_scalarbytes(void);
+size_t crypto_core_ristretto255_hashbytes(void);
+size_t crypto_core_ristretto255_nonreducedscalarbytes(void);
 int sodium_init(void);
 */
 import "C"
 import "fmt"
 
+// The sizes and limits are initialized from libsodium as package variables, rather than in init,
+// so that they are set before any package-level value that depends on them, such as the error
+// messages in errors.go
 var (
-	CryptoSecretBoxZeroBytes    int
-	CryptoSecretBoxBoxZeroBytes int
-	CryptoSecretBoxNonceBytes   int
-	CryptoSecretBoxKeyBytes     int
-	CryptoBoxSealBytes          int
-	CryptoBoxPublicKeyBytes     int
-	CryptoScalarMultBytes       int
-
-	CryptoSecretBoxMessageBytesMax uint64
+	CryptoSecretBoxZeroBytes    = int(C.crypto_secretbox_zerobytes())
+	CryptoSecretBoxBoxZeroBytes = int(C.crypto_secretbox_boxzerobytes())
+	CryptoSecretBoxNonceBytes   = int(C.crypto_secretbox_noncebytes())
+	CryptoSecretBoxKeyBytes     = int(C.crypto_secretbox_keybytes())
+	CryptoSecretBoxMacBytes     = int(C.crypto_secretbox_macbytes())
+	CryptoBoxSealBytes          = int(C.crypto_box_sealbytes())
+	CryptoBoxPublicKeyBytes     = int(C.crypto_box_publickeybytes())
+	CryptoBoxSeedBytes          = int(C.crypto_box_seedbytes())
+	CryptoRandomSeedBytes       = int(C.randombytes_seedbytes())
+	CryptoScalarMultBytes       = int(C.crypto_scalarmult_bytes())
+
+	CryptoAEADChaCha20Poly1305IETFABytes     = int(C.crypto_aead_chacha20poly1305_ietf_abytes())
+	CryptoAEADChaCha20Poly1305IETFNPubBytes  = int(C.crypto_aead_chacha20poly1305_ietf_npubbytes())
+	CryptoAEADXChaCha20Poly1305IETFABytes    = int(C.crypto_aead_xchacha20poly1305_ietf_abytes())
+	CryptoAEADXChaCha20Poly1305IETFNPubBytes = int(C.crypto_aead_xchacha20poly1305_ietf_npubbytes())
+	CryptoAEADAES256GCMABytes                = int(C.crypto_aead_aes256gcm_abytes())
+	CryptoAEADAES256GCMNPubBytes             = int(C.crypto_aead_aes256gcm_npubbytes())
+
+	CryptoSignPublicKeyBytes = int(C.crypto_sign_publickeybytes())
+	CryptoSignSecretKeyBytes = int(C.crypto_sign_secretkeybytes())
+	CryptoSignSeedBytes      = int(C.crypto_sign_seedbytes())
+	CryptoSignBytes          = int(C.crypto_sign_bytes())
+
+	CryptoPwHashSaltBytes           = int(C.crypto_pwhash_argon2id_saltbytes())
+	CryptoPwHashOpsLimitMin         = uint64(C.crypto_pwhash_argon2id_opslimit_min())
+	CryptoPwHashOpsLimitInteractive = uint64(C.crypto_pwhash_argon2id_opslimit_interactive())
+	CryptoPwHashOpsLimitModerate    = uint64(C.crypto_pwhash_argon2id_opslimit_moderate())
+	CryptoPwHashOpsLimitSensitive   = uint64(C.crypto_pwhash_argon2id_opslimit_sensitive())
+	CryptoPwHashOpsLimitMax         = uint64(C.crypto_pwhash_argon2id_opslimit_max())
+	CryptoPwHashMemLimitMin         = uint64(C.crypto_pwhash_argon2id_memlimit_min())
+	CryptoPwHashMemLimitInteractive = uint64(C.crypto_pwhash_argon2id_memlimit_interactive())
+	CryptoPwHashMemLimitModerate    = uint64(C.crypto_pwhash_argon2id_memlimit_moderate())
+	CryptoPwHashMemLimitSensitive   = uint64(C.crypto_pwhash_argon2id_memlimit_sensitive())
+	CryptoPwHashMemLimitMax         = uint64(C.crypto_pwhash_argon2id_memlimit_max())
+
+	CryptoCoreRistretto255Bytes                 = int(C.crypto_core_ristretto255_bytes())
+	CryptoCoreRistretto255ScalarBytes           = int(C.crypto_core_ristretto255_scalarbytes())
+	CryptoCoreRistretto255HashBytes             = int(C.crypto_core_ristretto255_hashbytes())
+	CryptoCoreRistretto255NonReducedScalarBytes = int(C.crypto_core_ristretto255_nonreducedscalarbytes())
+
+	CryptoSecretBoxMessageBytesMax = uint64(C.crypto_secretbox_messagebytes_max())
 )
 
 func init() {
@@ -36,14 +99,4 @@ func init() {
 	if rc < 0 {
 		panic(fmt.Errorf("libsodium initialization failed"))
 	}
-
-	CryptoSecretBoxZeroBytes = int(C.crypto_secretbox_zerobytes())
-	CryptoSecretBoxBoxZeroBytes = int(C.crypto_secretbox_boxzerobytes())
-	CryptoSecretBoxNonceBytes = int(C.crypto_secretbox_noncebytes())
-	CryptoSecretBoxKeyBytes = int(C.crypto_secretbox_keybytes())
-	CryptoBoxSealBytes = int(C.crypto_box_sealbytes())
-	CryptoBoxPublicKeyBytes = int(C.crypto_box_publickeybytes())
-	CryptoScalarMultBytes = int(C.crypto_scalarmult_bytes())
-
-	CryptoSecretBoxMessageBytesMax = uint64(C.crypto_secretbox_messagebytes_max())
 }
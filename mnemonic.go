@@ -0,0 +1,110 @@
+package bcl
+
+import (
+	_ "embed"
+	"strings"
+)
+
+// Seeds are written down as 24 words from the BIP-39 English word list: the 256 bits of the seed
+// followed by the first 8 bits of its SHA-256 hash are split into 11 bit word indices. This is the
+// BIP-39 encoding of entropy, so the mnemonic maps back to exactly the same seed (the BIP-39
+// passphrase and PBKDF2 stretching used by wallets are not applied)
+
+const (
+	SeedBytes     = 32
+	MnemonicWords = 24
+)
+
+//go:embed bip39_english.txt
+var bip39English string
+
+var (
+	bip39Words   = strings.Fields(bip39English)
+	bip39Indices = func() map[string]int {
+		m := make(map[string]int, len(bip39Words))
+		for i, w := range bip39Words {
+			m[w] = i
+		}
+		return m
+	}()
+)
+
+// NewSeed creates a new random seed for use with NewKeyPairFromSeed and NewSigningKeyPairFromSeed
+func NewSeed() ([]byte, error) {
+	seed := make([]byte, SeedBytes)
+	if err := randomBytes(nil, seed); err != nil {
+		return nil, err
+	}
+	return seed, nil
+}
+
+// SeedToMnemonic encodes a seed as a mnemonic of MnemonicWords space separated words
+func SeedToMnemonic(seed []byte) (string, error) {
+	if len(seed) != SeedBytes {
+		return "", ErrBadSeedLength
+	}
+	bits := append(append([]byte{}, seed...), sha256Sum(seed)[0])
+	return strings.Join(bytesToWords(bits, MnemonicWords), " "), nil
+}
+
+// SeedFromMnemonic decodes a mnemonic produced by SeedToMnemonic. Words may be separated by any
+// whitespace and are matched case-insensitively
+func SeedFromMnemonic(mnemonic string) ([]byte, error) {
+	bits, err := wordsToBytes(mnemonic, MnemonicWords, SeedBytes+1)
+	if err != nil {
+		return nil, err
+	}
+
+	seed := bits[:SeedBytes]
+	if sha256Sum(seed)[0] != bits[SeedBytes] {
+		return nil, ErrBadMnemonicChecksum
+	}
+	return seed, nil
+}
+
+// bytesToWords splits the leading bits of b into count 11 bit big-endian word indices, padding
+// with zero bits if b is too short
+func bytesToWords(b []byte, count int) []string {
+	words := make([]string, count)
+	for i := range words {
+		index := 0
+		for j := 0; j < 11; j++ {
+			bit := i*11 + j
+			index <<= 1
+			if bit/8 < len(b) {
+				index |= int(b[bit/8] >> (7 - bit%8) & 1)
+			}
+		}
+		words[i] = bip39Words[index]
+	}
+	return words
+}
+
+// wordsToBytes reverses bytesToWords, decoding exactly count words, separated by any whitespace
+// and matched case-insensitively, into size bytes. Any padding bits must be zero
+func wordsToBytes(mnemonic string, count, size int) ([]byte, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if len(words) != count {
+		return nil, ErrBadMnemonic
+	}
+
+	b := make([]byte, size)
+	for i, w := range words {
+		index, ok := bip39Indices[w]
+		if !ok {
+			return nil, ErrBadMnemonic
+		}
+		for j := 0; j < 11; j++ {
+			bit := i*11 + j
+			v := byte(index >> (10 - j) & 1)
+			if bit/8 >= size {
+				if v != 0 {
+					return nil, ErrBadMnemonic
+				}
+				continue
+			}
+			b[bit/8] |= v << (7 - bit%8)
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,84 @@
+package bcl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeedToMnemonic(t *testing.T) {
+	// 256 bit entropy vectors from the BIP-39 reference implementation
+	tests := []struct {
+		name     string
+		seed     []byte
+		mnemonic string
+	}{
+		{
+			name:     "TestSeedToMnemonic zeros",
+			seed:     bytes.Repeat([]byte{0x00}, 32),
+			mnemonic: strings.Repeat("abandon ", 23) + "art",
+		},
+		{
+			name:     "TestSeedToMnemonic 0x7f",
+			seed:     bytes.Repeat([]byte{0x7f}, 32),
+			mnemonic: "legal winner thank year wave sausage worth useful legal winner thank year wave sausage worth useful legal winner thank year wave sausage worth title",
+		},
+		{
+			name:     "TestSeedToMnemonic 0x80",
+			seed:     bytes.Repeat([]byte{0x80}, 32),
+			mnemonic: "letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic bless",
+		},
+		{
+			name:     "TestSeedToMnemonic ones",
+			seed:     bytes.Repeat([]byte{0xff}, 32),
+			mnemonic: strings.Repeat("zoo ", 23) + "vote",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mnemonic, err := SeedToMnemonic(tt.seed)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.mnemonic, mnemonic)
+
+			seed, err := SeedFromMnemonic(tt.mnemonic)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.seed, seed)
+		})
+	}
+
+	_, err := SeedToMnemonic(make([]byte, 16))
+	assert.EqualError(t, err, ErrBadSeedLength.Error())
+}
+
+func TestSeedFromMnemonic(t *testing.T) {
+	seed, err := NewSeed()
+	assert.NoError(t, err)
+	mnemonic, err := SeedToMnemonic(seed)
+	assert.NoError(t, err)
+	words := strings.Fields(mnemonic)
+
+	tests := []struct {
+		name     string
+		mnemonic string
+		err      error
+	}{
+		{name: "TestSeedFromMnemonic success", mnemonic: mnemonic, err: nil},
+		{name: "TestSeedFromMnemonic success whitespace and case", mnemonic: "  " + strings.ToUpper(strings.Join(words, "\n ")) + "\n", err: nil},
+		{name: "TestSeedFromMnemonic fail too few words", mnemonic: strings.Join(words[1:], " "), err: ErrBadMnemonic},
+		{name: "TestSeedFromMnemonic fail unknown word", mnemonic: strings.Join(append([]string{"notaword"}, words[1:]...), " "), err: ErrBadMnemonic},
+		{name: "TestSeedFromMnemonic fail checksum", mnemonic: strings.Repeat("abandon ", 24), err: ErrBadMnemonicChecksum},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SeedFromMnemonic(tt.mnemonic)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, seed, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,454 @@
+package bcl
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// This file implements the Noise protocol framework (revision 34) for the
+// 25519_ChaChaPoly_BLAKE2b cipher suite, see https://noiseprotocol.org/noise.html
+
+const (
+	noiseHashLen    = 64
+	noiseBlockLen   = 128
+	noiseMaxMessage = 65535
+	noiseSuite      = "_25519_ChaChaPoly_BLAKE2b"
+)
+
+// NoisePattern describes a Noise handshake pattern as its pre-messages and message token sequences
+type NoisePattern struct {
+	Name                 string
+	InitiatorPreMessages []string
+	ResponderPreMessages []string
+	Messages             [][]string
+}
+
+var (
+	// NoiseNK is the NK pattern, in which the initiator knows the responder's static key and the
+	// initiator is not authenticated
+	NoiseNK = NoisePattern{
+		Name:                 "NK",
+		ResponderPreMessages: []string{"s"},
+		Messages: [][]string{
+			{"e", "es"},
+			{"e", "ee"},
+		},
+	}
+	// NoiseXX is the XX pattern, in which both sides transmit their static keys during the handshake
+	NoiseXX = NoisePattern{
+		Name: "XX",
+		Messages: [][]string{
+			{"e"},
+			{"e", "ee", "s", "es"},
+			{"s", "se"},
+		},
+	}
+	// NoiseIK is the IK pattern, in which the initiator knows the responder's static key and
+	// transmits its own static key in the first message
+	NoiseIK = NoisePattern{
+		Name:                 "IK",
+		ResponderPreMessages: []string{"s"},
+		Messages: [][]string{
+			{"e", "es", "s", "ss"},
+			{"e", "ee", "se"},
+		},
+	}
+)
+
+// CipherState encrypts and decrypts Noise messages with a key and a counter nonce
+type CipherState struct {
+	k []byte
+	n uint64
+}
+
+// HasKey returns whether the cipher state has been initialized with a key
+func (cs *CipherState) HasKey() bool {
+	return cs.k != nil
+}
+
+// Nonce returns the nonce that will be used for the next message
+func (cs *CipherState) Nonce() uint64 {
+	return cs.n
+}
+
+// SetNonce sets the nonce that will be used for the next message, which is needed when transport
+// messages can be received out of order
+func (cs *CipherState) SetNonce(n uint64) {
+	cs.n = n
+}
+
+func (cs *CipherState) nonce(n uint64) []byte {
+	nonce := make([]byte, CryptoAEADChaCha20Poly1305IETFNPubBytes)
+	binary.LittleEndian.PutUint64(nonce[4:], n)
+	return nonce
+}
+
+// Encrypt encrypts a plaintext with the supplied associated data and appends the result to out. If
+// the cipher state has no key, the plaintext is appended unchanged
+func (cs *CipherState) Encrypt(out, ad, plaintext []byte) ([]byte, error) {
+	if !cs.HasKey() {
+		return append(out, plaintext...), nil
+	}
+	if cs.n == math.MaxUint64 {
+		return nil, ErrNoiseNonceExhausted
+	}
+	ciphertext, err := chacha20Poly1305Encrypt(cs.k, cs.nonce(cs.n), ad, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	cs.n++
+	return append(out, ciphertext...), nil
+}
+
+// Decrypt authenticates and decrypts a ciphertext with the supplied associated data and appends the
+// result to out. If the cipher state has no key, the ciphertext is appended unchanged
+func (cs *CipherState) Decrypt(out, ad, ciphertext []byte) ([]byte, error) {
+	if !cs.HasKey() {
+		return append(out, ciphertext...), nil
+	}
+	if cs.n == math.MaxUint64 {
+		return nil, ErrNoiseNonceExhausted
+	}
+	plaintext, err := chacha20Poly1305Decrypt(cs.k, cs.nonce(cs.n), ad, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	cs.n++
+	return append(out, plaintext...), nil
+}
+
+// Rekey replaces the key of the cipher state with one derived from the current key
+func (cs *CipherState) Rekey() error {
+	k, err := chacha20Poly1305Encrypt(cs.k, cs.nonce(math.MaxUint64), nil, make([]byte, 32))
+	if err != nil {
+		return err
+	}
+	cs.k = k[:32]
+	return nil
+}
+
+type symmetricState struct {
+	cs CipherState
+	ck []byte
+	h  []byte
+}
+
+func (ss *symmetricState) initialize(protocolName string) error {
+	if len(protocolName) <= noiseHashLen {
+		ss.h = make([]byte, noiseHashLen)
+		copy(ss.h, protocolName)
+	} else {
+		h, err := genericHash(noiseHashLen, nil, []byte(protocolName))
+		if err != nil {
+			return err
+		}
+		ss.h = h
+	}
+	ss.ck = append([]byte{}, ss.h...)
+	return nil
+}
+
+func (ss *symmetricState) mixKey(ikm []byte) error {
+	ck, k, err := noiseHKDF(ss.ck, ikm)
+	if err != nil {
+		return err
+	}
+	ss.ck = ck
+	ss.cs = CipherState{k: k[:32]}
+	return nil
+}
+
+func (ss *symmetricState) mixHash(data []byte) error {
+	h, err := genericHash(noiseHashLen, nil, ss.h, data)
+	if err != nil {
+		return err
+	}
+	ss.h = h
+	return nil
+}
+
+func (ss *symmetricState) encryptAndHash(out, plaintext []byte) ([]byte, error) {
+	ret, err := ss.cs.Encrypt(out, ss.h, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return ret, ss.mixHash(ret[len(out):])
+}
+
+func (ss *symmetricState) decryptAndHash(out, ciphertext []byte) ([]byte, error) {
+	ret, err := ss.cs.Decrypt(out, ss.h, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return ret, ss.mixHash(ciphertext)
+}
+
+func (ss *symmetricState) split() (*CipherState, *CipherState, error) {
+	k1, k2, err := noiseHKDF(ss.ck, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &CipherState{k: k1[:32]}, &CipherState{k: k2[:32]}, nil
+}
+
+// noiseHMAC computes HMAC-BLAKE2b over the concatenated inputs
+func noiseHMAC(key []byte, inputs ...[]byte) ([]byte, error) {
+	block := make([]byte, noiseBlockLen)
+	copy(block, key)
+	ipad := make([]byte, noiseBlockLen)
+	opad := make([]byte, noiseBlockLen)
+	for i := range block {
+		ipad[i] = block[i] ^ 0x36
+		opad[i] = block[i] ^ 0x5c
+	}
+	inner, err := genericHash(noiseHashLen, nil, append([][]byte{ipad}, inputs...)...)
+	if err != nil {
+		return nil, err
+	}
+	return genericHash(noiseHashLen, nil, opad, inner)
+}
+
+// noiseHKDF derives two outputs from a chaining key and input key material
+func noiseHKDF(ck, ikm []byte) ([]byte, []byte, error) {
+	tempKey, err := noiseHMAC(ck, ikm)
+	if err != nil {
+		return nil, nil, err
+	}
+	out1, err := noiseHMAC(tempKey, []byte{0x01})
+	if err != nil {
+		return nil, nil, err
+	}
+	out2, err := noiseHMAC(tempKey, out1, []byte{0x02})
+	if err != nil {
+		return nil, nil, err
+	}
+	return out1, out2, nil
+}
+
+// NoiseConfig configures a Noise HandshakeState
+type NoiseConfig struct {
+	Pattern   NoisePattern
+	Initiator bool
+	Prologue  []byte
+	// StaticKey is the local static secret key, required by patterns in which this side has one
+	StaticKey SecretKey
+	// PeerStatic is the remote static public key, required by patterns in which it is pre-shared
+	PeerStatic PublicKey
+	// EphemeralKey fixes the local ephemeral secret key, and should only be set for testing
+	EphemeralKey SecretKey
+}
+
+// HandshakeState executes a Noise handshake. Messages must be written and read in the order given
+// by the handshake pattern, and once the final message has been processed the resulting pair of
+// CipherStates is returned for transport messages
+type HandshakeState struct {
+	ss        symmetricState
+	s, e      SecretKey
+	sp, ep    PublicKey
+	rs, re    PublicKey
+	initiator bool
+	messages  [][]string
+	index     int
+}
+
+// NewHandshakeState initializes a Noise handshake
+func NewHandshakeState(config NoiseConfig) (*HandshakeState, error) {
+	hs := &HandshakeState{
+		initiator: config.Initiator,
+		messages:  config.Pattern.Messages,
+		rs:        config.PeerStatic,
+	}
+	if config.StaticKey != nil {
+		sp, err := NewPublicKey(config.StaticKey)
+		if err != nil {
+			return nil, err
+		}
+		hs.s, hs.sp = config.StaticKey, sp
+	}
+	if config.EphemeralKey != nil {
+		ep, err := NewPublicKey(config.EphemeralKey)
+		if err != nil {
+			return nil, err
+		}
+		hs.e, hs.ep = config.EphemeralKey, ep
+	}
+	if hs.rs != nil && len(hs.rs) != CryptoBoxPublicKeyBytes {
+		return nil, ErrBadPublicKeyLength
+	}
+
+	if err := hs.ss.initialize("Noise_" + config.Pattern.Name + noiseSuite); err != nil {
+		return nil, err
+	}
+	if err := hs.ss.mixHash(config.Prologue); err != nil {
+		return nil, err
+	}
+	for i, preMessages := range [][]string{config.Pattern.InitiatorPreMessages, config.Pattern.ResponderPreMessages} {
+		local := (i == 0) == hs.initiator
+		for _, token := range preMessages {
+			var key PublicKey
+			switch {
+			case token == "s" && local:
+				key = hs.sp
+			case token == "s":
+				key = hs.rs
+			case token == "e" && local:
+				key = hs.ep
+			case token == "e":
+				key = hs.re
+			}
+			if key == nil {
+				return nil, ErrNoiseMissingKey
+			}
+			if err := hs.ss.mixHash(key); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return hs, nil
+}
+
+// PeerStatic returns the remote static public key, if it is known
+func (hs *HandshakeState) PeerStatic() PublicKey {
+	return hs.rs
+}
+
+// ChannelBinding returns the handshake hash, which uniquely identifies the handshake and can be
+// used for channel binding once the handshake is complete
+func (hs *HandshakeState) ChannelBinding() []byte {
+	return append([]byte{}, hs.ss.h...)
+}
+
+func (hs *HandshakeState) writeTurn() bool {
+	return (hs.index%2 == 0) == hs.initiator
+}
+
+// WriteMessage appends the next handshake message carrying the supplied payload to out. When the
+// final handshake message is written, the initiator-to-responder and responder-to-initiator cipher
+// states are also returned
+func (hs *HandshakeState) WriteMessage(out, payload []byte) ([]byte, *CipherState, *CipherState, error) {
+	if hs.index >= len(hs.messages) || !hs.writeTurn() {
+		return nil, nil, nil, ErrNoiseOutOfOrder
+	}
+	start := len(out)
+	var err error
+	for _, token := range hs.messages[hs.index] {
+		switch token {
+		case "e":
+			if hs.e == nil {
+				if hs.e, hs.ep, err = NewKeyPair(); err != nil {
+					return nil, nil, nil, err
+				}
+			}
+			out = append(out, hs.ep...)
+			err = hs.ss.mixHash(hs.ep)
+		case "s":
+			if hs.s == nil {
+				return nil, nil, nil, ErrNoiseMissingKey
+			}
+			out, err = hs.ss.encryptAndHash(out, hs.sp)
+		default:
+			err = hs.mixDH(token)
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if out, err = hs.ss.encryptAndHash(out, payload); err != nil {
+		return nil, nil, nil, err
+	}
+	if len(out)-start > noiseMaxMessage {
+		return nil, nil, nil, ErrNoiseMessageTooLong
+	}
+	return hs.finishMessage(out)
+}
+
+// ReadMessage processes the next handshake message and appends its payload to out. When the final
+// handshake message is read, the initiator-to-responder and responder-to-initiator cipher states
+// are also returned
+func (hs *HandshakeState) ReadMessage(out, message []byte) ([]byte, *CipherState, *CipherState, error) {
+	if hs.index >= len(hs.messages) || hs.writeTurn() {
+		return nil, nil, nil, ErrNoiseOutOfOrder
+	}
+	if len(message) > noiseMaxMessage {
+		return nil, nil, nil, ErrNoiseMessageTooLong
+	}
+	var err error
+	for _, token := range hs.messages[hs.index] {
+		switch token {
+		case "e":
+			if len(message) < CryptoBoxPublicKeyBytes {
+				return nil, nil, nil, ErrNoiseShortMessage
+			}
+			hs.re = append(PublicKey{}, message[:CryptoBoxPublicKeyBytes]...)
+			message = message[CryptoBoxPublicKeyBytes:]
+			err = hs.ss.mixHash(hs.re)
+		case "s":
+			size := CryptoBoxPublicKeyBytes
+			if hs.ss.cs.HasKey() {
+				size += CryptoAEADChaCha20Poly1305IETFABytes
+			}
+			if len(message) < size {
+				return nil, nil, nil, ErrNoiseShortMessage
+			}
+			var rs []byte
+			if rs, err = hs.ss.decryptAndHash(nil, message[:size]); err == nil {
+				hs.rs = rs
+			}
+			message = message[size:]
+		default:
+			err = hs.mixDH(token)
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if out, err = hs.ss.decryptAndHash(out, message); err != nil {
+		return nil, nil, nil, err
+	}
+	return hs.finishMessage(out)
+}
+
+func (hs *HandshakeState) finishMessage(out []byte) ([]byte, *CipherState, *CipherState, error) {
+	hs.index++
+	if hs.index < len(hs.messages) {
+		return out, nil, nil, nil
+	}
+	c1, c2, err := hs.ss.split()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return out, c1, c2, nil
+}
+
+func (hs *HandshakeState) mixDH(token string) error {
+	var secretKey SecretKey
+	var publicKey PublicKey
+	switch token {
+	case "ee":
+		secretKey, publicKey = hs.e, hs.re
+	case "ss":
+		secretKey, publicKey = hs.s, hs.rs
+	case "es":
+		if hs.initiator {
+			secretKey, publicKey = hs.e, hs.rs
+		} else {
+			secretKey, publicKey = hs.s, hs.re
+		}
+	case "se":
+		if hs.initiator {
+			secretKey, publicKey = hs.s, hs.re
+		} else {
+			secretKey, publicKey = hs.e, hs.rs
+		}
+	default:
+		return ErrNoiseBadPattern
+	}
+	if secretKey == nil || publicKey == nil {
+		return ErrNoiseMissingKey
+	}
+	dh, err := ScalarMult(secretKey, publicKey)
+	if err != nil {
+		return err
+	}
+	return hs.ss.mixKey(dh)
+}
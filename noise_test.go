@@ -0,0 +1,155 @@
+package bcl
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoiseHandshake(t *testing.T) {
+	// expected messages were produced by an independent Noise implementation with the same keys
+	tests := []struct {
+		name     string
+		pattern  NoisePattern
+		messages []string
+		hash     string
+	}{
+		{
+			name:    "TestNoiseHandshake NK",
+			pattern: NoiseNK,
+			messages: []string{
+				"5dfedd3b6bd47f6fa28ee15d969d5bb0ea53774d488bdaf9df1c6e0124b3ef2218a9e436013a08bcd1e4fc2fefbe67635c748641a81af2cefa",
+				"ac01b2209e86354fb853237b5de0f4fab13c7fcbf433a61c019369617fecf10bfbe40759d8838098dab06834853fb044e8076e4ff13230bf73",
+				"7058c4ad3613c2e95e45ebf0e8bc182adde1f52fe7911840b02e5a49dfbeecb814491a21e194e993",
+				"4d4403bb146915ac7895d81a157d72cb02ffb9d80bb28d33afbe689e11711e1ea5c3becb478e40e3",
+			},
+			hash: "5892b6f41b9ccd6716ff49aa5ec4d3e1fa165885b5b7b722be18ea6f86186f1b5ecdd1c339768d70273534331fcc7c7fd89468ddb00f98c27d6d0b32aba91e2e",
+		},
+		{
+			name:    "TestNoiseHandshake XX",
+			pattern: NoiseXX,
+			messages: []string{
+				"5dfedd3b6bd47f6fa28ee15d969d5bb0ea53774d488bdaf9df1c6e0124b3ef227061796c6f61642030",
+				"ac01b2209e86354fb853237b5de0f4fab13c7fcbf433a61c019369617fecf10ba5e54d01ce8f2002c81fc8b2c23efdcf1a089a453fd78d9690d632e327df093127c8f290191f671417f0628c7ab0993f2d5cec88181efe3a2227d392ea99adebcfd246c93b071527de",
+				"fece4f740e1c8bde579102f77ee2080fc34f25ba04ee6ef4d7c8ac3234465e8f9193a44304e6a062d8ed346902eac488629f4ec344402ed3522b2668d71666f06e1be5e9b2bec4717e",
+				"d9e3d5836a56fffd3a4fb35e319cb7739d3a3e254d5ab60637cd7cca437793ba839c800776aa67a4",
+				"fb5614d7315a73aa0d966d7124055b70c89fdbef8e47550f98562f6081fa28d15d0358d59cc9ea75",
+			},
+			hash: "0d920a0853be77f95d82277d6a7cd16fdb533bdcffdbf9491eeeb654b4b49281fcfb5a6bebcdfb8450592e754c78743a4296d1042bf60a62fb9e5123ce52bf3b",
+		},
+		{
+			name:    "TestNoiseHandshake IK",
+			pattern: NoiseIK,
+			messages: []string{
+				"5dfedd3b6bd47f6fa28ee15d969d5bb0ea53774d488bdaf9df1c6e0124b3ef22067cf7be2bbd2098cca8cfa1902fe6de60a78362f04eedb3fb1789b2c570e6c547aaa4de3af3d36c45214117f4dba37b92b7423c3851ab4c4c2da79dd246a6e47ce61f7939f909cdcf",
+				"ac01b2209e86354fb853237b5de0f4fab13c7fcbf433a61c019369617fecf10b336c62a9dd3dd229a518d2fa64d163fb17b244ad9f7637cc7e",
+				"1353dc33852ef5a71f680fa8621bbe87bb999cb3bb0c201a396cc52f4c79f34c8bcd92208b922901",
+				"d05c8e9db442a5e8e5055de4c4d1ec402aa99176c147823b9a091ba159abbab82efdc5860a70c35f",
+			},
+			hash: "52fed55954bccc484c1d0e1909d93de2a2652a7687638f24eee2a176d16409aea1d2a4b509c4882d4f0db1fa6a147eba3b0b2ef303e46889d27b38994a9cd7d4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responderStatic := SecretKey(bytes.Repeat([]byte{0x02}, CryptoSecretBoxKeyBytes))
+			responderPublic, err := NewPublicKey(responderStatic)
+			assert.NoError(t, err)
+
+			initiatorConfig := NoiseConfig{
+				Pattern:      tt.pattern,
+				Initiator:    true,
+				Prologue:     []byte("bcl"),
+				EphemeralKey: SecretKey(bytes.Repeat([]byte{0x03}, CryptoSecretBoxKeyBytes)),
+			}
+			if tt.pattern.Name != "NK" {
+				initiatorConfig.StaticKey = SecretKey(bytes.Repeat([]byte{0x01}, CryptoSecretBoxKeyBytes))
+			}
+			if tt.pattern.Name != "XX" {
+				initiatorConfig.PeerStatic = responderPublic
+			}
+			initiator, err := NewHandshakeState(initiatorConfig)
+			assert.NoError(t, err)
+			responder, err := NewHandshakeState(NoiseConfig{
+				Pattern:      tt.pattern,
+				Prologue:     []byte("bcl"),
+				StaticKey:    responderStatic,
+				EphemeralKey: SecretKey(bytes.Repeat([]byte{0x04}, CryptoSecretBoxKeyBytes)),
+			})
+			assert.NoError(t, err)
+
+			var c1, c2 *CipherState
+			for i := 0; i < len(tt.pattern.Messages); i++ {
+				writer, reader := initiator, responder
+				if i%2 == 1 {
+					writer, reader = responder, initiator
+				}
+				payload := []byte(fmt.Sprintf("payload %d", i))
+				msg, w1, w2, err := writer.WriteMessage(nil, payload)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.messages[i], hex.EncodeToString(msg))
+
+				got, r1, r2, err := reader.ReadMessage(nil, msg)
+				assert.NoError(t, err)
+				assert.Equal(t, payload, got)
+				assert.Equal(t, w1 == nil, r1 == nil)
+				if w1 != nil {
+					c1, c2 = w1, w2
+					if writer == responder {
+						c1, c2 = r1, r2
+					}
+				}
+			}
+			assert.Equal(t, tt.hash, hex.EncodeToString(initiator.ChannelBinding()))
+			assert.Equal(t, tt.hash, hex.EncodeToString(responder.ChannelBinding()))
+
+			t1, err := c1.Encrypt(nil, nil, []byte("transport from initiator"))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.messages[len(tt.messages)-2], hex.EncodeToString(t1))
+			t2, err := c2.Encrypt(nil, nil, []byte("transport from responder"))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.messages[len(tt.messages)-1], hex.EncodeToString(t2))
+		})
+	}
+}
+
+func TestNoiseHandshakeErrors(t *testing.T) {
+	responderStatic, responderPublic, err := NewKeyPair()
+	assert.NoError(t, err)
+
+	_, err = NewHandshakeState(NoiseConfig{Pattern: NoiseIK, Initiator: true})
+	assert.EqualError(t, err, ErrNoiseMissingKey.Error())
+
+	initiator, err := NewHandshakeState(NoiseConfig{Pattern: NoiseNK, Initiator: true, PeerStatic: responderPublic})
+	assert.NoError(t, err)
+	responder, err := NewHandshakeState(NoiseConfig{Pattern: NoiseNK, StaticKey: responderStatic})
+	assert.NoError(t, err)
+
+	_, _, _, err = responder.WriteMessage(nil, nil)
+	assert.EqualError(t, err, ErrNoiseOutOfOrder.Error())
+
+	msg, _, _, err := initiator.WriteMessage(nil, []byte("hello"))
+	assert.NoError(t, err)
+	msg[len(msg)-1] ^= 0x01
+	_, _, _, err = responder.ReadMessage(nil, msg)
+	assert.EqualError(t, err, ErrDecryptionFailed.Error())
+
+	_, _, _, err = responder.ReadMessage(nil, msg[:8])
+	assert.Error(t, err)
+}
+
+func TestCipherStateRekey(t *testing.T) {
+	k := bytes.Repeat([]byte{0x05}, 32)
+	sender, receiver := &CipherState{k: k}, &CipherState{k: append([]byte{}, k...)}
+	assert.NoError(t, sender.Rekey())
+	assert.NoError(t, receiver.Rekey())
+
+	ct, err := sender.Encrypt(nil, []byte("ad"), []byte("hello"))
+	assert.NoError(t, err)
+	pt, err := receiver.Decrypt(nil, []byte("ad"), ct)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), pt)
+	assert.Equal(t, uint64(1), receiver.Nonce())
+}
@@ -5,9 +5,9 @@ int sodium_memcmp(const void * const b1_, const void * const b2_, size_t len);
 */
 import "C"
 import (
-	"crypto/rand"
 	"encoding/base64"
 	"hash/fnv"
+	"io"
 	"unsafe"
 )
 
@@ -15,8 +15,14 @@ type Nonce []byte
 
 // NewNonce creates a new random nonce
 func NewNonce() (Nonce, error) {
+	return NewNonceFromReader(nil)
+}
+
+// NewNonceFromReader creates a new nonce using randomness read from r, or from the package-level
+// random source if r is nil
+func NewNonceFromReader(r io.Reader) (Nonce, error) {
 	n := make([]byte, CryptoSecretBoxNonceBytes)
-	if _, err := rand.Read(n); err != nil {
+	if err := randomBytes(r, n); err != nil {
 		return nil, err
 	}
 	return Nonce(n), nil
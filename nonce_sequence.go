@@ -0,0 +1,118 @@
+package bcl
+
+/*
+void sodium_increment(unsigned char *n, const size_t nlen);
+*/
+import "C"
+import (
+	"bytes"
+	"sync"
+	"unsafe"
+)
+
+// NonceSequenceCounterBytes is the number of trailing nonce bytes that a NonceSequence uses as
+// its counter, the remaining leading bytes form the sequence prefix
+const NonceSequenceCounterBytes = 8
+
+// NonceStore persists the state of a NonceSequence so that its counter survives restarts
+type NonceStore interface {
+	// LoadNonce returns the last nonce saved by a sequence, or nil if nothing has been saved yet
+	LoadNonce() (Nonce, error)
+	// SaveNonce durably records a nonce before the sequence hands it out
+	SaveNonce(nonce Nonce) error
+}
+
+// NonceSequence produces unique nonces for use with a single secret key. Each nonce consists of
+// a fixed prefix followed by a little-endian counter that is incremented for every nonce, and the
+// sequence refuses to continue once the counter would wrap around
+type NonceSequence struct {
+	mu    sync.Mutex
+	last  Nonce
+	store NonceStore
+}
+
+// NewNonceSequence creates a nonce sequence, resuming from the nonce held by the supplied store if
+// there is one and otherwise starting a new sequence with a random prefix. The store may be nil,
+// in which case the sequence only lives in memory
+func NewNonceSequence(store NonceStore) (*NonceSequence, error) {
+	last, err := loadNonce(store)
+	if err != nil {
+		return nil, err
+	}
+	if last == nil {
+		last = make([]byte, CryptoSecretBoxNonceBytes)
+		if err := randomBytes(nil, last[:CryptoSecretBoxNonceBytes-NonceSequenceCounterBytes]); err != nil {
+			return nil, err
+		}
+	}
+	return &NonceSequence{last: last, store: store}, nil
+}
+
+// NewNonceSequenceWithPrefix creates a nonce sequence with a caller-supplied prefix (e.g. a device
+// identifier) of length CryptoSecretBoxNonceBytes-NonceSequenceCounterBytes, so that uniqueness
+// does not depend on a random number generator. If the supplied store holds a nonce, the sequence
+// resumes from it and its prefix must match
+func NewNonceSequenceWithPrefix(prefix []byte, store NonceStore) (*NonceSequence, error) {
+	if len(prefix) != CryptoSecretBoxNonceBytes-NonceSequenceCounterBytes {
+		return nil, ErrBadNoncePrefixLength
+	}
+	last, err := loadNonce(store)
+	if err != nil {
+		return nil, err
+	}
+	if last == nil {
+		last = make([]byte, CryptoSecretBoxNonceBytes)
+		copy(last, prefix)
+	} else if !bytes.Equal(last[:len(prefix)], prefix) {
+		return nil, ErrNonceSequencePrefixMismatch
+	}
+	return &NonceSequence{last: last, store: store}, nil
+}
+
+func loadNonce(store NonceStore) (Nonce, error) {
+	if store == nil {
+		return nil, nil
+	}
+	last, err := store.LoadNonce()
+	if err != nil {
+		return nil, err
+	}
+	if last == nil {
+		return nil, nil
+	}
+	return NonceFromBytes(append([]byte{}, last...))
+}
+
+// Next returns the next nonce in the sequence. If the sequence has a store, the nonce is saved
+// before it is returned, and a nonce that could not be saved is never handed out
+func (s *NonceSequence) Next() (Nonce, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := append(Nonce{}, s.last...)
+	counter := next[CryptoSecretBoxNonceBytes-NonceSequenceCounterBytes:]
+	if isExhausted(counter) {
+		return nil, ErrNonceSequenceExhausted
+	}
+	C.sodium_increment(
+		(*C.uchar)(unsafe.Pointer(&counter[0])),
+		C.size_t(len(counter)),
+	)
+
+	if s.store != nil {
+		if err := s.store.SaveNonce(next); err != nil {
+			return nil, err
+		}
+	}
+	s.last = next
+	return append(Nonce{}, next...), nil
+}
+
+func isExhausted(counter []byte) bool {
+	for _, v := range counter {
+		if v != 0xff {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,154 @@
+package bcl
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryNonceStore struct {
+	nonce Nonce
+	err   error
+}
+
+func (m *memoryNonceStore) LoadNonce() (Nonce, error) {
+	return m.nonce, nil
+}
+
+func (m *memoryNonceStore) SaveNonce(nonce Nonce) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.nonce = append(Nonce{}, nonce...)
+	return nil
+}
+
+func TestNonceSequenceNext(t *testing.T) {
+	seq, err := NewNonceSequence(nil)
+	assert.NoError(t, err)
+
+	seen := make(map[uint64]bool)
+	var prev Nonce
+	for i := 0; i < 1000; i++ {
+		n, err := seq.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, CryptoSecretBoxNonceBytes, len(n))
+		assert.False(t, seen[n.Hash()])
+		seen[n.Hash()] = true
+		if prev != nil {
+			prefixLen := CryptoSecretBoxNonceBytes - NonceSequenceCounterBytes
+			assert.Equal(t, prev[:prefixLen], n[:prefixLen])
+		}
+		prev = n
+	}
+}
+
+func TestNonceSequenceResume(t *testing.T) {
+	store := &memoryNonceStore{}
+	seq, err := NewNonceSequence(store)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		_, err := seq.Next()
+		assert.NoError(t, err)
+	}
+	last := append(Nonce{}, store.nonce...)
+
+	resumed, err := NewNonceSequence(store)
+	assert.NoError(t, err)
+	n, err := resumed.Next()
+	assert.NoError(t, err)
+	assert.True(t, n.NotEqual(last))
+	prefixLen := CryptoSecretBoxNonceBytes - NonceSequenceCounterBytes
+	assert.Equal(t, last[:prefixLen], n[:prefixLen])
+	assert.Equal(t, last[prefixLen]+1, n[prefixLen])
+}
+
+func TestNewNonceSequenceWithPrefix(t *testing.T) {
+	prefixLen := CryptoSecretBoxNonceBytes - NonceSequenceCounterBytes
+	tests := []struct {
+		name   string
+		prefix []byte
+		store  func() NonceStore
+		err    error
+	}{
+		{
+			name:   "TestNewNonceSequenceWithPrefix success",
+			prefix: bytes.Repeat([]byte{0x01}, prefixLen),
+			store: func() NonceStore {
+				return &memoryNonceStore{}
+			},
+			err: nil,
+		},
+		{
+			name:   "TestNewNonceSequenceWithPrefix success resume",
+			prefix: bytes.Repeat([]byte{0x01}, prefixLen),
+			store: func() NonceStore {
+				return &memoryNonceStore{nonce: bytes.Repeat([]byte{0x01}, CryptoSecretBoxNonceBytes)}
+			},
+			err: nil,
+		},
+		{
+			name:   "TestNewNonceSequenceWithPrefix fail length",
+			prefix: bytes.Repeat([]byte{0x01}, prefixLen-1),
+			store: func() NonceStore {
+				return nil
+			},
+			err: ErrBadNoncePrefixLength,
+		},
+		{
+			name:   "TestNewNonceSequenceWithPrefix fail mismatch",
+			prefix: bytes.Repeat([]byte{0x01}, prefixLen),
+			store: func() NonceStore {
+				return &memoryNonceStore{nonce: bytes.Repeat([]byte{0x02}, CryptoSecretBoxNonceBytes)}
+			},
+			err: ErrNonceSequencePrefixMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seq, err := NewNonceSequenceWithPrefix(tt.prefix, tt.store())
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+				n, err := seq.Next()
+				assert.NoError(t, err)
+				assert.Equal(t, tt.prefix, []byte(n[:prefixLen]))
+			}
+		})
+	}
+}
+
+func TestNonceSequenceExhausted(t *testing.T) {
+	prefixLen := CryptoSecretBoxNonceBytes - NonceSequenceCounterBytes
+	last := append(bytes.Repeat([]byte{0x00}, prefixLen), 0xfe)
+	last = append(last, bytes.Repeat([]byte{0xff}, NonceSequenceCounterBytes-1)...)
+	seq, err := NewNonceSequence(&memoryNonceStore{nonce: last})
+	assert.NoError(t, err)
+
+	n, err := seq.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{0xff}, NonceSequenceCounterBytes), []byte(n[prefixLen:]))
+
+	_, err = seq.Next()
+	assert.EqualError(t, err, ErrNonceSequenceExhausted.Error())
+}
+
+func TestNonceSequenceSaveFailure(t *testing.T) {
+	store := &memoryNonceStore{}
+	seq, err := NewNonceSequence(store)
+	assert.NoError(t, err)
+	first, err := seq.Next()
+	assert.NoError(t, err)
+
+	store.err = fmt.Errorf("disk full")
+	_, err = seq.Next()
+	assert.EqualError(t, err, "disk full")
+
+	store.err = nil
+	second, err := seq.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, first[CryptoSecretBoxNonceBytes-NonceSequenceCounterBytes]+1, second[CryptoSecretBoxNonceBytes-NonceSequenceCounterBytes])
+}
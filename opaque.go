@@ -0,0 +1,635 @@
+package bcl
+
+// This file implements the OPAQUE augmented password-authenticated key exchange of RFC 9807 with
+// ristretto255-SHA512 as the OPRF, HKDF-SHA-512, HMAC-SHA-512, 3DH over ristretto255 as the AKE
+// and Argon2id as the key stretching function. The server stores a record derived from the
+// password during registration but never sees the password itself, and a successful login gives
+// both sides the same session key. Messages are opaque byte slices that the application carries
+// between client and server however it likes
+
+const (
+	opaqueNonceBytes  = 32
+	opaqueSeedBytes   = 32
+	opaqueHashBytes   = 64
+	opaqueKeyBytes    = 32 // serialized ristretto255 elements and scalars
+	opaqueEnvelope    = opaqueNonceBytes + opaqueHashBytes
+	opaqueRecordBytes = opaqueKeyBytes + opaqueHashBytes + opaqueEnvelope
+	opaqueKE1Bytes    = 3 * opaqueKeyBytes
+	opaqueCredential  = opaqueKeyBytes + opaqueNonceBytes + opaqueKeyBytes + opaqueEnvelope
+	opaqueKE2Bytes    = opaqueCredential + opaqueNonceBytes + opaqueKeyBytes + opaqueHashBytes
+)
+
+// OPAQUEOPRFSeedBytes is the length of the server's OPRF seed
+const OPAQUEOPRFSeedBytes = opaqueHashBytes
+
+// OPAQUEConfig holds the parameters both sides of an OPAQUE exchange must agree on
+type OPAQUEConfig struct {
+	// Context is bound into every login transcript, e.g. an application name and version
+	Context []byte
+	// ServerIdentity defaults to the server's public key if empty
+	ServerIdentity []byte
+	// OpsLimit and MemLimit are the Argon2id parameters used to stretch the OPRF output
+	OpsLimit uint64
+	MemLimit uint64
+
+	// ksf replaces Argon2id as the key stretching function if non-nil, e.g. with the identity
+	// function of the RFC 9807 test vectors
+	ksf func([]byte) ([]byte, error)
+}
+
+// DefaultOPAQUEConfig returns a configuration using libsodium's interactive Argon2id limits
+func DefaultOPAQUEConfig(context []byte) *OPAQUEConfig {
+	return &OPAQUEConfig{
+		Context:  context,
+		OpsLimit: CryptoPwHashOpsLimitInteractive,
+		MemLimit: CryptoPwHashMemLimitInteractive,
+	}
+}
+
+// checkOPAQUEConfig rejects a nil config, or one whose Argon2id limits are zero, before any
+// message is exchanged
+func checkOPAQUEConfig(config *OPAQUEConfig) error {
+	if config == nil || (config.ksf == nil && (config.OpsLimit == 0 || config.MemLimit == 0)) {
+		return ErrBadOPAQUEConfig
+	}
+	return nil
+}
+
+// OPAQUERecord is the registration record the server stores for a client
+type OPAQUERecord []byte
+
+// NewOPAQUEServerKeys returns a random server secret key and OPRF seed. Both must be kept secret
+// and reused for every client, as changing them invalidates all registration records
+func NewOPAQUEServerKeys() (RistrettoScalar, []byte, error) {
+	secretKey, err := NewRistrettoScalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	oprfSeed := make([]byte, OPAQUEOPRFSeedBytes)
+	if err := randomBytes(nil, oprfSeed); err != nil {
+		return nil, nil, err
+	}
+	return secretKey, oprfSeed, nil
+}
+
+// opaqueDeriveKeyPair is DeriveDiffieHellmanKeyPair from RFC 9807
+func opaqueDeriveKeyPair(seed []byte) (RistrettoScalar, RistrettoPoint, error) {
+	return DeriveOPRFKeyPair(OPRFModeBase, seed, []byte("OPAQUE-DeriveDiffieHellmanKeyPair"))
+}
+
+func opaqueRandom(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if err := randomBytes(nil, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// opaqueCleartextCredentials binds the server public key and both identities, which default to
+// the public keys, into the envelope and the login transcript
+type opaqueCleartextCredentials struct {
+	serverPublicKey RistrettoPoint
+	serverIdentity  []byte
+	clientIdentity  []byte
+}
+
+func newOPAQUECleartextCredentials(serverPublicKey, clientPublicKey RistrettoPoint, serverIdentity, clientIdentity []byte) opaqueCleartextCredentials {
+	if len(serverIdentity) == 0 {
+		serverIdentity = serverPublicKey
+	}
+	if len(clientIdentity) == 0 {
+		clientIdentity = clientPublicKey
+	}
+	return opaqueCleartextCredentials{serverPublicKey, serverIdentity, clientIdentity}
+}
+
+func (c opaqueCleartextCredentials) bytes() []byte {
+	return append(append([]byte{}, c.serverPublicKey...), lengthPrefixed(c.serverIdentity, c.clientIdentity)...)
+}
+
+// opaqueEnvelopeKeys derives the authentication key, export key and client key pair from the
+// randomized password and the envelope nonce
+func opaqueEnvelopeKeys(randomizedPassword, nonce []byte) (authKey, exportKey []byte, secretKey RistrettoScalar, publicKey RistrettoPoint, err error) {
+	expand := func(label string, n int) []byte {
+		if err != nil {
+			return nil
+		}
+		var out []byte
+		out, err = hkdfSHA512Expand(randomizedPassword, append(append([]byte{}, nonce...), label...), n)
+		return out
+	}
+	authKey = expand("AuthKey", opaqueHashBytes)
+	exportKey = expand("ExportKey", opaqueHashBytes)
+	seed := expand("PrivateKey", opaqueSeedBytes)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	secretKey, publicKey, err = opaqueDeriveKeyPair(seed)
+	return authKey, exportKey, secretKey, publicKey, err
+}
+
+// stretch computes the randomized password from the OPRF output, hardened with Argon2id. The
+// salt is fixed because the OPRF output is already unique to the server and credential
+func (config *OPAQUEConfig) stretch(oprfOutput []byte) ([]byte, error) {
+	var stretched []byte
+	var err error
+	if config.ksf != nil {
+		stretched, err = config.ksf(oprfOutput)
+	} else {
+		stretched, err = argon2id(oprfOutput, make([]byte, CryptoPwHashSaltBytes), config.OpsLimit, config.MemLimit, opaqueHashBytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hkdfSHA512Extract(nil, append(append([]byte{}, oprfOutput...), stretched...))
+}
+
+// opaqueOPRFServer returns an OPRF server keyed with the per-credential key derived from the
+// server's OPRF seed
+func opaqueOPRFServer(oprfSeed, credentialIdentifier []byte) (*OPRFServer, error) {
+	if len(oprfSeed) != OPAQUEOPRFSeedBytes {
+		return nil, ErrBadOPAQUEOPRFSeed
+	}
+	seed, err := hkdfSHA512Expand(oprfSeed, append(append([]byte{}, credentialIdentifier...), "OprfKey"...), opaqueKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	oprfKey, _, err := DeriveOPRFKeyPair(OPRFModeBase, seed, []byte("OPAQUE-DeriveKeyPair"))
+	if err != nil {
+		return nil, err
+	}
+	return NewOPRFServer(OPRFModeBase, oprfKey)
+}
+
+// opaqueExpandLabel is Expand-Label from RFC 9807, section 6.4.2
+func opaqueExpandLabel(secret []byte, label string, context []byte) ([]byte, error) {
+	customLabel := []byte{0, opaqueHashBytes, byte(len("OPAQUE-") + len(label))}
+	customLabel = append(append(customLabel, "OPAQUE-"...), label...)
+	customLabel = append(append(customLabel, byte(len(context))), context...)
+	return hkdfSHA512Expand(secret, customLabel, opaqueHashBytes)
+}
+
+// opaqueKeys holds the MAC keys and session key derived from a login transcript
+type opaqueKeys struct {
+	serverMAC  []byte
+	clientMAC  []byte
+	sessionKey []byte
+}
+
+// deriveOPAQUEKeys computes the 3DH key schedule and the MACs of both sides for a login preamble
+func deriveOPAQUEKeys(preamble []byte, dhs ...[]byte) (*opaqueKeys, error) {
+	var ikm []byte
+	for _, dh := range dhs {
+		ikm = append(ikm, dh...)
+	}
+	prk, err := hkdfSHA512Extract(nil, ikm)
+	if err != nil {
+		return nil, err
+	}
+	preambleHash := sha512Sum(preamble)
+	handshakeSecret, err := opaqueExpandLabel(prk, "HandshakeSecret", preambleHash)
+	if err != nil {
+		return nil, err
+	}
+	sessionKey, err := opaqueExpandLabel(prk, "SessionKey", preambleHash)
+	if err != nil {
+		return nil, err
+	}
+	km2, err := opaqueExpandLabel(handshakeSecret, "ServerMAC", nil)
+	if err != nil {
+		return nil, err
+	}
+	km3, err := opaqueExpandLabel(handshakeSecret, "ClientMAC", nil)
+	if err != nil {
+		return nil, err
+	}
+	serverMAC, err := hmacSHA512(km2, preambleHash)
+	if err != nil {
+		return nil, err
+	}
+	clientMAC, err := hmacSHA512(km3, sha512Sum(preamble, serverMAC))
+	if err != nil {
+		return nil, err
+	}
+	return &opaqueKeys{serverMAC: serverMAC, clientMAC: clientMAC, sessionKey: sessionKey}, nil
+}
+
+// preamble builds the login transcript covered by both MACs
+func (config *OPAQUEConfig) preamble(credentials opaqueCleartextCredentials, ke1, credentialResponse, serverNonce, serverKeyshare []byte) []byte {
+	out := append([]byte("OPAQUEv1-"), lengthPrefixed(config.Context, credentials.clientIdentity)...)
+	out = append(out, ke1...)
+	out = append(out, lengthPrefixed(credentials.serverIdentity)...)
+	out = append(out, credentialResponse...)
+	out = append(out, serverNonce...)
+	return append(out, serverKeyshare...)
+}
+
+// opaqueSessionSecretKey derives a SecretKey for SymmetricEncrypt and NewAEAD from the 64 byte
+// OPAQUE session key
+func opaqueSessionSecretKey(sessionKey []byte) (SecretKey, error) {
+	key, err := hkdfSHA512Expand(sessionKey, []byte("bcl-OPAQUE-SessionSecretKey"), CryptoSecretBoxKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return SecretKey(key), nil
+}
+
+func opaqueDH(secretKey RistrettoScalar, publicKey RistrettoPoint) ([]byte, error) {
+	if err := oprfElement(publicKey); err != nil {
+		return nil, ErrBadOPAQUEMessage
+	}
+	return publicKey.ScalarMult(secretKey)
+}
+
+func xorBytes(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range out {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
+
+// OPAQUEClient runs the client side of a single registration or login. It must not be reused
+type OPAQUEClient struct {
+	config         *OPAQUEConfig
+	password       []byte
+	clientIdentity []byte
+	oprf           *OPRFClient
+	blind          RistrettoScalar
+	ke1            []byte
+	keyshareSecret RistrettoScalar
+}
+
+// NewOPAQUEClient returns a client for the supplied password. clientIdentity, e.g. a user name,
+// defaults to the client's public key if empty and must match the one given to the server
+func NewOPAQUEClient(config *OPAQUEConfig, password, clientIdentity []byte) (*OPAQUEClient, error) {
+	if err := checkOPAQUEConfig(config); err != nil {
+		return nil, err
+	}
+	oprf, err := NewOPRFClient(OPRFModeBase, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &OPAQUEClient{
+		config:         config,
+		password:       append([]byte{}, password...),
+		clientIdentity: append([]byte{}, clientIdentity...),
+		oprf:           oprf,
+	}, nil
+}
+
+// RegistrationRequest starts a registration, returning the request to send to the server
+func (c *OPAQUEClient) RegistrationRequest() ([]byte, error) {
+	if c.blind != nil {
+		return nil, ErrOPAQUEOutOfOrder
+	}
+	blind, err := NewRistrettoScalar()
+	if err != nil {
+		return nil, err
+	}
+	return c.registrationRequestWith(blind)
+}
+
+func (c *OPAQUEClient) registrationRequestWith(blind RistrettoScalar) ([]byte, error) {
+	blinded, err := c.oprf.blindWith(c.password, blind)
+	if err != nil {
+		return nil, err
+	}
+	c.blind = blind
+	return blinded, nil
+}
+
+// FinalizeRegistration processes the server's registration response, returning the record to
+// upload to the server and an export key that only the client can recompute at login
+func (c *OPAQUEClient) FinalizeRegistration(response []byte) (OPAQUERecord, []byte, error) {
+	nonce, err := opaqueRandom(opaqueNonceBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.finalizeRegistrationWith(response, nonce)
+}
+
+func (c *OPAQUEClient) finalizeRegistrationWith(response, nonce []byte) (OPAQUERecord, []byte, error) {
+	if c.blind == nil || c.ke1 != nil {
+		return nil, nil, ErrOPAQUEOutOfOrder
+	}
+	if len(response) != 2*opaqueKeyBytes {
+		return nil, nil, ErrBadOPAQUEMessage
+	}
+	evaluated, serverPublicKey := RistrettoPoint(response[:opaqueKeyBytes]), RistrettoPoint(response[opaqueKeyBytes:])
+	if err := oprfElement(serverPublicKey); err != nil {
+		return nil, nil, ErrBadOPAQUEMessage
+	}
+	randomizedPassword, err := c.randomizedPassword(evaluated)
+	if err != nil {
+		return nil, nil, err
+	}
+	maskingKey, err := hkdfSHA512Expand(randomizedPassword, []byte("MaskingKey"), opaqueHashBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	authKey, exportKey, _, clientPublicKey, err := opaqueEnvelopeKeys(randomizedPassword, nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+	credentials := newOPAQUECleartextCredentials(serverPublicKey, clientPublicKey, c.config.ServerIdentity, c.clientIdentity)
+	authTag, err := hmacSHA512(authKey, nonce, credentials.bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	record := append(append(append(OPAQUERecord{}, clientPublicKey...), maskingKey...), nonce...)
+	return append(record, authTag...), exportKey, nil
+}
+
+func (c *OPAQUEClient) randomizedPassword(evaluated RistrettoPoint) ([]byte, error) {
+	oprfOutput, err := c.oprf.Finalize(c.password, c.blind, nil, evaluated, nil)
+	if err != nil {
+		return nil, ErrBadOPAQUEMessage
+	}
+	return c.config.stretch(oprfOutput)
+}
+
+// LoginStart starts a login, returning the KE1 message to send to the server
+func (c *OPAQUEClient) LoginStart() ([]byte, error) {
+	blind, err := NewRistrettoScalar()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := opaqueRandom(opaqueNonceBytes)
+	if err != nil {
+		return nil, err
+	}
+	keyshareSeed, err := opaqueRandom(opaqueSeedBytes)
+	if err != nil {
+		return nil, err
+	}
+	return c.loginStartWith(blind, nonce, keyshareSeed)
+}
+
+func (c *OPAQUEClient) loginStartWith(blind RistrettoScalar, nonce, keyshareSeed []byte) ([]byte, error) {
+	if c.blind != nil {
+		return nil, ErrOPAQUEOutOfOrder
+	}
+	blinded, err := c.oprf.blindWith(c.password, blind)
+	if err != nil {
+		return nil, err
+	}
+	keyshareSecret, keyshare, err := opaqueDeriveKeyPair(keyshareSeed)
+	if err != nil {
+		return nil, err
+	}
+	c.blind, c.keyshareSecret = blind, keyshareSecret
+	c.ke1 = append(append(append([]byte{}, blinded...), nonce...), keyshare...)
+	return c.ke1, nil
+}
+
+// LoginFinish processes the server's KE2 message. If the password was correct and the server
+// holds the matching record, it returns the KE3 message to send back, the session key and the
+// export key established at registration. A wrong password gives ErrOPAQUEEnvelopeRecovery
+func (c *OPAQUEClient) LoginFinish(ke2 []byte) ([]byte, SecretKey, []byte, error) {
+	ke3, keys, exportKey, err := c.loginFinish(ke2)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	sessionKey, err := opaqueSessionSecretKey(keys.sessionKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return ke3, sessionKey, exportKey, nil
+}
+
+// loginFinish is LoginFinish returning the raw OPAQUE keys
+func (c *OPAQUEClient) loginFinish(ke2 []byte) ([]byte, *opaqueKeys, []byte, error) {
+	if c.ke1 == nil || c.keyshareSecret == nil {
+		return nil, nil, nil, ErrOPAQUEOutOfOrder
+	}
+	if len(ke2) != opaqueKE2Bytes {
+		return nil, nil, nil, ErrBadOPAQUEMessage
+	}
+	keyshareSecret := c.keyshareSecret
+	c.keyshareSecret = nil
+
+	credentialResponse := ke2[:opaqueCredential]
+	evaluated := RistrettoPoint(credentialResponse[:opaqueKeyBytes])
+	maskingNonce := credentialResponse[opaqueKeyBytes : opaqueKeyBytes+opaqueNonceBytes]
+	maskedResponse := credentialResponse[opaqueKeyBytes+opaqueNonceBytes:]
+	serverNonce := ke2[opaqueCredential : opaqueCredential+opaqueNonceBytes]
+	serverKeyshare := RistrettoPoint(ke2[opaqueCredential+opaqueNonceBytes : opaqueCredential+opaqueNonceBytes+opaqueKeyBytes])
+	serverMAC := ke2[opaqueCredential+opaqueNonceBytes+opaqueKeyBytes:]
+
+	randomizedPassword, err := c.randomizedPassword(evaluated)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	maskingKey, err := hkdfSHA512Expand(randomizedPassword, []byte("MaskingKey"), opaqueHashBytes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	pad, err := hkdfSHA512Expand(maskingKey, append(append([]byte{}, maskingNonce...), "CredentialResponsePad"...), len(maskedResponse))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	unmasked := xorBytes(maskedResponse, pad)
+	serverPublicKey := RistrettoPoint(unmasked[:opaqueKeyBytes])
+	envelopeNonce, envelopeTag := unmasked[opaqueKeyBytes:opaqueKeyBytes+opaqueNonceBytes], unmasked[opaqueKeyBytes+opaqueNonceBytes:]
+
+	authKey, exportKey, clientSecretKey, clientPublicKey, err := opaqueEnvelopeKeys(randomizedPassword, envelopeNonce)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	credentials := newOPAQUECleartextCredentials(serverPublicKey, clientPublicKey, c.config.ServerIdentity, c.clientIdentity)
+	expectedTag, err := hmacSHA512(authKey, envelopeNonce, credentials.bytes())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !constantTimeEqual(expectedTag, envelopeTag) {
+		return nil, nil, nil, ErrOPAQUEEnvelopeRecovery
+	}
+
+	var dhs [3][]byte
+	pairs := [3]struct {
+		secretKey RistrettoScalar
+		publicKey RistrettoPoint
+	}{{keyshareSecret, serverKeyshare}, {keyshareSecret, serverPublicKey}, {clientSecretKey, serverKeyshare}}
+	for i, pair := range pairs {
+		if dhs[i], err = opaqueDH(pair.secretKey, pair.publicKey); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	preamble := c.config.preamble(credentials, c.ke1, credentialResponse, serverNonce, serverKeyshare)
+	keys, err := deriveOPAQUEKeys(preamble, dhs[:]...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !constantTimeEqual(keys.serverMAC, serverMAC) {
+		return nil, nil, nil, ErrOPAQUEServerAuthentication
+	}
+	return keys.clientMAC, keys, exportKey, nil
+}
+
+// OPAQUEServer answers registration and login requests using its long-term keys
+type OPAQUEServer struct {
+	config    *OPAQUEConfig
+	secretKey RistrettoScalar
+	publicKey RistrettoPoint
+	oprfSeed  []byte
+}
+
+// NewOPAQUEServer returns a server using the secret key and OPRF seed from NewOPAQUEServerKeys
+func NewOPAQUEServer(config *OPAQUEConfig, secretKey RistrettoScalar, oprfSeed []byte) (*OPAQUEServer, error) {
+	if err := checkOPAQUEConfig(config); err != nil {
+		return nil, err
+	}
+	if len(oprfSeed) != OPAQUEOPRFSeedBytes {
+		return nil, ErrBadOPAQUEOPRFSeed
+	}
+	publicKey, err := RistrettoScalarMultBase(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	return &OPAQUEServer{
+		config:    config,
+		secretKey: append(RistrettoScalar{}, secretKey...),
+		publicKey: publicKey,
+		oprfSeed:  append([]byte{}, oprfSeed...),
+	}, nil
+}
+
+// PublicKey returns the server's public key
+func (s *OPAQUEServer) PublicKey() RistrettoPoint {
+	return s.publicKey
+}
+
+// RegistrationResponse answers a registration request for the credential identifier, which must
+// be unique per client and stable across registration and login, e.g. an account ID
+func (s *OPAQUEServer) RegistrationResponse(request, credentialIdentifier []byte) ([]byte, error) {
+	oprf, err := opaqueOPRFServer(s.oprfSeed, credentialIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	evaluated, _, err := oprf.BlindEvaluate(request)
+	if err != nil {
+		return nil, ErrBadOPAQUEMessage
+	}
+	return append(evaluated, s.publicKey...), nil
+}
+
+// OPAQUEServerLogin holds the server's state between sending KE2 and receiving KE3
+type OPAQUEServerLogin struct {
+	expectedClientMAC []byte
+	sessionKey        []byte
+}
+
+// LoginStart answers a client's KE1 message using the stored record, returning the KE2 message to
+// send back and the state needed to check the client's KE3. If no record exists for the client,
+// pass a nil record: a fake response is produced that is indistinguishable from a real one, so
+// that the existence of accounts is not revealed, and the login fails at the client
+func (s *OPAQUEServer) LoginStart(record OPAQUERecord, credentialIdentifier, clientIdentity, ke1 []byte) ([]byte, *OPAQUEServerLogin, error) {
+	maskingNonce, err := opaqueRandom(opaqueNonceBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	serverNonce, err := opaqueRandom(opaqueNonceBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyshareSeed, err := opaqueRandom(opaqueSeedBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.loginStartWith(record, credentialIdentifier, clientIdentity, ke1, maskingNonce, serverNonce, keyshareSeed)
+}
+
+func (s *OPAQUEServer) loginStartWith(record OPAQUERecord, credentialIdentifier, clientIdentity, ke1, maskingNonce, serverNonce, keyshareSeed []byte) ([]byte, *OPAQUEServerLogin, error) {
+	if len(ke1) != opaqueKE1Bytes {
+		return nil, nil, ErrBadOPAQUEMessage
+	}
+	if record == nil {
+		var err error
+		if record, err = s.fakeRecord(); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(record) != opaqueRecordBytes {
+		return nil, nil, ErrBadOPAQUERecord
+	}
+	clientPublicKey := RistrettoPoint(record[:opaqueKeyBytes])
+	maskingKey := record[opaqueKeyBytes : opaqueKeyBytes+opaqueHashBytes]
+	envelope := record[opaqueKeyBytes+opaqueHashBytes:]
+	if err := oprfElement(clientPublicKey); err != nil {
+		return nil, nil, ErrBadOPAQUERecord
+	}
+
+	oprf, err := opaqueOPRFServer(s.oprfSeed, credentialIdentifier)
+	if err != nil {
+		return nil, nil, err
+	}
+	evaluated, _, err := oprf.BlindEvaluate(RistrettoPoint(ke1[:opaqueKeyBytes]))
+	if err != nil {
+		return nil, nil, ErrBadOPAQUEMessage
+	}
+	plain := append(append([]byte{}, s.publicKey...), envelope...)
+	pad, err := hkdfSHA512Expand(maskingKey, append(append([]byte{}, maskingNonce...), "CredentialResponsePad"...), len(plain))
+	if err != nil {
+		return nil, nil, err
+	}
+	credentialResponse := append(append(append([]byte{}, evaluated...), maskingNonce...), xorBytes(plain, pad)...)
+
+	keyshareSecret, keyshare, err := opaqueDeriveKeyPair(keyshareSeed)
+	if err != nil {
+		return nil, nil, err
+	}
+	clientKeyshare := RistrettoPoint(ke1[opaqueKeyBytes+opaqueNonceBytes:])
+	var dhs [3][]byte
+	pairs := [3]struct {
+		secretKey RistrettoScalar
+		publicKey RistrettoPoint
+	}{{keyshareSecret, clientKeyshare}, {s.secretKey, clientKeyshare}, {keyshareSecret, clientPublicKey}}
+	for i, pair := range pairs {
+		if dhs[i], err = opaqueDH(pair.secretKey, pair.publicKey); err != nil {
+			return nil, nil, err
+		}
+	}
+	credentials := newOPAQUECleartextCredentials(s.publicKey, clientPublicKey, s.config.ServerIdentity, clientIdentity)
+	preamble := s.config.preamble(credentials, ke1, credentialResponse, serverNonce, keyshare)
+	keys, err := deriveOPAQUEKeys(preamble, dhs[:]...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ke2 := append(append(append(credentialResponse, serverNonce...), keyshare...), keys.serverMAC...)
+	return ke2, &OPAQUEServerLogin{expectedClientMAC: keys.clientMAC, sessionKey: keys.sessionKey}, nil
+}
+
+// fakeRecord returns a random record for a client that is not registered
+func (s *OPAQUEServer) fakeRecord() (OPAQUERecord, error) {
+	seed, err := opaqueRandom(opaqueSeedBytes)
+	if err != nil {
+		return nil, err
+	}
+	_, clientPublicKey, err := opaqueDeriveKeyPair(seed)
+	if err != nil {
+		return nil, err
+	}
+	maskingKey, err := opaqueRandom(opaqueHashBytes)
+	if err != nil {
+		return nil, err
+	}
+	record := append(append(OPAQUERecord{}, clientPublicKey...), maskingKey...)
+	return append(record, make([]byte, opaqueEnvelope)...), nil
+}
+
+// Finish checks the client's KE3 message and returns the session key, which equals the one the
+// client obtained from LoginFinish
+func (l *OPAQUEServerLogin) Finish(ke3 []byte) (SecretKey, error) {
+	if l.sessionKey == nil {
+		return nil, ErrOPAQUEOutOfOrder
+	}
+	sessionKey := l.sessionKey
+	l.sessionKey = nil
+	if !constantTimeEqual(ke3, l.expectedClientMAC) {
+		return nil, ErrOPAQUEClientAuthentication
+	}
+	return opaqueSessionSecretKey(sessionKey)
+}
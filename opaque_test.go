@@ -0,0 +1,308 @@
+package bcl
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha512"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testOPAQUEConfig() *OPAQUEConfig {
+	return &OPAQUEConfig{
+		Context:  []byte("bcl OPAQUE test"),
+		OpsLimit: CryptoPwHashOpsLimitMin,
+		MemLimit: CryptoPwHashMemLimitMin,
+	}
+}
+
+func testOPAQUEServer(t *testing.T, config *OPAQUEConfig) *OPAQUEServer {
+	secretKey, oprfSeed, err := NewOPAQUEServerKeys()
+	assert.NoError(t, err)
+	server, err := NewOPAQUEServer(config, secretKey, oprfSeed)
+	assert.NoError(t, err)
+	return server
+}
+
+func opaqueRegister(t *testing.T, config *OPAQUEConfig, server *OPAQUEServer, password, credentialIdentifier, clientIdentity []byte) (OPAQUERecord, []byte) {
+	client, err := NewOPAQUEClient(config, password, clientIdentity)
+	assert.NoError(t, err)
+	request, err := client.RegistrationRequest()
+	assert.NoError(t, err)
+	response, err := server.RegistrationResponse(request, credentialIdentifier)
+	assert.NoError(t, err)
+	record, exportKey, err := client.FinalizeRegistration(response)
+	assert.NoError(t, err)
+	return record, exportKey
+}
+
+func TestOPAQUE(t *testing.T) {
+	tests := []struct {
+		name           string
+		serverIdentity []byte
+		clientIdentity []byte
+	}{
+		{name: "TestOPAQUE success default identities"},
+		{name: "TestOPAQUE success explicit identities", serverIdentity: []byte("auth.example.com"), clientIdentity: []byte("alice@example.com")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testOPAQUEConfig()
+			config.ServerIdentity = tt.serverIdentity
+			server := testOPAQUEServer(t, config)
+			password, credentialIdentifier := []byte("correct horse battery staple"), []byte("user-1")
+			record, registrationExportKey := opaqueRegister(t, config, server, password, credentialIdentifier, tt.clientIdentity)
+			assert.Len(t, record, opaqueRecordBytes)
+
+			client, err := NewOPAQUEClient(config, password, tt.clientIdentity)
+			assert.NoError(t, err)
+			ke1, err := client.LoginStart()
+			assert.NoError(t, err)
+			assert.Len(t, ke1, opaqueKE1Bytes)
+			ke2, login, err := server.LoginStart(record, credentialIdentifier, tt.clientIdentity, ke1)
+			assert.NoError(t, err)
+			assert.Len(t, ke2, opaqueKE2Bytes)
+			ke3, clientSessionKey, exportKey, err := client.LoginFinish(ke2)
+			assert.NoError(t, err)
+			serverSessionKey, err := login.Finish(ke3)
+			assert.NoError(t, err)
+
+			assert.True(t, clientSessionKey.Equal(serverSessionKey))
+			assert.Equal(t, registrationExportKey, exportKey)
+
+			// the session key works with the symmetric API
+			c, err := SymmetricEncrypt(clientSessionKey, Plaintext("hello"), nil)
+			assert.NoError(t, err)
+			m, err := SymmetricDecrypt(serverSessionKey, c)
+			assert.NoError(t, err)
+			assert.Equal(t, Plaintext("hello"), m)
+
+			// the state machines cannot be replayed
+			_, _, _, err = client.LoginFinish(ke2)
+			assert.EqualError(t, err, ErrOPAQUEOutOfOrder.Error())
+			_, err = login.Finish(ke3)
+			assert.EqualError(t, err, ErrOPAQUEOutOfOrder.Error())
+			_, err = client.LoginStart()
+			assert.EqualError(t, err, ErrOPAQUEOutOfOrder.Error())
+		})
+	}
+}
+
+func opaqueLogin(t *testing.T, config *OPAQUEConfig, server *OPAQUEServer, record OPAQUERecord, password, credentialIdentifier []byte, tamper func(ke2 []byte)) error {
+	client, err := NewOPAQUEClient(config, password, nil)
+	assert.NoError(t, err)
+	ke1, err := client.LoginStart()
+	assert.NoError(t, err)
+	ke2, _, err := server.LoginStart(record, credentialIdentifier, nil, ke1)
+	if err != nil {
+		return err
+	}
+	if tamper != nil {
+		tamper(ke2)
+	}
+	_, _, _, err = client.LoginFinish(ke2)
+	return err
+}
+
+func TestOPAQUEFail(t *testing.T) {
+	config := testOPAQUEConfig()
+	server := testOPAQUEServer(t, config)
+	password, credentialIdentifier := []byte("password"), []byte("user-1")
+	record, _ := opaqueRegister(t, config, server, password, credentialIdentifier, nil)
+
+	otherConfig := testOPAQUEConfig()
+	otherConfig.Context = []byte("another application")
+	tests := []struct {
+		name  string
+		login func() error
+		err   error
+	}{
+		{name: "TestOPAQUEFail wrong password", login: func() error {
+			return opaqueLogin(t, config, server, record, []byte("wrong password"), credentialIdentifier, nil)
+		}, err: ErrOPAQUEEnvelopeRecovery},
+		{name: "TestOPAQUEFail wrong credential identifier", login: func() error {
+			return opaqueLogin(t, config, server, record, password, []byte("user-2"), nil)
+		}, err: ErrOPAQUEEnvelopeRecovery},
+		{name: "TestOPAQUEFail unknown client", login: func() error {
+			return opaqueLogin(t, config, server, nil, password, credentialIdentifier, nil)
+		}, err: ErrOPAQUEEnvelopeRecovery},
+		{name: "TestOPAQUEFail wrong server", login: func() error {
+			return opaqueLogin(t, config, testOPAQUEServer(t, config), record, password, credentialIdentifier, nil)
+		}, err: ErrOPAQUEEnvelopeRecovery},
+		{name: "TestOPAQUEFail context mismatch", login: func() error {
+			client, err := NewOPAQUEClient(otherConfig, password, nil)
+			assert.NoError(t, err)
+			ke1, err := client.LoginStart()
+			assert.NoError(t, err)
+			ke2, _, err := server.LoginStart(record, credentialIdentifier, nil, ke1)
+			assert.NoError(t, err)
+			_, _, _, err = client.LoginFinish(ke2)
+			return err
+		}, err: ErrOPAQUEServerAuthentication},
+		{name: "TestOPAQUEFail tampered server MAC", login: func() error {
+			return opaqueLogin(t, config, server, record, password, credentialIdentifier, func(ke2 []byte) { ke2[len(ke2)-1] ^= 0x01 })
+		}, err: ErrOPAQUEServerAuthentication},
+		{name: "TestOPAQUEFail tampered server nonce", login: func() error {
+			return opaqueLogin(t, config, server, record, password, credentialIdentifier, func(ke2 []byte) { ke2[opaqueCredential] ^= 0x01 })
+		}, err: ErrOPAQUEServerAuthentication},
+		{name: "TestOPAQUEFail tampered masked response", login: func() error {
+			return opaqueLogin(t, config, server, record, password, credentialIdentifier, func(ke2 []byte) { ke2[opaqueCredential-1] ^= 0x01 })
+		}, err: ErrOPAQUEEnvelopeRecovery},
+		{name: "TestOPAQUEFail bad record", login: func() error {
+			return opaqueLogin(t, config, server, record[1:], password, credentialIdentifier, nil)
+		}, err: ErrBadOPAQUERecord},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, tt.login(), tt.err.Error())
+		})
+	}
+}
+
+func TestOPAQUEClientAuthentication(t *testing.T) {
+	config := testOPAQUEConfig()
+	server := testOPAQUEServer(t, config)
+	record, _ := opaqueRegister(t, config, server, []byte("password"), []byte("user-1"), nil)
+
+	client, err := NewOPAQUEClient(config, []byte("password"), nil)
+	assert.NoError(t, err)
+	ke1, err := client.LoginStart()
+	assert.NoError(t, err)
+	ke2, login, err := server.LoginStart(record, []byte("user-1"), nil, ke1)
+	assert.NoError(t, err)
+	ke3, _, _, err := client.LoginFinish(ke2)
+	assert.NoError(t, err)
+	ke3[0] ^= 0x01
+	_, err = login.Finish(ke3)
+	assert.EqualError(t, err, ErrOPAQUEClientAuthentication.Error())
+
+	_, _, err = server.LoginStart(record, []byte("user-1"), nil, ke1[1:])
+	assert.EqualError(t, err, ErrBadOPAQUEMessage.Error())
+	_, err = server.RegistrationResponse(make([]byte, opaqueKeyBytes), []byte("user-1"))
+	assert.EqualError(t, err, ErrBadOPAQUEMessage.Error())
+	_, err = NewOPAQUEServer(config, newTestRistrettoScalar(t), make([]byte, 10))
+	assert.EqualError(t, err, ErrBadOPAQUEOPRFSeed.Error())
+	_, err = NewOPAQUEServer(nil, newTestRistrettoScalar(t), make([]byte, OPAQUEOPRFSeedBytes))
+	assert.EqualError(t, err, ErrBadOPAQUEConfig.Error())
+	_, err = NewOPAQUEClient(nil, []byte("password"), nil)
+	assert.EqualError(t, err, ErrBadOPAQUEConfig.Error())
+	_, err = NewOPAQUEClient(&OPAQUEConfig{MemLimit: CryptoPwHashMemLimitMin}, []byte("password"), nil)
+	assert.EqualError(t, err, ErrBadOPAQUEConfig.Error())
+	_, err = NewOPAQUEServer(&OPAQUEConfig{OpsLimit: CryptoPwHashOpsLimitMin}, newTestRistrettoScalar(t), make([]byte, OPAQUEOPRFSeedBytes))
+	assert.EqualError(t, err, ErrBadOPAQUEConfig.Error())
+
+	client, err = NewOPAQUEClient(config, []byte("password"), nil)
+	assert.NoError(t, err)
+	_, _, err = client.FinalizeRegistration(make([]byte, 2*opaqueKeyBytes))
+	assert.EqualError(t, err, ErrOPAQUEOutOfOrder.Error())
+	_, _, _, err = client.LoginFinish(ke2)
+	assert.EqualError(t, err, ErrOPAQUEOutOfOrder.Error())
+}
+
+// RFC 9807, appendix C.1.1: OPAQUE-3DH Real Test Vector 1, ristretto255-SHA512 with the identity
+// KSF and no identities
+func TestOPAQUEVectors(t *testing.T) {
+	config := &OPAQUEConfig{
+		Context: mustDecodeHex("4f50415155452d504f43"),
+		ksf:     func(b []byte) ([]byte, error) { return b, nil },
+	}
+	oprfSeed := mustDecodeHex("f433d0227b0b9dd54f7c4422b600e764e47fb503f1f9a0f0a47c6606b054a7fd" +
+		"c65347f1a08f277e22358bbabe26f823fca82c7848e9a75661f4ec5d5c1989ef")
+	credentialIdentifier := mustDecodeHex("31323334")
+	password := mustDecodeHex("436f7272656374486f72736542617474657279537461706c65")
+	serverSecretKey := RistrettoScalar(mustDecodeHex("47451a85372f8b3537e249d7b54188091fb18edde78094b43e2ba42b5eb89f0d"))
+	envelopeNonce := mustDecodeHex("ac13171b2f17bc2c74997f0fce1e1f35bec6b91fe2e12dbd323d23ba7a38dfec")
+	maskingNonce := mustDecodeHex("38fe59af0df2c79f57b8780278f5ae47355fe1f817119041951c80f612fdfc6d")
+	serverNonce := mustDecodeHex("71cd9960ecef2fe0d0f7494986fa3d8b2bb01963537e60efb13981e138e3d4a1")
+	clientNonce := mustDecodeHex("da7e07376d6d6f034cfa9bb537d11b8c6b4238c334333d1f0aebb380cae6a6cc")
+	clientKeyshareSeed := mustDecodeHex("82850a697b42a505f5b68fcdafce8c31f0af2b581f063cf1091933541936304b")
+	serverKeyshareSeed := mustDecodeHex("05a4f54206eef1ba2f615bc0aa285cb22f26d1153b5b40a1e85ff80da12f982f")
+	blindRegistration := RistrettoScalar(mustDecodeHex("76cfbfe758db884bebb33582331ba9f159720ca8784a2a070a265d9c2d6abe01"))
+	blindLogin := RistrettoScalar(mustDecodeHex("6ecc102d2e7a7cf49617aad7bbe188556792d4acd60a1a8a8d2b65d4b0790308"))
+
+	server, err := NewOPAQUEServer(config, serverSecretKey, oprfSeed)
+	assert.NoError(t, err)
+	assert.Equal(t, mustDecodeHex("b2fe7af9f48cc502d016729d2fe25cdd433f2c4bc904660b2a382c9b79df1a78"), []byte(server.PublicKey()))
+
+	client, err := NewOPAQUEClient(config, password, nil)
+	assert.NoError(t, err)
+	request, err := client.registrationRequestWith(blindRegistration)
+	assert.NoError(t, err)
+	assert.Equal(t, mustDecodeHex("5059ff249eb1551b7ce4991f3336205bde44a105a032e747d21bf382e75f7a71"), request)
+	response, err := server.RegistrationResponse(request, credentialIdentifier)
+	assert.NoError(t, err)
+	assert.Equal(t, mustDecodeHex("7408a268083e03abc7097fc05b587834539065e86fb0c7b6342fcf5e01e5b019"+
+		"b2fe7af9f48cc502d016729d2fe25cdd433f2c4bc904660b2a382c9b79df1a78"), response)
+	record, exportKey, err := client.finalizeRegistrationWith(response, envelopeNonce)
+	assert.NoError(t, err)
+	assert.Equal(t, mustDecodeHex("76a845464c68a5d2f7e442436bb1424953b17d3e2e289ccbaccafb57ac5c3675"+
+		"1ac5844383c7708077dea41cbefe2fa15724f449e535dd7dd562e66f5ecfb95864eadddec9db5874959905117dad40a4"+
+		"524111849799281fefe3c51fa82785c5ac13171b2f17bc2c74997f0fce1e1f35bec6b91fe2e12dbd323d23ba7a38dfec"+
+		"634b0f5b96109c198a8027da51854c35bee90d1e1c781806d07d49b76de6a28b8d9e9b6c93b9f8b64d16dddd9c5bfb5f"+
+		"ea48ee8fd2f75012a8b308605cdd8ba5"), []byte(record))
+	expectedExportKey := mustDecodeHex("1ef15b4fa99e8a852412450ab78713aad30d21fa6966c9b8c9fb3262a970dc62" +
+		"950d4dd4ed62598229b1b72794fc0335199d9f7fcc6eaedde92cc04870e63f16")
+	assert.Equal(t, expectedExportKey, exportKey)
+
+	client, err = NewOPAQUEClient(config, password, nil)
+	assert.NoError(t, err)
+	ke1, err := client.loginStartWith(blindLogin, clientNonce, clientKeyshareSeed)
+	assert.NoError(t, err)
+	assert.Equal(t, mustDecodeHex("c4dedb0ba6ed5d965d6f250fbe554cd45cba5dfcce3ce836e4aee778aa3cd44d"+
+		"da7e07376d6d6f034cfa9bb537d11b8c6b4238c334333d1f0aebb380cae6a6cc"+
+		"6e29bee50701498605b2c085d7b241ca15ba5c32027dd21ba420b94ce60da326"), ke1)
+	ke2, login, err := server.loginStartWith(record, credentialIdentifier, nil, ke1, maskingNonce, serverNonce, serverKeyshareSeed)
+	assert.NoError(t, err)
+	assert.Equal(t, mustDecodeHex("7e308140890bcde30cbcea28b01ea1ecfbd077cff62c4def8efa075aabcbb471"+
+		"38fe59af0df2c79f57b8780278f5ae47355fe1f817119041951c80f612fdfc6d"+
+		"d6ec60bcdb26dc455ddf3e718f1020490c192d70dfc7e403981179d8073d1146a4f9aa1ced4e4cd984c657eb3b54ced3"+
+		"848326f70331953d91b02535af44d9fedc80188ca46743c52786e0382f95ad85c08f6afcd1ccfbff95e2bdeb015b166c"+
+		"6b20b92f832cc6df01e0b86a7efd92c1c804ff865781fa93f2f20b446c8371b6"+
+		"71cd9960ecef2fe0d0f7494986fa3d8b2bb01963537e60efb13981e138e3d4a1"+
+		"c4f62198a9d6fa9170c42c3c71f1971b29eb1d5d0bd733e40816c91f7912cc4a"+
+		"660c48dae03e57aaa38f3d0cffcfc21852ebc8b405d15bd6744945ba1a93438a162b6111699d98a16bb55b7bdddfe0fc"+
+		"5608b23da246e7bd73b47369169c5c90"), ke2)
+	ke3, keys, loginExportKey, err := client.loginFinish(ke2)
+	assert.NoError(t, err)
+	assert.Equal(t, mustDecodeHex("4455df4f810ac31a6748835888564b536e6da5d9944dfea9e34defb9575fe5e2"+
+		"661ef61d2ae3929bcf57e53d464113d364365eb7d1a57b629707ca48da18e442"), ke3)
+	expectedSessionKey := mustDecodeHex("42afde6f5aca0cfa5c163763fbad55e73a41db6b41bc87b8e7b62214a8eedc67" +
+		"31fa3cb857d657ab9b3764b89a84e91ebcb4785166fbb02cedfcbdfda215b96f")
+	assert.Equal(t, expectedSessionKey, keys.sessionKey)
+	assert.Equal(t, expectedExportKey, loginExportKey)
+	assert.Equal(t, expectedSessionKey, login.sessionKey)
+
+	serverSessionKey, err := login.Finish(ke3)
+	assert.NoError(t, err)
+	expectedSecretKey, err := opaqueSessionSecretKey(expectedSessionKey)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSecretKey, serverSessionKey)
+}
+
+func newTestRistrettoScalar(t *testing.T) RistrettoScalar {
+	s, err := NewRistrettoScalar()
+	assert.NoError(t, err)
+	return s
+}
+
+func TestHKDFSHA512(t *testing.T) {
+	ikm, salt, info := []byte("input key material"), []byte("salt"), []byte("info")
+	prk, err := hkdfSHA512Extract(salt, ikm)
+	assert.NoError(t, err)
+	expectedPRK, err := hkdf.Extract(sha512.New, ikm, salt)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedPRK, prk)
+
+	out, err := hkdfSHA512Expand(prk, info, 100)
+	assert.NoError(t, err)
+	expected, err := hkdf.Expand(sha512.New, expectedPRK, string(info), 100)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, out)
+
+	mac, err := hmacSHA512(salt, ikm, info)
+	assert.NoError(t, err)
+	h := hmac.New(sha512.New, salt)
+	h.Write(append(append([]byte{}, ikm...), info...))
+	assert.Equal(t, h.Sum(nil), mac)
+}
@@ -0,0 +1,429 @@
+package bcl
+
+import "io"
+
+// This file implements the OPRF and VOPRF modes of RFC 9497 with the ristretto255-SHA512 cipher
+// suite. A client blinds its input, the server evaluates the blinded element with its secret key
+// without learning the input, and the client unblinds the result and hashes it into the output. In
+// the verifiable mode the server also proves that it used the key matching its public key, so
+// that it cannot tag clients by evaluating with a different key
+
+// OPRFMode selects between the base and verifiable modes of the OPRF protocol
+type OPRFMode byte
+
+const (
+	OPRFModeBase       OPRFMode = 0x00
+	OPRFModeVerifiable OPRFMode = 0x01
+)
+
+// OPRFOutputBytes is the length of an OPRF output, that of a SHA-512 hash
+const OPRFOutputBytes = 64
+
+const oprfSuite = "ristretto255-SHA512"
+
+// OPRFProof is the serialized (c, s) pair of a discrete log equivalence proof
+type OPRFProof []byte
+
+func (m OPRFMode) contextString() ([]byte, error) {
+	if m != OPRFModeBase && m != OPRFModeVerifiable {
+		return nil, ErrBadOPRFMode
+	}
+	return append([]byte{'O', 'P', 'R', 'F', 'V', '1', '-', byte(m), '-'}, oprfSuite...), nil
+}
+
+// lengthPrefixed concatenates its inputs, each preceded by its length as a two byte big-endian
+// integer, as done by every transcript in RFC 9497
+func lengthPrefixed(inputs ...[]byte) []byte {
+	var out []byte
+	for _, in := range inputs {
+		out = append(out, byte(len(in)>>8), byte(len(in)))
+		out = append(out, in...)
+	}
+	return out
+}
+
+// oprfElement checks that a point received from the other party is a valid non-identity element,
+// as required of DeserializeElement
+func oprfElement(p RistrettoPoint) error {
+	if _, err := RistrettoPointFromBytes(p); err != nil {
+		return err
+	}
+	if p.IsIdentity() {
+		return ErrBadRistrettoPoint
+	}
+	return nil
+}
+
+// NewOPRFKeyPair returns a random (secret key, public key) pair for an OPRF server
+func NewOPRFKeyPair() (RistrettoScalar, RistrettoPoint, error) {
+	secretKey, err := NewRistrettoScalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	publicKey, err := RistrettoScalarMultBase(secretKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return secretKey, publicKey, nil
+}
+
+// DeriveOPRFKeyPair deterministically derives a (secret key, public key) pair for an OPRF server
+// from a seed of at least 32 bytes and a public info string
+func DeriveOPRFKeyPair(mode OPRFMode, seed, info []byte) (RistrettoScalar, RistrettoPoint, error) {
+	contextString, err := mode.contextString()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(seed) < 32 {
+		return nil, nil, ErrBadSeedLength
+	}
+	deriveInput := append(append([]byte{}, seed...), lengthPrefixed(info)...)
+	dst := append([]byte("DeriveKeyPair"), contextString...)
+	for counter := 0; counter <= 255; counter++ {
+		secretKey, err := HashToRistrettoScalar(append(deriveInput, byte(counter)), dst)
+		if err != nil {
+			return nil, nil, err
+		}
+		if secretKey.IsZero() {
+			continue
+		}
+		publicKey, err := RistrettoScalarMultBase(secretKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		return secretKey, publicKey, nil
+	}
+	return nil, nil, ErrOPRFDeriveKeyPair
+}
+
+// OPRFClient blinds inputs and finalizes the server's evaluations into OPRF outputs
+type OPRFClient struct {
+	mode          OPRFMode
+	contextString []byte
+	publicKey     RistrettoPoint
+}
+
+// NewOPRFClient returns an OPRF client. In the verifiable mode the server's public key is
+// required to check its proofs; in the base mode it is ignored and may be nil
+func NewOPRFClient(mode OPRFMode, publicKey RistrettoPoint) (*OPRFClient, error) {
+	contextString, err := mode.contextString()
+	if err != nil {
+		return nil, err
+	}
+	c := &OPRFClient{mode: mode, contextString: contextString}
+	if mode == OPRFModeVerifiable {
+		if err := oprfElement(publicKey); err != nil {
+			return nil, err
+		}
+		c.publicKey = publicKey
+	}
+	return c, nil
+}
+
+// Blind hashes an input to the group and blinds it with a fresh random scalar, returning the
+// blind, which must be kept for Finalize, and the blinded element to send to the server
+func (c *OPRFClient) Blind(input []byte) (RistrettoScalar, RistrettoPoint, error) {
+	return c.BlindFromReader(nil, input)
+}
+
+// BlindFromReader is like Blind but reads the blind's randomness from r, or from the package-level
+// random source if r is nil
+func (c *OPRFClient) BlindFromReader(r io.Reader, input []byte) (RistrettoScalar, RistrettoPoint, error) {
+	blind, err := NewRistrettoScalarFromReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	blinded, err := c.blindWith(input, blind)
+	if err != nil {
+		return nil, nil, err
+	}
+	return blind, blinded, nil
+}
+
+func (c *OPRFClient) blindWith(input []byte, blind RistrettoScalar) (RistrettoPoint, error) {
+	inputElement, err := HashToRistrettoPoint(input, append([]byte("HashToGroup-"), c.contextString...))
+	if err != nil {
+		return nil, err
+	}
+	if inputElement.IsIdentity() {
+		return nil, ErrOPRFInvalidInput
+	}
+	return inputElement.ScalarMult(blind)
+}
+
+// Finalize unblinds the server's evaluation of a single input and returns the OPRF output. In the
+// verifiable mode the blinded element and proof are checked against the server's public key,
+// otherwise they are ignored and may be nil
+func (c *OPRFClient) Finalize(input []byte, blind RistrettoScalar, blinded, evaluated RistrettoPoint, proof OPRFProof) ([]byte, error) {
+	outputs, err := c.FinalizeBatch([][]byte{input}, []RistrettoScalar{blind}, []RistrettoPoint{blinded}, []RistrettoPoint{evaluated}, proof)
+	if err != nil {
+		return nil, err
+	}
+	return outputs[0], nil
+}
+
+// FinalizeBatch unblinds the server's evaluations of several inputs, whose correctness in the
+// verifiable mode is covered by a single batched proof
+func (c *OPRFClient) FinalizeBatch(inputs [][]byte, blinds []RistrettoScalar, blinded, evaluated []RistrettoPoint, proof OPRFProof) ([][]byte, error) {
+	n := len(inputs)
+	if n == 0 || len(blinds) != n || len(evaluated) != n || (c.mode == OPRFModeVerifiable && len(blinded) != n) {
+		return nil, ErrBadOPRFBatch
+	}
+	for _, e := range evaluated {
+		if err := oprfElement(e); err != nil {
+			return nil, err
+		}
+	}
+	if c.mode == OPRFModeVerifiable {
+		for _, b := range blinded {
+			if err := oprfElement(b); err != nil {
+				return nil, err
+			}
+		}
+		if err := verifyOPRFProof(c.contextString, c.publicKey, blinded, evaluated, proof); err != nil {
+			return nil, err
+		}
+	}
+
+	outputs := make([][]byte, n)
+	for i := range inputs {
+		blindInv, err := blinds[i].Invert()
+		if err != nil {
+			return nil, err
+		}
+		unblinded, err := evaluated[i].ScalarMult(blindInv)
+		if err != nil {
+			return nil, err
+		}
+		outputs[i] = oprfOutput(inputs[i], unblinded)
+	}
+	return outputs, nil
+}
+
+func oprfOutput(input []byte, element RistrettoPoint) []byte {
+	return sha512Sum(lengthPrefixed(input, element), []byte("Finalize"))
+}
+
+// OPRFServer evaluates blinded elements with its secret key
+type OPRFServer struct {
+	mode          OPRFMode
+	contextString []byte
+	secretKey     RistrettoScalar
+	publicKey     RistrettoPoint
+}
+
+// NewOPRFServer returns an OPRF server using the supplied secret key
+func NewOPRFServer(mode OPRFMode, secretKey RistrettoScalar) (*OPRFServer, error) {
+	contextString, err := mode.contextString()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := RistrettoScalarFromBytes(secretKey); err != nil {
+		return nil, err
+	}
+	publicKey, err := RistrettoScalarMultBase(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	return &OPRFServer{
+		mode:          mode,
+		contextString: contextString,
+		secretKey:     append(RistrettoScalar{}, secretKey...),
+		publicKey:     publicKey,
+	}, nil
+}
+
+// PublicKey returns the server's public key, which clients need in the verifiable mode
+func (s *OPRFServer) PublicKey() RistrettoPoint {
+	return s.publicKey
+}
+
+// BlindEvaluate evaluates a single blinded element. In the verifiable mode it also returns a proof
+// of correct evaluation; in the base mode the proof is nil
+func (s *OPRFServer) BlindEvaluate(blinded RistrettoPoint) (RistrettoPoint, OPRFProof, error) {
+	evaluated, proof, err := s.BlindEvaluateBatch([]RistrettoPoint{blinded})
+	if err != nil {
+		return nil, nil, err
+	}
+	return evaluated[0], proof, nil
+}
+
+// BlindEvaluateBatch evaluates several blinded elements, producing a single proof for all of them
+// in the verifiable mode
+func (s *OPRFServer) BlindEvaluateBatch(blinded []RistrettoPoint) ([]RistrettoPoint, OPRFProof, error) {
+	return s.blindEvaluateBatchFromReader(nil, blinded)
+}
+
+func (s *OPRFServer) blindEvaluateBatchFromReader(r io.Reader, blinded []RistrettoPoint) ([]RistrettoPoint, OPRFProof, error) {
+	if len(blinded) == 0 {
+		return nil, nil, ErrBadOPRFBatch
+	}
+	evaluated := make([]RistrettoPoint, len(blinded))
+	for i, b := range blinded {
+		if err := oprfElement(b); err != nil {
+			return nil, nil, err
+		}
+		e, err := b.ScalarMult(s.secretKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		evaluated[i] = e
+	}
+	if s.mode != OPRFModeVerifiable {
+		return evaluated, nil, nil
+	}
+	proofRandom, err := NewRistrettoScalarFromReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof, err := generateOPRFProof(s.contextString, s.secretKey, s.publicKey, blinded, evaluated, proofRandom)
+	if err != nil {
+		return nil, nil, err
+	}
+	return evaluated, proof, nil
+}
+
+// Evaluate computes the OPRF output for an input directly with the secret key, which gives the
+// same result as a client running Blind, BlindEvaluate and Finalize
+func (s *OPRFServer) Evaluate(input []byte) ([]byte, error) {
+	inputElement, err := HashToRistrettoPoint(input, append([]byte("HashToGroup-"), s.contextString...))
+	if err != nil {
+		return nil, err
+	}
+	if inputElement.IsIdentity() {
+		return nil, ErrOPRFInvalidInput
+	}
+	evaluated, err := inputElement.ScalarMult(s.secretKey)
+	if err != nil {
+		return nil, err
+	}
+	return oprfOutput(input, evaluated), nil
+}
+
+// computeComposites combines the (blinded, evaluated) pairs into a single pair with random
+// weights derived from the transcript, so that one proof covers the whole batch. If secretKey is
+// set, the evaluated composite is computed from the blinded one, as only the server can do
+func computeComposites(contextString []byte, secretKey RistrettoScalar, publicKey RistrettoPoint, c, d []RistrettoPoint) (RistrettoPoint, RistrettoPoint, error) {
+	seed := sha512Sum(lengthPrefixed(publicKey, append([]byte("Seed-"), contextString...)))
+	dst := append([]byte("HashToScalar-"), contextString...)
+	m := make(RistrettoPoint, CryptoCoreRistretto255Bytes)
+	z := make(RistrettoPoint, CryptoCoreRistretto255Bytes)
+	for i := range c {
+		transcript := append(lengthPrefixed(seed), byte(i>>8), byte(i))
+		transcript = append(transcript, lengthPrefixed(c[i], d[i])...)
+		di, err := HashToRistrettoScalar(append(transcript, "Composite"...), dst)
+		if err != nil {
+			return nil, nil, err
+		}
+		if m, err = addScaled(m, c[i], di); err != nil {
+			return nil, nil, err
+		}
+		if secretKey == nil {
+			if z, err = addScaled(z, d[i], di); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if secretKey != nil {
+		var err error
+		if z, err = m.ScalarMult(secretKey); err != nil {
+			return nil, nil, err
+		}
+	}
+	return m, z, nil
+}
+
+// addScaled returns acc + s*p
+func addScaled(acc, p RistrettoPoint, s RistrettoScalar) (RistrettoPoint, error) {
+	sp, err := p.ScalarMult(s)
+	if err != nil {
+		return nil, err
+	}
+	return acc.Add(sp)
+}
+
+func oprfChallenge(contextString []byte, publicKey, m, z, t2, t3 RistrettoPoint) (RistrettoScalar, error) {
+	transcript := append(lengthPrefixed(publicKey, m, z, t2, t3), "Challenge"...)
+	return HashToRistrettoScalar(transcript, append([]byte("HashToScalar-"), contextString...))
+}
+
+// generateOPRFProof proves that log_G(publicKey) equals log_c[i](d[i]) for every i, using the
+// random scalar r as the commitment nonce
+func generateOPRFProof(contextString []byte, secretKey RistrettoScalar, publicKey RistrettoPoint, c, d []RistrettoPoint, r RistrettoScalar) (OPRFProof, error) {
+	m, z, err := computeComposites(contextString, secretKey, publicKey, c, d)
+	if err != nil {
+		return nil, err
+	}
+	t2, err := RistrettoScalarMultBase(r)
+	if err != nil {
+		return nil, err
+	}
+	t3, err := m.ScalarMult(r)
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := oprfChallenge(contextString, publicKey, m, z, t2, t3)
+	if err != nil {
+		return nil, err
+	}
+	ck, err := challenge.Mul(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	response, err := r.Sub(ck)
+	if err != nil {
+		return nil, err
+	}
+	return OPRFProof(append(challenge, response...)), nil
+}
+
+// verifyOPRFProof checks a proof produced by generateOPRFProof
+func verifyOPRFProof(contextString []byte, publicKey RistrettoPoint, c, d []RistrettoPoint, proof OPRFProof) error {
+	if len(proof) != 2*CryptoCoreRistretto255ScalarBytes {
+		return ErrBadOPRFProof
+	}
+	challenge, err := RistrettoScalarFromBytes(proof[:CryptoCoreRistretto255ScalarBytes])
+	if err != nil {
+		return ErrBadOPRFProof
+	}
+	response, err := RistrettoScalarFromBytes(proof[CryptoCoreRistretto255ScalarBytes:])
+	if err != nil {
+		return ErrBadOPRFProof
+	}
+
+	m, z, err := computeComposites(contextString, nil, publicKey, c, d)
+	if err != nil {
+		return err
+	}
+	// t2 = s*G + c*publicKey and t3 = s*M + c*Z; the multiplications by s are done as additions
+	// of scaled points so that a zero response or challenge does not produce an error
+	sG := make(RistrettoPoint, CryptoCoreRistretto255Bytes)
+	sM := make(RistrettoPoint, CryptoCoreRistretto255Bytes)
+	if !response.IsZero() {
+		if sG, err = RistrettoScalarMultBase(response); err != nil {
+			return err
+		}
+		if sM, err = m.ScalarMult(response); err != nil {
+			return ErrOPRFVerifyFailed
+		}
+	}
+	var t2, t3 RistrettoPoint
+	if challenge.IsZero() {
+		t2, t3 = sG, sM
+	} else {
+		if t2, err = addScaled(sG, publicKey, challenge); err != nil {
+			return ErrOPRFVerifyFailed
+		}
+		if t3, err = addScaled(sM, z, challenge); err != nil {
+			return ErrOPRFVerifyFailed
+		}
+	}
+	expected, err := oprfChallenge(contextString, publicKey, m, z, t2, t3)
+	if err != nil {
+		return err
+	}
+	if !expected.Equal(challenge) {
+		return ErrOPRFVerifyFailed
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package bcl
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 9497, appendix A.1 (ristretto255-SHA512)
+var (
+	oprfSeed    = mustDecodeHex("a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3a3")
+	oprfKeyInfo = mustDecodeHex("74657374206b6579")
+	oprfBlind   = RistrettoScalar(mustDecodeHex("64d37aed22a27f5191de1c1d69fadb899d8862b58eb4220029e036ec4c1f6706"))
+	oprfInputs  = [][]byte{mustDecodeHex("00"), mustDecodeHex("5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a5a")}
+)
+
+type oprfVector struct {
+	input     []byte
+	blinded   string
+	evaluated string
+	output    string
+	proof     string
+}
+
+func TestDeriveOPRFKeyPair(t *testing.T) {
+	secretKey, _, err := DeriveOPRFKeyPair(OPRFModeBase, oprfSeed, oprfKeyInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, "5ebcea5ee37023ccb9fc2d2019f9d7737be85591ae8652ffa9ef0f4d37063b0e", hex.EncodeToString(secretKey))
+
+	secretKey, publicKey, err := DeriveOPRFKeyPair(OPRFModeVerifiable, oprfSeed, oprfKeyInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, "e6f73f344b79b379f1a0dd37e07ff62e38d9f71345ce62ae3a9bc60b04ccd909", hex.EncodeToString(secretKey))
+	assert.Equal(t, "c803e2cc6b05fc15064549b5920659ca4a77b2cca6f04f6b357009335476ad4e", hex.EncodeToString(publicKey))
+
+	_, _, err = DeriveOPRFKeyPair(OPRFModeBase, oprfSeed[:31], oprfKeyInfo)
+	assert.EqualError(t, err, ErrBadSeedLength.Error())
+	_, _, err = DeriveOPRFKeyPair(OPRFMode(0x07), oprfSeed, oprfKeyInfo)
+	assert.EqualError(t, err, ErrBadOPRFMode.Error())
+}
+
+func TestOPRFBaseVectors(t *testing.T) {
+	vectors := []oprfVector{
+		{
+			input:     oprfInputs[0],
+			blinded:   "609a0ae68c15a3cf6903766461307e5c8bb2f95e7e6550e1ffa2dc99e412803c",
+			evaluated: "7ec6578ae5120958eb2db1745758ff379e77cb64fe77b0b2d8cc917ea0869c7e",
+			output:    "527759c3d9366f277d8c6020418d96bb393ba2afb20ff90df23fb7708264e2f3ab9135e3bd69955851de4b1f9fe8a0973396719b7912ba9ee8aa7d0b5e24bcf6",
+		},
+		{
+			input:     oprfInputs[1],
+			blinded:   "da27ef466870f5f15296299850aa088629945a17d1f5b7f5ff043f76b3c06418",
+			evaluated: "b4cbf5a4f1eeda5a63ce7b77c7d23f461db3fcab0dd28e4e17cecb5c90d02c25",
+			output:    "f4a74c9c592497375e796aa837e907b1a045d34306a749db9f34221f7e750cb4f2a6413a6bf6fa5e19ba6348eb673934a722a7ede2e7621306d18951e7cf2c73",
+		},
+	}
+	secretKey, _, err := DeriveOPRFKeyPair(OPRFModeBase, oprfSeed, oprfKeyInfo)
+	assert.NoError(t, err)
+	server, err := NewOPRFServer(OPRFModeBase, secretKey)
+	assert.NoError(t, err)
+	client, err := NewOPRFClient(OPRFModeBase, nil)
+	assert.NoError(t, err)
+
+	for _, v := range vectors {
+		blinded, err := client.blindWith(v.input, oprfBlind)
+		assert.NoError(t, err)
+		assert.Equal(t, v.blinded, hex.EncodeToString(blinded))
+
+		evaluated, proof, err := server.BlindEvaluate(blinded)
+		assert.NoError(t, err)
+		assert.Nil(t, proof)
+		assert.Equal(t, v.evaluated, hex.EncodeToString(evaluated))
+
+		output, err := client.Finalize(v.input, oprfBlind, nil, evaluated, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, v.output, hex.EncodeToString(output))
+
+		direct, err := server.Evaluate(v.input)
+		assert.NoError(t, err)
+		assert.Equal(t, v.output, hex.EncodeToString(direct))
+	}
+}
+
+func TestOPRFVerifiableVectors(t *testing.T) {
+	proofBlind := RistrettoScalar(mustDecodeHex("222a5e897cf59db8145db8d16e597e8facb80ae7d4e26d9881aa6f61d645fc0e"))
+	vectors := []oprfVector{
+		{
+			input:     oprfInputs[0],
+			blinded:   "863f330cc1a1259ed5a5998a23acfd37fb4351a793a5b3c090b642ddc439b945",
+			evaluated: "aa8fa048764d5623868679402ff6108d2521884fa138cd7f9c7669a9a014267e",
+			output:    "b58cfbe118e0cb94d79b5fd6a6dafb98764dff49c14e1770b566e42402da1a7da4d8527693914139caee5bd03903af43a491351d23b430948dd50cde10d32b3c",
+			proof:     "ddef93772692e535d1a53903db24367355cc2cc78de93b3be5a8ffcc6985dd066d4346421d17bf5117a2a1ff0fcb2a759f58a539dfbe857a40bce4cf49ec600d",
+		},
+		{
+			input:     oprfInputs[1],
+			blinded:   "cc0b2a350101881d8a4cba4c80241d74fb7dcbfde4a61fde2f91443c2bf9ef0c",
+			evaluated: "60a59a57208d48aca71e9e850d22674b611f752bed48b36f7a91b372bd7ad468",
+			output:    "8a9a2f3c7f085b65933594309041fc1898d42d0858e59f90814ae90571a6df60356f4610bf816f27afdd84f47719e480906d27ecd994985890e5f539e7ea74b6",
+			proof:     "401a0da6264f8cf45bb2f5264bc31e109155600babb3cd4e5af7d181a2c9dc0a67154fabf031fd936051dec80b0b6ae29c9503493dde7393b722eafdf5a50b02",
+		},
+	}
+	secretKey, publicKey, err := DeriveOPRFKeyPair(OPRFModeVerifiable, oprfSeed, oprfKeyInfo)
+	assert.NoError(t, err)
+	server, err := NewOPRFServer(OPRFModeVerifiable, secretKey)
+	assert.NoError(t, err)
+	assert.True(t, server.PublicKey().Equal(publicKey))
+	client, err := NewOPRFClient(OPRFModeVerifiable, publicKey)
+	assert.NoError(t, err)
+
+	for _, v := range vectors {
+		blinded, err := client.blindWith(v.input, oprfBlind)
+		assert.NoError(t, err)
+		assert.Equal(t, v.blinded, hex.EncodeToString(blinded))
+
+		evaluated, err := blinded.ScalarMult(secretKey)
+		assert.NoError(t, err)
+		assert.Equal(t, v.evaluated, hex.EncodeToString(evaluated))
+		proof, err := generateOPRFProof(server.contextString, secretKey, publicKey, []RistrettoPoint{blinded}, []RistrettoPoint{evaluated}, proofBlind)
+		assert.NoError(t, err)
+		assert.Equal(t, v.proof, hex.EncodeToString(proof))
+
+		output, err := client.Finalize(v.input, oprfBlind, blinded, evaluated, proof)
+		assert.NoError(t, err)
+		assert.Equal(t, v.output, hex.EncodeToString(output))
+	}
+
+	// batch of both inputs with a second blind
+	blinds := []RistrettoScalar{oprfBlind, proofBlind}
+	blinded := make([]RistrettoPoint, 2)
+	evaluated := make([]RistrettoPoint, 2)
+	for i := range blinds {
+		blinded[i], err = client.blindWith(oprfInputs[i], blinds[i])
+		assert.NoError(t, err)
+		evaluated[i], err = blinded[i].ScalarMult(secretKey)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "90a0145ea9da29254c3a56be4fe185465ebb3bf2a1801f7124bbbadac751e654", hex.EncodeToString(blinded[1]))
+	assert.Equal(t, "cc5ac221950a49ceaa73c8db41b82c20372a4c8d63e5dded2db920b7eee36a2a", hex.EncodeToString(evaluated[1]))
+	batchProofBlind := RistrettoScalar(mustDecodeHex("419c4f4f5052c53c45f3da494d2b67b220d02118e0857cdbcf037f9ea84bbe0c"))
+	proof, err := generateOPRFProof(server.contextString, secretKey, publicKey, blinded, evaluated, batchProofBlind)
+	assert.NoError(t, err)
+	assert.Equal(t, "cc203910175d786927eeb44ea847328047892ddf8590e723c37205cb74600b0a5ab5337c8eb4ceae0494c2cf89529dcf94572ed267473d567aeed6ab873dee08", hex.EncodeToString(proof))
+	outputs, err := client.FinalizeBatch(oprfInputs, blinds, blinded, evaluated, proof)
+	assert.NoError(t, err)
+	assert.Equal(t, vectors[0].output, hex.EncodeToString(outputs[0]))
+	assert.Equal(t, vectors[1].output, hex.EncodeToString(outputs[1]))
+}
+
+func TestOPRFVerifiable(t *testing.T) {
+	secretKey, publicKey, err := NewOPRFKeyPair()
+	assert.NoError(t, err)
+	server, err := NewOPRFServer(OPRFModeVerifiable, secretKey)
+	assert.NoError(t, err)
+	client, err := NewOPRFClient(OPRFModeVerifiable, publicKey)
+	assert.NoError(t, err)
+
+	inputs := [][]byte{[]byte("correct horse"), []byte("battery staple"), []byte("")}
+	blinds := make([]RistrettoScalar, len(inputs))
+	blinded := make([]RistrettoPoint, len(inputs))
+	for i, input := range inputs {
+		blinds[i], blinded[i], err = client.Blind(input)
+		assert.NoError(t, err)
+	}
+	evaluated, proof, err := server.BlindEvaluateBatch(blinded)
+	assert.NoError(t, err)
+	outputs, err := client.FinalizeBatch(inputs, blinds, blinded, evaluated, proof)
+	assert.NoError(t, err)
+	for i, input := range inputs {
+		assert.Len(t, outputs[i], OPRFOutputBytes)
+		direct, err := server.Evaluate(input)
+		assert.NoError(t, err)
+		assert.Equal(t, direct, outputs[i])
+	}
+
+	// a server evaluating with a different key is caught by the proof
+	otherSecretKey, _, err := NewOPRFKeyPair()
+	assert.NoError(t, err)
+	other, err := NewOPRFServer(OPRFModeVerifiable, otherSecretKey)
+	assert.NoError(t, err)
+	otherEvaluated, otherProof, err := other.BlindEvaluateBatch(blinded)
+	assert.NoError(t, err)
+	_, err = client.FinalizeBatch(inputs, blinds, blinded, otherEvaluated, otherProof)
+	assert.EqualError(t, err, ErrOPRFVerifyFailed.Error())
+
+	// swapping evaluations invalidates the proof
+	swapped := []RistrettoPoint{evaluated[1], evaluated[0], evaluated[2]}
+	_, err = client.FinalizeBatch(inputs, blinds, blinded, swapped, proof)
+	assert.EqualError(t, err, ErrOPRFVerifyFailed.Error())
+
+	tampered := append(OPRFProof{}, proof...)
+	tampered[0] ^= 0x01
+	_, err = client.FinalizeBatch(inputs, blinds, blinded, evaluated, tampered)
+	assert.EqualError(t, err, ErrOPRFVerifyFailed.Error())
+	_, err = client.FinalizeBatch(inputs, blinds, blinded, evaluated, proof[1:])
+	assert.EqualError(t, err, ErrBadOPRFProof.Error())
+	_, err = client.FinalizeBatch(inputs, blinds[1:], blinded, evaluated, proof)
+	assert.EqualError(t, err, ErrBadOPRFBatch.Error())
+}
+
+func TestOPRFFail(t *testing.T) {
+	secretKey, publicKey, err := NewOPRFKeyPair()
+	assert.NoError(t, err)
+	server, err := NewOPRFServer(OPRFModeBase, secretKey)
+	assert.NoError(t, err)
+	identity := make(RistrettoPoint, CryptoCoreRistretto255Bytes)
+
+	_, _, err = server.BlindEvaluate(identity)
+	assert.EqualError(t, err, ErrBadRistrettoPoint.Error())
+	_, _, err = server.BlindEvaluateBatch(nil)
+	assert.EqualError(t, err, ErrBadOPRFBatch.Error())
+	_, err = NewOPRFClient(OPRFModeVerifiable, nil)
+	assert.EqualError(t, err, ErrBadRistrettoPoint.Error())
+	_, err = NewOPRFClient(OPRFMode(0x02), publicKey)
+	assert.EqualError(t, err, ErrBadOPRFMode.Error())
+	_, err = NewOPRFServer(OPRFModeBase, make(RistrettoScalar, CryptoCoreRistretto255ScalarBytes))
+	assert.EqualError(t, err, ErrRistrettoZeroScalar.Error())
+
+	client, err := NewOPRFClient(OPRFModeBase, nil)
+	assert.NoError(t, err)
+	blind, _, err := client.Blind([]byte("input"))
+	assert.NoError(t, err)
+	_, err = client.Finalize([]byte("input"), blind, nil, identity, nil)
+	assert.EqualError(t, err, ErrBadRistrettoPoint.Error())
+}
@@ -0,0 +1,120 @@
+package bcl
+
+/*
+int sodium_pad(size_t *padded_buflen_p, unsigned char *buf, size_t unpadded_buflen, size_t blocksize, size_t max_buflen);
+int sodium_unpad(size_t *unpadded_buflen_p, const unsigned char *buf, size_t padded_buflen, size_t blocksize);
+*/
+import "C"
+import (
+	"math/bits"
+	"unsafe"
+)
+
+// PadmeBlockSize selects Padmé buckets instead of a fixed block size when passed to the padding
+// functions. Padmé bounds the padding overhead to roughly 12% of the message length while leaking
+// only O(log log n) bits of information about it
+const PadmeBlockSize = 0
+
+// Pad applies ISO/IEC 7816-4 padding to a plaintext, extending it to a multiple of blockSize (or to
+// its Padmé bucket if blockSize is PadmeBlockSize). At least one byte of padding is always added
+func Pad(plaintext Plaintext, blockSize int) (Plaintext, error) {
+	if blockSize < 0 {
+		return nil, ErrBadBlockSize
+	}
+	if blockSize == PadmeBlockSize {
+		blockSize = padmeLength(len(plaintext) + 1)
+	}
+
+	buf := make([]byte, len(plaintext)+blockSize)
+	copy(buf, plaintext)
+	var paddedLen C.size_t
+	rc := C.sodium_pad(
+		&paddedLen,
+		(*C.uchar)(unsafe.Pointer(&buf[0])),
+		C.size_t(len(plaintext)),
+		C.size_t(blockSize),
+		C.size_t(len(buf)),
+	)
+	if rc != 0 {
+		return nil, ErrBadBlockSize
+	}
+	return PlaintextFromBytes(buf[:paddedLen])
+}
+
+// Unpad removes ISO/IEC 7816-4 padding that was applied by Pad with the same blockSize
+func Unpad(padded Plaintext, blockSize int) (Plaintext, error) {
+	if blockSize < 0 {
+		return nil, ErrBadBlockSize
+	}
+	if len(padded) == 0 {
+		return nil, ErrBadPadding
+	}
+	padme := blockSize == PadmeBlockSize
+	if padme {
+		blockSize = len(padded)
+	}
+
+	var unpaddedLen C.size_t
+	rc := C.sodium_unpad(
+		&unpaddedLen,
+		(*C.uchar)(unsafe.Pointer(&padded[0])),
+		C.size_t(len(padded)),
+		C.size_t(blockSize),
+	)
+	if rc != 0 {
+		return nil, ErrBadPadding
+	}
+	if padme && padmeLength(int(unpaddedLen)+1) != len(padded) {
+		return nil, ErrBadPadding
+	}
+	return PlaintextFromBytes(padded[:unpaddedLen])
+}
+
+// padmeLength returns the Padmé bucket that a message of length n is padded to
+func padmeLength(n int) int {
+	if n < 2 {
+		return n
+	}
+	e := bits.Len(uint(n)) - 1
+	s := bits.Len(uint(e))
+	mask := (1 << (e - s)) - 1
+	return (n + mask) &^ mask
+}
+
+// SymmetricEncryptPadded pads a plaintext to blockSize (see Pad) and then encrypts it using the
+// supplied secret key and optional nonce, so that the ciphertext length only reveals the padded length
+func SymmetricEncryptPadded(secretKey SecretKey, plaintext Plaintext, nonce Nonce, blockSize int) (Ciphertext, error) {
+	padded, err := Pad(plaintext, blockSize)
+	if err != nil {
+		return nil, err
+	}
+	return SymmetricEncrypt(secretKey, padded, nonce)
+}
+
+// SymmetricDecryptPadded decrypts a ciphertext produced by SymmetricEncryptPadded and strips its padding
+func SymmetricDecryptPadded(secretKey SecretKey, ciphertext Ciphertext, blockSize int) (Plaintext, error) {
+	padded, err := SymmetricDecrypt(secretKey, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return Unpad(padded, blockSize)
+}
+
+// AsymmetricEncryptPadded pads a plaintext to blockSize (see Pad) and then encrypts it using the
+// supplied public key, so that the ciphertext length only reveals the padded length
+func AsymmetricEncryptPadded(publicKey PublicKey, plaintext Plaintext, blockSize int) (Ciphertext, error) {
+	padded, err := Pad(plaintext, blockSize)
+	if err != nil {
+		return nil, err
+	}
+	return AsymmetricEncrypt(publicKey, padded)
+}
+
+// AsymmetricDecryptPadded decrypts a ciphertext produced by AsymmetricEncryptPadded and strips its padding
+func AsymmetricDecryptPadded(secretKey SecretKey, ciphertext Ciphertext, blockSize int) (Plaintext, error) {
+	padded, err := AsymmetricDecrypt(secretKey, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return Unpad(padded, blockSize)
+}
@@ -0,0 +1,149 @@
+package bcl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPad(t *testing.T) {
+	tests := []struct {
+		name      string
+		msg       []byte
+		blockSize int
+		length    int
+		err       error
+	}{
+		{
+			name:      "TestPad success empty",
+			msg:       []byte{},
+			blockSize: 16,
+			length:    16,
+			err:       nil,
+		},
+		{
+			name:      "TestPad success short",
+			msg:       []byte("yes"),
+			blockSize: 16,
+			length:    16,
+			err:       nil,
+		},
+		{
+			name:      "TestPad success full block",
+			msg:       bytes.Repeat([]byte{0x01}, 16),
+			blockSize: 16,
+			length:    32,
+			err:       nil,
+		},
+		{
+			name:      "TestPad success non power of two",
+			msg:       bytes.Repeat([]byte{0x01}, 10),
+			blockSize: 12,
+			length:    12,
+			err:       nil,
+		},
+		{
+			name:      "TestPad success padme",
+			msg:       bytes.Repeat([]byte{0x01}, 1000),
+			blockSize: PadmeBlockSize,
+			length:    1024,
+			err:       nil,
+		},
+		{
+			name:      "TestPad fail block size",
+			msg:       []byte("no"),
+			blockSize: -1,
+			err:       ErrBadBlockSize,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			padded, err := Pad(tt.msg, tt.blockSize)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.length, len(padded))
+
+				unpadded, err := Unpad(padded, tt.blockSize)
+				assert.NoError(t, err)
+				assert.Equal(t, Plaintext(tt.msg), unpadded)
+			}
+		})
+	}
+}
+
+func TestUnpad(t *testing.T) {
+	tests := []struct {
+		name      string
+		padded    []byte
+		blockSize int
+		err       error
+	}{
+		{
+			name:      "TestUnpad fail empty",
+			padded:    []byte{},
+			blockSize: 16,
+			err:       ErrBadPadding,
+		},
+		{
+			name:      "TestUnpad fail no marker",
+			padded:    bytes.Repeat([]byte{0x00}, 16),
+			blockSize: 16,
+			err:       ErrBadPadding,
+		},
+		{
+			name:      "TestUnpad fail padme bucket",
+			padded:    append(bytes.Repeat([]byte{0x01}, 1000), 0x80, 0x00),
+			blockSize: PadmeBlockSize,
+			err:       ErrBadPadding,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Unpad(tt.padded, tt.blockSize)
+			assert.EqualError(t, err, tt.err.Error())
+		})
+	}
+}
+
+func TestPadmeLength(t *testing.T) {
+	for n := 1; n < 5000; n++ {
+		l := padmeLength(n)
+		assert.GreaterOrEqual(t, l, n)
+		assert.LessOrEqual(t, float64(l-n), 0.12*float64(n)+1)
+	}
+}
+
+func TestSymmetricEncryptPadded(t *testing.T) {
+	sk, err := NewSecretKey()
+	assert.NoError(t, err)
+
+	short, err := SymmetricEncryptPadded(sk, Plaintext("ok"), nil, 64)
+	assert.NoError(t, err)
+	long, err := SymmetricEncryptPadded(sk, Plaintext("see you tomorrow at noon"), nil, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, len(short), len(long))
+
+	dec, err := SymmetricDecryptPadded(sk, long, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, Plaintext("see you tomorrow at noon"), dec)
+}
+
+func TestAsymmetricEncryptPadded(t *testing.T) {
+	sk, pk, err := NewKeyPair()
+	assert.NoError(t, err)
+
+	short, err := AsymmetricEncryptPadded(pk, Plaintext("ok"), PadmeBlockSize)
+	assert.NoError(t, err)
+	dec, err := AsymmetricDecryptPadded(sk, short, PadmeBlockSize)
+	assert.NoError(t, err)
+	assert.Equal(t, Plaintext("ok"), dec)
+
+	empty, err := AsymmetricEncryptPadded(pk, Plaintext{}, 32)
+	assert.NoError(t, err)
+	dec, err = AsymmetricDecryptPadded(sk, empty, 32)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(dec))
+}
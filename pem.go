@@ -0,0 +1,178 @@
+package bcl
+
+import (
+	"encoding/asn1"
+	"encoding/pem"
+)
+
+// Keys are encoded as PKIX SubjectPublicKeyInfo ("PUBLIC KEY") and PKCS #8 ("PRIVATE KEY") PEM
+// blocks with the algorithm identifiers of RFC 8410, which is the format used by OpenSSL
+
+var (
+	oidX25519  = asn1.ObjectIdentifier{1, 3, 101, 110}
+	oidEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+const (
+	pemPublicKeyType  = "PUBLIC KEY"
+	pemPrivateKeyType = "PRIVATE KEY"
+)
+
+type pkixAlgorithm struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type pkixPublicKey struct {
+	Algorithm pkixAlgorithm
+	PublicKey asn1.BitString
+}
+
+type pkcs8PrivateKey struct {
+	Version    int
+	Algorithm  pkixAlgorithm
+	PrivateKey []byte
+	Attributes asn1.RawValue  `asn1:"optional,tag:0"`
+	PublicKey  asn1.BitString `asn1:"optional,tag:1"`
+}
+
+func marshalPublicKeyPEM(oid asn1.ObjectIdentifier, key []byte) []byte {
+	der, _ := asn1.Marshal(pkixPublicKey{
+		Algorithm: pkixAlgorithm{Algorithm: oid},
+		PublicKey: asn1.BitString{Bytes: key, BitLength: 8 * len(key)},
+	})
+	return pem.EncodeToMemory(&pem.Block{Type: pemPublicKeyType, Bytes: der})
+}
+
+func marshalPrivateKeyPEM(oid asn1.ObjectIdentifier, key []byte) []byte {
+	// the private key is itself DER encoded as an OCTET STRING inside the PKCS #8 OCTET STRING
+	inner, _ := asn1.Marshal(key)
+	der, _ := asn1.Marshal(pkcs8PrivateKey{
+		Algorithm:  pkixAlgorithm{Algorithm: oid},
+		PrivateKey: inner,
+	})
+	return pem.EncodeToMemory(&pem.Block{Type: pemPrivateKeyType, Bytes: der})
+}
+
+func decodePEM(data []byte, blockType string) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return nil, ErrBadPEM
+	}
+	return block.Bytes, nil
+}
+
+func parsePublicKeyPEM(data []byte, oid asn1.ObjectIdentifier, size int) ([]byte, error) {
+	der, err := decodePEM(data, pemPublicKeyType)
+	if err != nil {
+		return nil, err
+	}
+	var spki pkixPublicKey
+	rest, err := asn1.Unmarshal(der, &spki)
+	if err != nil || len(rest) > 0 {
+		return nil, ErrBadPEM
+	}
+	if !spki.Algorithm.Algorithm.Equal(oid) || len(spki.Algorithm.Parameters.FullBytes) > 0 {
+		return nil, ErrBadPEM
+	}
+	if spki.PublicKey.BitLength != 8*size || len(spki.PublicKey.Bytes) != size {
+		return nil, ErrBadPEM
+	}
+	return spki.PublicKey.Bytes, nil
+}
+
+func parsePrivateKeyPEM(data []byte, oid asn1.ObjectIdentifier, size int) ([]byte, error) {
+	der, err := decodePEM(data, pemPrivateKeyType)
+	if err != nil {
+		return nil, err
+	}
+	var p8 pkcs8PrivateKey
+	rest, err := asn1.Unmarshal(der, &p8)
+	if err != nil || len(rest) > 0 {
+		return nil, ErrBadPEM
+	}
+	// version 1 is the OneAsymmetricKey structure of RFC 5958, which may also carry the public key
+	if p8.Version != 0 && p8.Version != 1 {
+		return nil, ErrBadPEM
+	}
+	if !p8.Algorithm.Algorithm.Equal(oid) || len(p8.Algorithm.Parameters.FullBytes) > 0 {
+		return nil, ErrBadPEM
+	}
+	var key []byte
+	rest, err = asn1.Unmarshal(p8.PrivateKey, &key)
+	if err != nil || len(rest) > 0 || len(key) != size {
+		return nil, ErrBadPEM
+	}
+	return key, nil
+}
+
+// ToPEM encodes a public key as a PKIX "PUBLIC KEY" PEM block with the X25519 algorithm identifier
+func (p PublicKey) ToPEM() ([]byte, error) {
+	if len(p) != CryptoBoxPublicKeyBytes {
+		return nil, ErrBadPublicKeyLength
+	}
+	return marshalPublicKeyPEM(oidX25519, p), nil
+}
+
+// PublicKeyFromPEM parses an X25519 public key from a PKIX "PUBLIC KEY" PEM block
+func PublicKeyFromPEM(data []byte) (PublicKey, error) {
+	b, err := parsePublicKeyPEM(data, oidX25519, CryptoBoxPublicKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return PublicKey(b), nil
+}
+
+// ToPEM encodes a secret key as a PKCS #8 "PRIVATE KEY" PEM block with the X25519 algorithm identifier
+func (s SecretKey) ToPEM() ([]byte, error) {
+	if len(s) != CryptoSecretBoxKeyBytes {
+		return nil, ErrBadSecretKeyLength
+	}
+	return marshalPrivateKeyPEM(oidX25519, s), nil
+}
+
+// SecretKeyFromPEM parses an X25519 secret key from a PKCS #8 "PRIVATE KEY" PEM block
+func SecretKeyFromPEM(data []byte) (SecretKey, error) {
+	b, err := parsePrivateKeyPEM(data, oidX25519, CryptoSecretBoxKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return SecretKey(b), nil
+}
+
+// ToPEM encodes a verify key as a PKIX "PUBLIC KEY" PEM block with the Ed25519 algorithm identifier
+func (v VerifyKey) ToPEM() ([]byte, error) {
+	if len(v) != CryptoSignPublicKeyBytes {
+		return nil, ErrBadVerifyKeyLength
+	}
+	return marshalPublicKeyPEM(oidEd25519, v), nil
+}
+
+// VerifyKeyFromPEM parses an Ed25519 verify key from a PKIX "PUBLIC KEY" PEM block
+func VerifyKeyFromPEM(data []byte) (VerifyKey, error) {
+	b, err := parsePublicKeyPEM(data, oidEd25519, CryptoSignPublicKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return VerifyKey(b), nil
+}
+
+// ToPEM encodes the seed of a signing key as a PKCS #8 "PRIVATE KEY" PEM block with the Ed25519
+// algorithm identifier
+func (s SigningKey) ToPEM() ([]byte, error) {
+	seed, err := s.Seed()
+	if err != nil {
+		return nil, err
+	}
+	return marshalPrivateKeyPEM(oidEd25519, seed), nil
+}
+
+// SigningKeyFromPEM parses an Ed25519 signing key from a PKCS #8 "PRIVATE KEY" PEM block
+func SigningKeyFromPEM(data []byte) (SigningKey, error) {
+	seed, err := parsePrivateKeyPEM(data, oidEd25519, CryptoSignSeedBytes)
+	if err != nil {
+		return nil, err
+	}
+	sk, _, err := NewSigningKeyPairFromSeed(seed)
+	return sk, err
+}
@@ -1,13 +1,11 @@
 package bcl
 
 /*
-int crypto_scalarmult_base(unsigned char *q, const unsigned char *n);
 int sodium_memcmp(const void * const b1_, const void * const b2_, size_t len);
 */
 import "C"
 import (
 	"encoding/base64"
-	"fmt"
 	"hash/fnv"
 	"unsafe"
 )
@@ -16,24 +14,7 @@ type PublicKey []byte
 
 // NewPublicKey creates a new public key from a secret key
 func NewPublicKey(secretKey SecretKey) (PublicKey, error) {
-	return fromSecret(secretKey)
-}
-
-func fromSecret(secretKey SecretKey) (PublicKey, error) {
-	if len(secretKey) != CryptoSecretBoxKeyBytes {
-		return nil, ErrBadSecretKeyLength
-	}
-
-	out := make([]byte, CryptoScalarMultBytes) // CryptoScalarMultBytes will always equal CryptoBoxPublicKeyBytes
-	rc := C.crypto_scalarmult_base(
-		(*C.uchar)(unsafe.Pointer(&out[0])),
-		(*C.uchar)(unsafe.Pointer(&secretKey[0])),
-	)
-	if rc != 0 {
-		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
-	}
-
-	return PublicKey(out), nil
+	return ScalarMultBase(secretKey)
 }
 
 // PublicKeyFromBytes casts a public key from a byte slice of length CryptoBoxPublicKeyBytes
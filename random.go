@@ -0,0 +1,106 @@
+package bcl
+
+/*
+#include <stddef.h>
+void randombytes_buf(void * const buf, const size_t size);
+void randombytes_buf_deterministic(void * const buf, const size_t size, const unsigned char *seed);
+*/
+import "C"
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"sync"
+	"unsafe"
+)
+
+// Every key, nonce, seed and salt generated by this library is read from the package-level random
+// source, which is crypto/rand unless replaced with SetRandomSource. Sealed boxes created by
+// AsymmetricEncrypt are the exception: their ephemeral keys are always generated inside libsodium
+
+var (
+	randomMu     sync.RWMutex
+	randomSource io.Reader = rand.Reader
+)
+
+// SetRandomSource replaces the random source used for all key, nonce, seed and salt generation.
+// Passing nil restores crypto/rand. The source must be safe for concurrent use if the library is
+// used from multiple goroutines
+func SetRandomSource(r io.Reader) {
+	if r == nil {
+		r = rand.Reader
+	}
+	randomMu.Lock()
+	randomSource = r
+	randomMu.Unlock()
+}
+
+// RandomSource returns the current package-level random source
+func RandomSource() io.Reader {
+	randomMu.RLock()
+	defer randomMu.RUnlock()
+	return randomSource
+}
+
+// randomBytes fills b from the supplied reader, or from the package-level random source if r is nil
+func randomBytes(r io.Reader, b []byte) error {
+	if r == nil {
+		r = RandomSource()
+	}
+	_, err := io.ReadFull(r, b)
+	return err
+}
+
+type sodiumReader struct{}
+
+func (sodiumReader) Read(p []byte) (int, error) {
+	if len(p) > 0 {
+		C.randombytes_buf(unsafe.Pointer(&p[0]), C.size_t(len(p)))
+	}
+	return len(p), nil
+}
+
+// SodiumReader is a random source that reads from libsodium's randombytes_buf
+var SodiumReader io.Reader = sodiumReader{}
+
+const deterministicBlockSize = 4096
+
+type deterministicReader struct {
+	mu    sync.Mutex
+	seed  []byte
+	block uint64
+	buf   []byte
+}
+
+// NewDeterministicReader returns a random source that produces a reproducible stream from a seed of
+// length CryptoRandomSeedBytes using libsodium's randombytes_buf_deterministic. The stream does not
+// depend on how reads are sized. It must only be used for tests and reproducible fixtures
+func NewDeterministicReader(seed []byte) (io.Reader, error) {
+	if len(seed) != CryptoRandomSeedBytes {
+		return nil, ErrBadSeedLength
+	}
+	return &deterministicReader{seed: append([]byte{}, seed...)}, nil
+}
+
+func (d *deterministicReader) Read(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := 0
+	for n < len(p) {
+		if len(d.buf) == 0 {
+			// each block is generated from its own seed so that the stream can be produced incrementally
+			index := binary.BigEndian.AppendUint64(nil, d.block)
+			blockSeed, err := genericHash(CryptoRandomSeedBytes, d.seed, index)
+			if err != nil {
+				return n, err
+			}
+			d.buf = make([]byte, deterministicBlockSize)
+			C.randombytes_buf_deterministic(unsafe.Pointer(&d.buf[0]), C.size_t(len(d.buf)), ucharPtr(blockSeed))
+			d.block++
+		}
+		c := copy(p[n:], d.buf)
+		d.buf = d.buf[c:]
+		n += c
+	}
+	return n, nil
+}
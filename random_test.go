@@ -0,0 +1,97 @@
+package bcl
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeterministicReader(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x01}, CryptoRandomSeedBytes)
+	r1, err := NewDeterministicReader(seed)
+	assert.NoError(t, err)
+	r2, err := NewDeterministicReader(seed)
+	assert.NoError(t, err)
+
+	// the stream is the same regardless of how reads are sized
+	a := make([]byte, 3*deterministicBlockSize+5)
+	_, err = io.ReadFull(r1, a)
+	assert.NoError(t, err)
+	b := make([]byte, 0, len(a))
+	for len(b) < len(a) {
+		chunk := make([]byte, min(7, len(a)-len(b)))
+		_, err = io.ReadFull(r2, chunk)
+		assert.NoError(t, err)
+		b = append(b, chunk...)
+	}
+	assert.Equal(t, a, b)
+
+	r3, err := NewDeterministicReader(bytes.Repeat([]byte{0x02}, CryptoRandomSeedBytes))
+	assert.NoError(t, err)
+	c := make([]byte, len(a))
+	_, err = io.ReadFull(r3, c)
+	assert.NoError(t, err)
+	assert.NotEqual(t, a, c)
+
+	_, err = NewDeterministicReader(seed[1:])
+	assert.EqualError(t, err, ErrBadSeedLength.Error())
+}
+
+func TestSetRandomSource(t *testing.T) {
+	defer SetRandomSource(nil)
+	seed := bytes.Repeat([]byte{0x01}, CryptoRandomSeedBytes)
+	m, err := PlaintextFromString("golden")
+	assert.NoError(t, err)
+
+	encrypt := func() (SecretKey, Ciphertext) {
+		r, err := NewDeterministicReader(seed)
+		assert.NoError(t, err)
+		SetRandomSource(r)
+		sk, err := NewSecretKey()
+		assert.NoError(t, err)
+		c, err := SymmetricEncrypt(sk, m, nil)
+		assert.NoError(t, err)
+		return sk, c
+	}
+	sk1, c1 := encrypt()
+	sk2, c2 := encrypt()
+	assert.Equal(t, sk1, sk2)
+	assert.Equal(t, c1, c2)
+
+	SetRandomSource(nil)
+	sk3, err := NewSecretKey()
+	assert.NoError(t, err)
+	assert.NotEqual(t, sk1, sk3)
+}
+
+func TestNewSecretKeyFromReader(t *testing.T) {
+	key := bytes.Repeat([]byte{0x07}, CryptoSecretBoxKeyBytes)
+	tests := []struct {
+		name string
+		r    io.Reader
+		err  error
+	}{
+		{name: "TestNewSecretKeyFromReader success", r: bytes.NewReader(key), err: nil},
+		{name: "TestNewSecretKeyFromReader fail short", r: bytes.NewReader(key[1:]), err: io.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sk, err := NewSecretKeyFromReader(tt.r)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, SecretKey(key), sk)
+			}
+		})
+	}
+
+	n, err := NewNonceFromReader(SodiumReader)
+	assert.NoError(t, err)
+	assert.False(t, isZero(n))
+	_, pk, err := NewKeyPairFromReader(SodiumReader)
+	assert.NoError(t, err)
+	assert.Equal(t, CryptoBoxPublicKeyBytes, len(pk))
+}
@@ -0,0 +1,300 @@
+package bcl
+
+/*
+int crypto_core_ristretto255_is_valid_point(const unsigned char *p);
+int crypto_core_ristretto255_add(unsigned char *r, const unsigned char *p, const unsigned char *q);
+int crypto_core_ristretto255_sub(unsigned char *r, const unsigned char *p, const unsigned char *q);
+int crypto_core_ristretto255_from_hash(unsigned char *p, const unsigned char *r);
+int crypto_core_ristretto255_scalar_invert(unsigned char *recip, const unsigned char *s);
+void crypto_core_ristretto255_scalar_negate(unsigned char *neg, const unsigned char *s);
+void crypto_core_ristretto255_scalar_complement(unsigned char *comp, const unsigned char *s);
+void crypto_core_ristretto255_scalar_add(unsigned char *z, const unsigned char *x, const unsigned char *y);
+void crypto_core_ristretto255_scalar_sub(unsigned char *z, const unsigned char *x, const unsigned char *y);
+void crypto_core_ristretto255_scalar_mul(unsigned char *z, const unsigned char *x, const unsigned char *y);
+void crypto_core_ristretto255_scalar_reduce(unsigned char *r, const unsigned char *s);
+int crypto_scalarmult_ristretto255(unsigned char *q, const unsigned char *n, const unsigned char *p);
+int crypto_scalarmult_ristretto255_base(unsigned char *q, const unsigned char *n);
+int sodium_is_zero(const unsigned char *n, const size_t nlen);
+*/
+import "C"
+import (
+	"encoding/base64"
+	"io"
+)
+
+// Ristretto255 is a prime order group built on Curve25519, so unlike X25519 keys its elements can
+// be added and multiplied by arbitrary scalars without cofactor pitfalls. Points and scalars are
+// always kept in their canonical 32 byte encodings, and every constructor rejects non-canonical
+// input. Random points and scalars are drawn from the package-level random source
+
+// RistrettoPoint is the canonical encoding of an element of the ristretto255 group
+type RistrettoPoint []byte
+
+// RistrettoScalar is the canonical little-endian encoding of an integer modulo the group order
+type RistrettoScalar []byte
+
+// NewRistrettoPoint returns a uniformly random ristretto255 point
+func NewRistrettoPoint() (RistrettoPoint, error) {
+	return NewRistrettoPointFromReader(nil)
+}
+
+// NewRistrettoPointFromReader returns a uniformly random ristretto255 point using randomness read
+// from r, or from the package-level random source if r is nil
+func NewRistrettoPointFromReader(r io.Reader) (RistrettoPoint, error) {
+	h := make([]byte, CryptoCoreRistretto255HashBytes)
+	if err := randomBytes(r, h); err != nil {
+		return nil, err
+	}
+	return RistrettoPointFromHash(h)
+}
+
+// RistrettoPointFromBytes casts a ristretto255 point from its canonical encoding
+func RistrettoPointFromBytes(b []byte) (RistrettoPoint, error) {
+	if len(b) != CryptoCoreRistretto255Bytes || C.crypto_core_ristretto255_is_valid_point(ucharPtr(b)) != 1 {
+		return nil, ErrBadRistrettoPoint
+	}
+	return RistrettoPoint(b), nil
+}
+
+// RistrettoPointFromBase64 casts a ristretto255 point from a base64 encoded string
+func RistrettoPointFromBase64(arg string) (RistrettoPoint, error) {
+	b, err := base64.StdEncoding.DecodeString(arg)
+	if err != nil {
+		return nil, err
+	}
+	return RistrettoPointFromBytes(b)
+}
+
+// RistrettoPointFromHash maps CryptoCoreRistretto255HashBytes uniformly random bytes, such as the
+// output of a 512 bit hash function, to a ristretto255 point
+func RistrettoPointFromHash(h []byte) (RistrettoPoint, error) {
+	if len(h) != CryptoCoreRistretto255HashBytes {
+		return nil, ErrBadRistrettoHashLength
+	}
+	out := make([]byte, CryptoCoreRistretto255Bytes)
+	// crypto_core_ristretto255_from_hash cannot fail
+	C.crypto_core_ristretto255_from_hash(ucharPtr(out), ucharPtr(h))
+	return RistrettoPoint(out), nil
+}
+
+// HashToRistrettoPoint hashes a message to a ristretto255 point using hash_to_ristretto255 from
+// RFC 9380 (expand_message_xmd with SHA-512) under the supplied domain separation tag
+func HashToRistrettoPoint(msg, dst []byte) (RistrettoPoint, error) {
+	h, err := expandMessageXMD(msg, dst, CryptoCoreRistretto255HashBytes)
+	if err != nil {
+		return nil, err
+	}
+	return RistrettoPointFromHash(h)
+}
+
+// RistrettoScalarMultBase multiplies the ristretto255 generator by a canonical scalar. It returns
+// ErrRistrettoZeroScalar if the scalar is zero, as the result would be the identity element
+func RistrettoScalarMultBase(s RistrettoScalar) (RistrettoPoint, error) {
+	if _, err := RistrettoScalarFromBytes(s); err != nil {
+		return nil, err
+	}
+	out := make([]byte, CryptoCoreRistretto255Bytes)
+	if C.crypto_scalarmult_ristretto255_base(ucharPtr(out), ucharPtr(s)) != 0 {
+		return nil, ErrRistrettoZeroScalar
+	}
+	return RistrettoPoint(out), nil
+}
+
+// Add returns the sum of two ristretto255 points
+func (p RistrettoPoint) Add(q RistrettoPoint) (RistrettoPoint, error) {
+	if len(p) != CryptoCoreRistretto255Bytes || len(q) != CryptoCoreRistretto255Bytes {
+		return nil, ErrBadRistrettoPoint
+	}
+	out := make([]byte, CryptoCoreRistretto255Bytes)
+	if C.crypto_core_ristretto255_add(ucharPtr(out), ucharPtr(p), ucharPtr(q)) != 0 {
+		return nil, ErrBadRistrettoPoint
+	}
+	return RistrettoPoint(out), nil
+}
+
+// Sub returns the difference of two ristretto255 points
+func (p RistrettoPoint) Sub(q RistrettoPoint) (RistrettoPoint, error) {
+	if len(p) != CryptoCoreRistretto255Bytes || len(q) != CryptoCoreRistretto255Bytes {
+		return nil, ErrBadRistrettoPoint
+	}
+	out := make([]byte, CryptoCoreRistretto255Bytes)
+	if C.crypto_core_ristretto255_sub(ucharPtr(out), ucharPtr(p), ucharPtr(q)) != 0 {
+		return nil, ErrBadRistrettoPoint
+	}
+	return RistrettoPoint(out), nil
+}
+
+// ScalarMult multiplies a ristretto255 point by a canonical scalar. It returns ErrRistrettoIdentity if the
+// result is the identity element, which for a valid point only happens when the scalar is zero
+func (p RistrettoPoint) ScalarMult(s RistrettoScalar) (RistrettoPoint, error) {
+	if _, err := RistrettoScalarFromBytes(s); err != nil {
+		return nil, err
+	}
+	if _, err := RistrettoPointFromBytes(p); err != nil {
+		return nil, err
+	}
+	out := make([]byte, CryptoCoreRistretto255Bytes)
+	if C.crypto_scalarmult_ristretto255(ucharPtr(out), ucharPtr(s), ucharPtr(p)) != 0 {
+		return nil, ErrRistrettoIdentity
+	}
+	return RistrettoPoint(out), nil
+}
+
+// IsIdentity returns whether a point is the identity element of the group
+func (p RistrettoPoint) IsIdentity() bool {
+	return len(p) == CryptoCoreRistretto255Bytes && C.sodium_is_zero(ucharPtr(p), C.size_t(len(p))) == 1
+}
+
+// ToBase64 converts a ristretto255 point to a base64 encoded string
+func (p RistrettoPoint) ToBase64() string {
+	return base64.StdEncoding.EncodeToString(p)
+}
+
+// Equal returns whether a ristretto255 point is equal to another point
+func (p RistrettoPoint) Equal(other RistrettoPoint) bool {
+	return constantTimeEqual(p, other)
+}
+
+// NewRistrettoScalar returns a uniformly random non-zero ristretto255 scalar
+func NewRistrettoScalar() (RistrettoScalar, error) {
+	return NewRistrettoScalarFromReader(nil)
+}
+
+// NewRistrettoScalarFromReader returns a uniformly random non-zero ristretto255 scalar using
+// randomness read from r, or from the package-level random source if r is nil
+func NewRistrettoScalarFromReader(r io.Reader) (RistrettoScalar, error) {
+	wide := make([]byte, CryptoCoreRistretto255NonReducedScalarBytes)
+	for {
+		if err := randomBytes(r, wide); err != nil {
+			return nil, err
+		}
+		s, err := RistrettoScalarFromWideBytes(wide)
+		if err != nil {
+			return nil, err
+		}
+		if !s.IsZero() {
+			return s, nil
+		}
+	}
+}
+
+// RistrettoScalarFromBytes casts a ristretto255 scalar from its canonical encoding, rejecting
+// encodings of integers that are not reduced modulo the group order
+func RistrettoScalarFromBytes(b []byte) (RistrettoScalar, error) {
+	if len(b) != CryptoCoreRistretto255ScalarBytes {
+		return nil, ErrBadRistrettoScalar
+	}
+	wide := make([]byte, CryptoCoreRistretto255NonReducedScalarBytes)
+	copy(wide, b)
+	reduced, err := RistrettoScalarFromWideBytes(wide)
+	if err != nil {
+		return nil, err
+	}
+	if !constantTimeEqual(reduced, b) {
+		return nil, ErrBadRistrettoScalar
+	}
+	return RistrettoScalar(b), nil
+}
+
+// RistrettoScalarFromBase64 casts a ristretto255 scalar from a base64 encoded string
+func RistrettoScalarFromBase64(arg string) (RistrettoScalar, error) {
+	b, err := base64.StdEncoding.DecodeString(arg)
+	if err != nil {
+		return nil, err
+	}
+	return RistrettoScalarFromBytes(b)
+}
+
+// RistrettoScalarFromWideBytes reduces a CryptoCoreRistretto255NonReducedScalarBytes little-endian
+// integer, such as the output of a 512 bit hash function, modulo the group order
+func RistrettoScalarFromWideBytes(b []byte) (RistrettoScalar, error) {
+	if len(b) != CryptoCoreRistretto255NonReducedScalarBytes {
+		return nil, ErrBadRistrettoScalar
+	}
+	out := make([]byte, CryptoCoreRistretto255ScalarBytes)
+	C.crypto_core_ristretto255_scalar_reduce(ucharPtr(out), ucharPtr(b))
+	return RistrettoScalar(out), nil
+}
+
+// HashToRistrettoScalar hashes a message to a ristretto255 scalar using expand_message_xmd with
+// SHA-512 under the supplied domain separation tag, as in the HashToScalar function of RFC 9497
+func HashToRistrettoScalar(msg, dst []byte) (RistrettoScalar, error) {
+	wide, err := expandMessageXMD(msg, dst, CryptoCoreRistretto255NonReducedScalarBytes)
+	if err != nil {
+		return nil, err
+	}
+	return RistrettoScalarFromWideBytes(wide)
+}
+
+// scalarOp applies a binary libsodium scalar operation after checking both operand lengths
+func scalarOp(x, y RistrettoScalar, op func(z, x, y *C.uchar)) (RistrettoScalar, error) {
+	if len(x) != CryptoCoreRistretto255ScalarBytes || len(y) != CryptoCoreRistretto255ScalarBytes {
+		return nil, ErrBadRistrettoScalar
+	}
+	out := make([]byte, CryptoCoreRistretto255ScalarBytes)
+	op(ucharPtr(out), ucharPtr(x), ucharPtr(y))
+	return RistrettoScalar(out), nil
+}
+
+// Add returns the sum of two scalars modulo the group order
+func (s RistrettoScalar) Add(other RistrettoScalar) (RistrettoScalar, error) {
+	return scalarOp(s, other, func(z, x, y *C.uchar) { C.crypto_core_ristretto255_scalar_add(z, x, y) })
+}
+
+// Sub returns the difference of two scalars modulo the group order
+func (s RistrettoScalar) Sub(other RistrettoScalar) (RistrettoScalar, error) {
+	return scalarOp(s, other, func(z, x, y *C.uchar) { C.crypto_core_ristretto255_scalar_sub(z, x, y) })
+}
+
+// Mul returns the product of two scalars modulo the group order
+func (s RistrettoScalar) Mul(other RistrettoScalar) (RistrettoScalar, error) {
+	return scalarOp(s, other, func(z, x, y *C.uchar) { C.crypto_core_ristretto255_scalar_mul(z, x, y) })
+}
+
+// Negate returns the additive inverse of a scalar modulo the group order
+func (s RistrettoScalar) Negate() (RistrettoScalar, error) {
+	if len(s) != CryptoCoreRistretto255ScalarBytes {
+		return nil, ErrBadRistrettoScalar
+	}
+	out := make([]byte, CryptoCoreRistretto255ScalarBytes)
+	C.crypto_core_ristretto255_scalar_negate(ucharPtr(out), ucharPtr(s))
+	return RistrettoScalar(out), nil
+}
+
+// Complement returns 1 - s modulo the group order
+func (s RistrettoScalar) Complement() (RistrettoScalar, error) {
+	if len(s) != CryptoCoreRistretto255ScalarBytes {
+		return nil, ErrBadRistrettoScalar
+	}
+	out := make([]byte, CryptoCoreRistretto255ScalarBytes)
+	C.crypto_core_ristretto255_scalar_complement(ucharPtr(out), ucharPtr(s))
+	return RistrettoScalar(out), nil
+}
+
+// Invert returns the multiplicative inverse of a scalar modulo the group order, or
+// ErrRistrettoZeroScalar if the scalar is zero
+func (s RistrettoScalar) Invert() (RistrettoScalar, error) {
+	if len(s) != CryptoCoreRistretto255ScalarBytes {
+		return nil, ErrBadRistrettoScalar
+	}
+	out := make([]byte, CryptoCoreRistretto255ScalarBytes)
+	if C.crypto_core_ristretto255_scalar_invert(ucharPtr(out), ucharPtr(s)) != 0 {
+		return nil, ErrRistrettoZeroScalar
+	}
+	return RistrettoScalar(out), nil
+}
+
+// IsZero returns whether a scalar is zero
+func (s RistrettoScalar) IsZero() bool {
+	return len(s) == CryptoCoreRistretto255ScalarBytes && C.sodium_is_zero(ucharPtr(s), C.size_t(len(s))) == 1
+}
+
+// ToBase64 converts a ristretto255 scalar to a base64 encoded string
+func (s RistrettoScalar) ToBase64() string {
+	return base64.StdEncoding.EncodeToString(s)
+}
+
+// Equal returns whether a ristretto255 scalar is equal to another scalar
+func (s RistrettoScalar) Equal(other RistrettoScalar) bool {
+	return constantTimeEqual(s, other)
+}
@@ -0,0 +1,264 @@
+package bcl
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ristrettoScalarFromUint(n byte) RistrettoScalar {
+	s := make(RistrettoScalar, CryptoCoreRistretto255ScalarBytes)
+	s[0] = n
+	return s
+}
+
+func TestRistrettoScalarMultBase(t *testing.T) {
+	// RFC 9496, appendix A.1
+	multiples := []string{
+		"e2f2ae0a6abc4e71a884a961c500515f58e30b6aa582dd8db6a65945e08d2d76",
+		"6a493210f7499cd17fecb510ae0cea23a110e8d5b901f8acadd3095c73a3b919",
+		"94741f5d5d52755ece4f23f044ee27d5d1ea1e2bd196b462166b16152a9d0259",
+	}
+	for i, expected := range multiples {
+		p, err := RistrettoScalarMultBase(ristrettoScalarFromUint(byte(i + 1)))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, hex.EncodeToString(p))
+	}
+
+	_, err := RistrettoScalarMultBase(ristrettoScalarFromUint(0))
+	assert.EqualError(t, err, ErrRistrettoZeroScalar.Error())
+	_, err = RistrettoScalarMultBase(ristrettoScalarFromUint(1)[1:])
+	assert.EqualError(t, err, ErrBadRistrettoScalar.Error())
+	// the group order plus one, which reduces to one
+	_, err = RistrettoScalarMultBase(mustDecodeHex("eed3f55c1a631258d69cf7a2def9de1400000000000000000000000000000010"))
+	assert.EqualError(t, err, ErrBadRistrettoScalar.Error())
+}
+
+func TestRistrettoPointFromBytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   error
+	}{
+		{name: "TestRistrettoPointFromBytes success generator", input: "e2f2ae0a6abc4e71a884a961c500515f58e30b6aa582dd8db6a65945e08d2d76"},
+		{name: "TestRistrettoPointFromBytes success identity", input: "0000000000000000000000000000000000000000000000000000000000000000"},
+		{name: "TestRistrettoPointFromBytes fail non-canonical", input: "00ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", err: ErrBadRistrettoPoint},
+		{name: "TestRistrettoPointFromBytes fail negative", input: "0100000000000000000000000000000000000000000000000000000000000000", err: ErrBadRistrettoPoint},
+		{name: "TestRistrettoPointFromBytes fail length", input: "e2f2ae0a6abc4e71a884a961c500515f58e30b6aa582dd8db6a65945e08d2d", err: ErrBadRistrettoPoint},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := mustDecodeHex(tt.input)
+			p, err := RistrettoPointFromBytes(input)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, RistrettoPoint(input), p)
+				decoded, err := RistrettoPointFromBase64(p.ToBase64())
+				assert.NoError(t, err)
+				assert.True(t, decoded.Equal(p))
+			}
+		})
+	}
+}
+
+func TestHashToRistretto(t *testing.T) {
+	// generated with expand_message_xmd from github.com/cloudflare/circl and the one-way map from
+	// github.com/gtank/ristretto255
+	tests := []struct {
+		msg    string
+		point  string
+		scalar string
+	}{
+		{msg: "", point: "20caeac64d5d62411a47c3df3a0427fe85402a2fa6d29dd94ac3b77f0e6f2679", scalar: "e447be6eb1c7ea6a0e8ab322cf8eef8a9a1511d46286a48b220734fe379fcc0b"},
+		{msg: "abc", point: "7efe130ebdd60faefafcd8a1331288b8daa8bcdb0d19b1f3318e34168730c86c", scalar: "8a7976899192874f1c042e7399b6b671365236ee56608c106eb9bc86aeff6f00"},
+		{msg: "hello ristretto", point: "f2078aef0b2aede89cd30582d06de286810da56acd7a325e959e13f0232c4916", scalar: "9c33729e26be928131366bcdd8140bfe850a4155dc93e9f3bcda22420e6d5404"},
+	}
+	for _, tt := range tests {
+		t.Run("TestHashToRistretto success "+tt.msg, func(t *testing.T) {
+			p, err := HashToRistrettoPoint([]byte(tt.msg), []byte("bcl-test-H2G"))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.point, hex.EncodeToString(p))
+			s, err := HashToRistrettoScalar([]byte(tt.msg), []byte("bcl-test-H2S"))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.scalar, hex.EncodeToString(s))
+		})
+	}
+
+	_, err := RistrettoPointFromHash(make([]byte, 32))
+	assert.EqualError(t, err, ErrBadRistrettoHashLength.Error())
+}
+
+func TestExpandMessageXMD(t *testing.T) {
+	// RFC 9380, appendix K.3
+	out, err := expandMessageXMD(nil, []byte("QUUX-V01-CS02-with-expander-SHA512-256"), 0x20)
+	assert.NoError(t, err)
+	assert.Equal(t, "6b9a7312411d92f921c6f68ca0b6380730a1a4d982c507211a90964c394179ba", hex.EncodeToString(out))
+	out, err = expandMessageXMD([]byte("abc"), []byte("QUUX-V01-CS02-with-expander-SHA512-256"), 0x80)
+	assert.NoError(t, err)
+	assert.Equal(t, "7f1dddd13c08b543f2e2037b14cefb255b44c83cc397c1786d975653e36a6b11bdd7732d8b38adb4a0edc26a0cef4bb45217135456e58fbca1703cd6032cb1347ee720b87972d63fbf232587043ed2901bce7f22610c0419751c065922b488431851041310ad659e4b23520e1772ab29dcdeb2002222a363f0c2b1c972b3efe1", hex.EncodeToString(out))
+
+	// oversized domain separation tags are hashed first
+	out, err = expandMessageXMD([]byte("msg"), bytes.Repeat([]byte("a"), 300), 0x40)
+	assert.NoError(t, err)
+	assert.Equal(t, "f3433d73211fedbc9a3a02f0c0ab6e649081cbedfe313778e07791740f211d8116ec86e53ca49e369c942da3abdc5b2b6e4619c934996ed90420180480fa593a", hex.EncodeToString(out))
+
+	_, err = expandMessageXMD([]byte("msg"), []byte("dst"), 0)
+	assert.EqualError(t, err, ErrBadExpandLength.Error())
+	_, err = expandMessageXMD([]byte("msg"), []byte("dst"), 256*64)
+	assert.EqualError(t, err, ErrBadExpandLength.Error())
+}
+
+func TestRistrettoPointArithmetic(t *testing.T) {
+	a, err := NewRistrettoScalar()
+	assert.NoError(t, err)
+	b, err := NewRistrettoScalar()
+	assert.NoError(t, err)
+	aG, err := RistrettoScalarMultBase(a)
+	assert.NoError(t, err)
+	bG, err := RistrettoScalarMultBase(b)
+	assert.NoError(t, err)
+
+	// aG + bG = (a + b)G
+	sum, err := aG.Add(bG)
+	assert.NoError(t, err)
+	ab, err := a.Add(b)
+	assert.NoError(t, err)
+	abG, err := RistrettoScalarMultBase(ab)
+	assert.NoError(t, err)
+	assert.True(t, sum.Equal(abG))
+
+	// (aG + bG) - bG = aG
+	diff, err := sum.Sub(bG)
+	assert.NoError(t, err)
+	assert.True(t, diff.Equal(aG))
+	identity, err := aG.Sub(aG)
+	assert.NoError(t, err)
+	assert.True(t, identity.IsIdentity())
+	assert.False(t, aG.IsIdentity())
+
+	// b(aG) = a(bG)
+	baG, err := aG.ScalarMult(b)
+	assert.NoError(t, err)
+	abG2, err := bG.ScalarMult(a)
+	assert.NoError(t, err)
+	assert.True(t, baG.Equal(abG2))
+
+	// a^-1(aP) = P
+	p, err := NewRistrettoPoint()
+	assert.NoError(t, err)
+	ap, err := p.ScalarMult(a)
+	assert.NoError(t, err)
+	aInv, err := a.Invert()
+	assert.NoError(t, err)
+	unblinded, err := ap.ScalarMult(aInv)
+	assert.NoError(t, err)
+	assert.True(t, unblinded.Equal(p))
+
+	_, err = p.ScalarMult(ristrettoScalarFromUint(0))
+	assert.EqualError(t, err, ErrRistrettoIdentity.Error())
+	_, err = identity.ScalarMult(a)
+	assert.EqualError(t, err, ErrRistrettoIdentity.Error())
+	_, err = p.ScalarMult(mustDecodeHex("eed3f55c1a631258d69cf7a2def9de1400000000000000000000000000000010"))
+	assert.EqualError(t, err, ErrBadRistrettoScalar.Error())
+	_, err = p.ScalarMult(a[1:])
+	assert.EqualError(t, err, ErrBadRistrettoScalar.Error())
+	_, err = RistrettoPoint(mustDecodeHex("0100000000000000000000000000000000000000000000000000000000000000")).ScalarMult(a)
+	assert.EqualError(t, err, ErrBadRistrettoPoint.Error())
+	_, err = aG.Add(bG[1:])
+	assert.EqualError(t, err, ErrBadRistrettoPoint.Error())
+}
+
+func TestRistrettoScalarArithmetic(t *testing.T) {
+	// generated with github.com/gtank/ristretto255
+	a := RistrettoScalar(mustDecodeHex("0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f00"))
+	b := RistrettoScalar(mustDecodeHex("ffeeddccbbaa99887766554433221100ffeeddccbbaa99887766554433221100"))
+	mul, err := a.Mul(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "aca2c5e36f0f4afc66ab7cd58fe4da4dc0b0e568d15801ac930bd533b2407104", hex.EncodeToString(mul))
+	inv, err := a.Invert()
+	assert.NoError(t, err)
+	assert.Equal(t, "929bfe74ecfa234350ee2b066843184ec49ddd8242185bd685a60b49b5b3f106", hex.EncodeToString(inv))
+	aG, err := RistrettoScalarMultBase(a)
+	assert.NoError(t, err)
+	assert.Equal(t, "cece76aabc4bb51f95d38fd5d7ab0349d6ddd42a6fae74056e06cc8002b07b5a", hex.EncodeToString(aG))
+
+	// a - b + b = a, a + (-a) = 0 and a + (1 - a) = 1
+	diff, err := a.Sub(b)
+	assert.NoError(t, err)
+	sum, err := diff.Add(b)
+	assert.NoError(t, err)
+	assert.True(t, sum.Equal(a))
+	neg, err := a.Negate()
+	assert.NoError(t, err)
+	zero, err := a.Add(neg)
+	assert.NoError(t, err)
+	assert.True(t, zero.IsZero())
+	comp, err := a.Complement()
+	assert.NoError(t, err)
+	one, err := a.Add(comp)
+	assert.NoError(t, err)
+	assert.Equal(t, ristrettoScalarFromUint(1), one)
+
+	_, err = zero.Invert()
+	assert.EqualError(t, err, ErrRistrettoZeroScalar.Error())
+	_, err = a.Mul(b[1:])
+	assert.EqualError(t, err, ErrBadRistrettoScalar.Error())
+	_, err = a[1:].Negate()
+	assert.EqualError(t, err, ErrBadRistrettoScalar.Error())
+}
+
+func TestRistrettoScalarFromBytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   error
+	}{
+		{name: "TestRistrettoScalarFromBytes success", input: "0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f00"},
+		{name: "TestRistrettoScalarFromBytes success order minus one", input: "ecd3f55c1a631258d69cf7a2def9de1400000000000000000000000000000010"},
+		{name: "TestRistrettoScalarFromBytes fail order", input: "edd3f55c1a631258d69cf7a2def9de1400000000000000000000000000000010", err: ErrBadRistrettoScalar},
+		{name: "TestRistrettoScalarFromBytes fail unreduced", input: "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", err: ErrBadRistrettoScalar},
+		{name: "TestRistrettoScalarFromBytes fail length", input: "0102", err: ErrBadRistrettoScalar},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := mustDecodeHex(tt.input)
+			s, err := RistrettoScalarFromBytes(input)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, RistrettoScalar(input), s)
+				decoded, err := RistrettoScalarFromBase64(s.ToBase64())
+				assert.NoError(t, err)
+				assert.True(t, decoded.Equal(s))
+			}
+		})
+	}
+}
+
+func TestNewRistrettoFromReader(t *testing.T) {
+	r1, err := NewDeterministicReader(bytes.Repeat([]byte{0x01}, CryptoRandomSeedBytes))
+	assert.NoError(t, err)
+	r2, err := NewDeterministicReader(bytes.Repeat([]byte{0x01}, CryptoRandomSeedBytes))
+	assert.NoError(t, err)
+
+	s1, err := NewRistrettoScalarFromReader(r1)
+	assert.NoError(t, err)
+	s2, err := NewRistrettoScalarFromReader(r2)
+	assert.NoError(t, err)
+	assert.True(t, s1.Equal(s2))
+	_, err = RistrettoScalarFromBytes(s1)
+	assert.NoError(t, err)
+
+	p1, err := NewRistrettoPointFromReader(r1)
+	assert.NoError(t, err)
+	p2, err := NewRistrettoPointFromReader(r2)
+	assert.NoError(t, err)
+	assert.True(t, p1.Equal(p2))
+	_, err = RistrettoPointFromBytes(p1)
+	assert.NoError(t, err)
+}
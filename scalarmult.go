@@ -0,0 +1,43 @@
+package bcl
+
+/*
+int crypto_scalarmult_base(unsigned char *q, const unsigned char *n);
+int crypto_scalarmult(unsigned char *q, const unsigned char *n, const unsigned char *p);
+*/
+import "C"
+import "fmt"
+
+// ScalarMult computes the raw X25519 shared secret between a secret key and a public key. Low
+// order public keys, for which the output would be all zeros regardless of the secret key, are
+// rejected with ErrLowOrderPublicKey. The output is not uniformly random and should be passed
+// through a key derivation function, together with both public keys, before it is used as a key
+func ScalarMult(secretKey SecretKey, publicKey PublicKey) ([]byte, error) {
+	if len(secretKey) != CryptoSecretBoxKeyBytes {
+		return nil, ErrBadSecretKeyLength
+	}
+	if len(publicKey) != CryptoBoxPublicKeyBytes {
+		return nil, ErrBadPublicKeyLength
+	}
+
+	out := make([]byte, CryptoScalarMultBytes)
+	// libsodium returns -1 when the result is all zeros, which is the only way this can fail
+	rc := C.crypto_scalarmult(ucharPtr(out), ucharPtr(secretKey), ucharPtr(publicKey))
+	if rc != 0 {
+		return nil, ErrLowOrderPublicKey
+	}
+	return out, nil
+}
+
+// ScalarMultBase computes the X25519 public key corresponding to a secret key
+func ScalarMultBase(secretKey SecretKey) (PublicKey, error) {
+	if len(secretKey) != CryptoSecretBoxKeyBytes {
+		return nil, ErrBadSecretKeyLength
+	}
+
+	out := make([]byte, CryptoScalarMultBytes) // CryptoScalarMultBytes will always equal CryptoBoxPublicKeyBytes
+	rc := C.crypto_scalarmult_base(ucharPtr(out), ucharPtr(secretKey))
+	if rc != 0 {
+		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+	}
+	return PublicKey(out), nil
+}
@@ -0,0 +1,79 @@
+package bcl
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 7748, section 6.1
+var (
+	scalarMultAliceSecret = mustDecodeHex("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a")
+	scalarMultAlicePublic = mustDecodeHex("8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a")
+	scalarMultBobSecret   = mustDecodeHex("5dab087e624a8a4b79e17f8b83800ee66f3bb1292618b6fd1c2f8b27ff88e0eb")
+	scalarMultBobPublic   = mustDecodeHex("de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f")
+	scalarMultShared      = mustDecodeHex("4a5d9d5ba4ce2de1728e3bf480350f25e07e21c947d19e3376f09b3c1e161742")
+)
+
+func TestScalarMultBase(t *testing.T) {
+	tests := []struct {
+		name      string
+		secretKey SecretKey
+		publicKey PublicKey
+		err       error
+	}{
+		{name: "TestScalarMultBase success alice", secretKey: scalarMultAliceSecret, publicKey: scalarMultAlicePublic},
+		{name: "TestScalarMultBase success bob", secretKey: scalarMultBobSecret, publicKey: scalarMultBobPublic},
+		{name: "TestScalarMultBase fail secret key length", secretKey: scalarMultAliceSecret[1:], err: ErrBadSecretKeyLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publicKey, err := ScalarMultBase(tt.secretKey)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.publicKey, publicKey)
+			}
+		})
+	}
+}
+
+func TestScalarMult(t *testing.T) {
+	lowOrder := make(PublicKey, CryptoBoxPublicKeyBytes)
+	lowOrder[0] = 0x01
+	tests := []struct {
+		name      string
+		secretKey SecretKey
+		publicKey PublicKey
+		err       error
+	}{
+		{name: "TestScalarMult success alice", secretKey: scalarMultAliceSecret, publicKey: scalarMultBobPublic},
+		{name: "TestScalarMult success bob", secretKey: scalarMultBobSecret, publicKey: scalarMultAlicePublic},
+		{name: "TestScalarMult fail zero public key", secretKey: scalarMultAliceSecret, publicKey: make(PublicKey, CryptoBoxPublicKeyBytes), err: ErrLowOrderPublicKey},
+		{name: "TestScalarMult fail low order public key", secretKey: scalarMultAliceSecret, publicKey: lowOrder, err: ErrLowOrderPublicKey},
+		{name: "TestScalarMult fail secret key length", secretKey: scalarMultAliceSecret[1:], publicKey: scalarMultBobPublic, err: ErrBadSecretKeyLength},
+		{name: "TestScalarMult fail public key length", secretKey: scalarMultAliceSecret, publicKey: scalarMultBobPublic[1:], err: ErrBadPublicKeyLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shared, err := ScalarMult(tt.secretKey, tt.publicKey)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				assert.Nil(t, shared)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, scalarMultShared, shared)
+			}
+		})
+	}
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
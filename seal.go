@@ -0,0 +1,100 @@
+package bcl
+
+// The Seal and Open functions follow the conventions of crypto/cipher.AEAD: output is appended to
+// dst and the extended slice is returned, so a caller that reuses a buffer with enough capacity
+// performs no allocations. The output formats are the same as those of SymmetricEncrypt and
+// AsymmetricEncrypt. dst must not overlap the input
+
+// sliceForAppend extends in by n bytes, reallocating only if its capacity is too small, and returns
+// the extended slice along with the n appended bytes
+func sliceForAppend(in []byte, n int) ([]byte, []byte) {
+	total := len(in) + n
+	var head []byte
+	if cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	return head, head[len(in):]
+}
+
+// SymmetricSeal appends the nonce, MAC and ciphertext of a plaintext to dst using the supplied
+// secret key. If nonce is nil, a random nonce is generated
+func SymmetricSeal(dst []byte, secretKey SecretKey, nonce Nonce, plaintext []byte) ([]byte, error) {
+	if len(secretKey) != CryptoSecretBoxKeyBytes {
+		return nil, ErrBadSecretKeyLength
+	}
+	if uint64(len(plaintext)) > CryptoSecretBoxMessageBytesMax {
+		return nil, ErrBadPlaintextLength
+	}
+	if nonce != nil && len(nonce) != CryptoSecretBoxNonceBytes {
+		return nil, ErrBadNonceLength
+	}
+
+	ret, out := sliceForAppend(dst, CryptoSecretBoxNonceBytes+CryptoSecretBoxMacBytes+len(plaintext))
+	if nonce == nil {
+		nonce = out[:CryptoSecretBoxNonceBytes]
+		if err := randomBytes(nil, nonce); err != nil {
+			return nil, err
+		}
+	}
+	if err := secretBoxSealInto(out, secretKey, nonce, plaintext); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// SymmetricOpen verifies and decrypts a ciphertext produced by SymmetricSeal or SymmetricEncrypt,
+// appending the plaintext to dst
+func SymmetricOpen(dst []byte, secretKey SecretKey, ciphertext []byte) ([]byte, error) {
+	if len(secretKey) != CryptoSecretBoxKeyBytes {
+		return nil, ErrBadSecretKeyLength
+	}
+	if len(ciphertext) < CryptoSecretBoxNonceBytes+CryptoSecretBoxMacBytes {
+		return nil, ErrBadSymmetricCiphertextLength
+	}
+
+	ret, out := sliceForAppend(dst, len(ciphertext)-CryptoSecretBoxNonceBytes-CryptoSecretBoxMacBytes)
+	if err := secretBoxOpenInto(out, secretKey, ciphertext); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// AsymmetricSeal appends a sealed box of a plaintext to dst using the supplied public key
+func AsymmetricSeal(dst []byte, publicKey PublicKey, plaintext []byte) ([]byte, error) {
+	if len(publicKey) != CryptoBoxPublicKeyBytes {
+		return nil, ErrBadPublicKeyLength
+	}
+	if uint64(len(plaintext)) > CryptoSecretBoxMessageBytesMax {
+		return nil, ErrBadPlaintextLength
+	}
+
+	ret, out := sliceForAppend(dst, CryptoBoxSealBytes+len(plaintext))
+	if err := boxSealInto(out, publicKey, plaintext); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// AsymmetricOpen decrypts a sealed box produced by AsymmetricSeal or AsymmetricEncrypt, appending
+// the plaintext to dst. Unlike AsymmetricDecrypt, it takes the public key corresponding to the
+// secret key so that it does not need to be derived on every call
+func AsymmetricOpen(dst []byte, secretKey SecretKey, publicKey PublicKey, ciphertext []byte) ([]byte, error) {
+	if len(secretKey) != CryptoSecretBoxKeyBytes {
+		return nil, ErrBadSecretKeyLength
+	}
+	if len(publicKey) != CryptoBoxPublicKeyBytes {
+		return nil, ErrBadPublicKeyLength
+	}
+	if len(ciphertext) < CryptoBoxSealBytes {
+		return nil, ErrBadCiphertextLength
+	}
+
+	ret, out := sliceForAppend(dst, len(ciphertext)-CryptoBoxSealBytes)
+	if err := boxSealOpenInto(out, publicKey, secretKey, ciphertext); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,125 @@
+package bcl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymmetricSeal(t *testing.T) {
+	sk, err := NewSecretKey()
+	assert.NoError(t, err)
+	nonce, err := NewNonce()
+	assert.NoError(t, err)
+	m := []byte("packet payload")
+
+	// the output format is the same as SymmetricEncrypt
+	c, err := SymmetricEncrypt(sk, m, nonce)
+	assert.NoError(t, err)
+	sealed, err := SymmetricSeal([]byte("prefix"), sk, nonce, m)
+	assert.NoError(t, err)
+	assert.Equal(t, "prefix", string(sealed[:6]))
+	assert.Equal(t, []byte(c), sealed[6:])
+
+	opened, err := SymmetricOpen([]byte("prefix"), sk, sealed[6:])
+	assert.NoError(t, err)
+	assert.Equal(t, "prefix"+string(m), string(opened))
+
+	random, err := SymmetricSeal(nil, sk, nil, m)
+	assert.NoError(t, err)
+	assert.NotEqual(t, sealed[6:], random)
+	opened, err = SymmetricOpen(nil, sk, random)
+	assert.NoError(t, err)
+	assert.Equal(t, m, opened)
+
+	empty, err := SymmetricSeal(nil, sk, nil, nil)
+	assert.NoError(t, err)
+	opened, err = SymmetricOpen(nil, sk, empty)
+	assert.NoError(t, err)
+	assert.Empty(t, opened)
+
+	tests := []struct {
+		name string
+		open func() error
+		err  error
+	}{
+		{name: "TestSymmetricSeal fail tampered", open: func() error {
+			tampered := append([]byte{}, random...)
+			tampered[len(tampered)-1] ^= 0x01
+			_, err := SymmetricOpen(nil, sk, tampered)
+			return err
+		}, err: ErrDecryptionFailed},
+		{name: "TestSymmetricSeal fail short", open: func() error {
+			_, err := SymmetricOpen(nil, sk, random[:CryptoSecretBoxNonceBytes])
+			return err
+		}, err: ErrBadSymmetricCiphertextLength},
+		{name: "TestSymmetricSeal fail key length", open: func() error {
+			_, err := SymmetricSeal(nil, sk[1:], nil, m)
+			return err
+		}, err: ErrBadSecretKeyLength},
+		{name: "TestSymmetricSeal fail nonce length", open: func() error {
+			_, err := SymmetricSeal(nil, sk, nonce[1:], m)
+			return err
+		}, err: ErrBadNonceLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, tt.open(), tt.err.Error())
+		})
+	}
+}
+
+func TestSymmetricSealAllocations(t *testing.T) {
+	sk, err := NewSecretKey()
+	assert.NoError(t, err)
+	nonce, err := NewNonce()
+	assert.NoError(t, err)
+	m := bytes.Repeat([]byte{0x01}, 1024)
+	sealed := make([]byte, 0, 2048)
+	opened := make([]byte, 0, 2048)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		c, err := SymmetricSeal(sealed[:0], sk, nonce, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := SymmetricOpen(opened[:0], sk, c); err != nil {
+			t.Fatal(err)
+		}
+	})
+	assert.Equal(t, float64(0), allocs)
+}
+
+func TestAsymmetricSeal(t *testing.T) {
+	sk, pk, err := NewKeyPair()
+	assert.NoError(t, err)
+	m := []byte("sealed")
+
+	sealed, err := AsymmetricSeal(nil, pk, m)
+	assert.NoError(t, err)
+	d, err := AsymmetricDecrypt(sk, sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, m, []byte(d))
+
+	c, err := AsymmetricEncrypt(pk, m)
+	assert.NoError(t, err)
+	opened, err := AsymmetricOpen([]byte("> "), sk, pk, c)
+	assert.NoError(t, err)
+	assert.Equal(t, "> sealed", string(opened))
+
+	empty, err := AsymmetricSeal(nil, pk, nil)
+	assert.NoError(t, err)
+	opened, err = AsymmetricOpen(nil, sk, pk, empty)
+	assert.NoError(t, err)
+	assert.Empty(t, opened)
+
+	_, otherPk, err := NewKeyPair()
+	assert.NoError(t, err)
+	_, err = AsymmetricOpen(nil, sk, otherPk, c)
+	assert.EqualError(t, err, ErrDecryptionFailed.Error())
+	_, err = AsymmetricOpen(nil, sk, pk, c[:CryptoBoxSealBytes-1])
+	assert.EqualError(t, err, ErrBadCiphertextLength.Error())
+	_, err = AsymmetricSeal(nil, pk[1:], m)
+	assert.EqualError(t, err, ErrBadPublicKeyLength.Error())
+}
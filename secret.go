@@ -5,9 +5,9 @@ int sodium_memcmp(const void * const b1_, const void * const b2_, size_t len);
 */
 import "C"
 import (
-	"crypto/rand"
 	"encoding/base64"
 	"hash/fnv"
+	"io"
 	"unsafe"
 )
 
@@ -15,8 +15,14 @@ type SecretKey []byte
 
 // NewSecretKey creates a new secret key
 func NewSecretKey() (SecretKey, error) {
+	return NewSecretKeyFromReader(nil)
+}
+
+// NewSecretKeyFromReader creates a new secret key using randomness read from r, or from the
+// package-level random source if r is nil
+func NewSecretKeyFromReader(r io.Reader) (SecretKey, error) {
 	s := make([]byte, CryptoSecretBoxKeyBytes)
-	if _, err := rand.Read(s); err != nil {
+	if err := randomBytes(r, s); err != nil {
 		return nil, err
 	}
 	return SecretKey(s), nil
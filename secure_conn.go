@@ -0,0 +1,327 @@
+package bcl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+)
+
+const (
+	secureConnVersion = 1
+
+	// SecureConnMaxRecordPayload is the largest plaintext carried by a single SecureConn record
+	SecureConnMaxRecordPayload = 16384
+)
+
+var secureConnLabel = []byte("bcl secure conn v1")
+
+// SecureConfig holds the static identity of one side of a SecureConn
+type SecureConfig struct {
+	// SecretKey is the static secret key that authenticates this side of the connection
+	SecretKey SecretKey
+	// VerifyPeer is called with the peer's static public key during the handshake, and the
+	// handshake fails if it returns an error. It is required, as accepting any static key would let
+	// anyone on the path complete the handshake in place of the intended peer; see AllowPeers
+	VerifyPeer func(PublicKey) error
+}
+
+// AllowPeers returns a SecureConfig.VerifyPeer function that accepts only the supplied static
+// public keys
+func AllowPeers(peers ...PublicKey) func(PublicKey) error {
+	allowed := make([]PublicKey, len(peers))
+	for i, p := range peers {
+		allowed[i] = append(PublicKey{}, p...)
+	}
+	return func(peer PublicKey) error {
+		for _, p := range allowed {
+			if p.Equal(peer) {
+				return nil
+			}
+		}
+		return ErrUnknownPeer
+	}
+}
+
+// checkSecureConfig rejects a missing config or one that does not verify the peer
+func checkSecureConfig(config *SecureConfig) error {
+	if config == nil || config.VerifyPeer == nil {
+		return ErrBadSecureConfig
+	}
+	return nil
+}
+
+// SecureConn is a net.Conn that encrypts and authenticates all traffic sent over an underlying
+// net.Conn. Both sides are authenticated with their static keys, and each connection uses fresh
+// ephemeral keys so that recorded traffic stays confidential if static keys are later compromised
+type SecureConn struct {
+	net.Conn
+
+	peer PublicKey
+
+	wmu     sync.Mutex
+	sendKey SecretKey
+	sendSeq *NonceSequence
+
+	rmu     sync.Mutex
+	recvKey SecretKey
+	recvSeq *NonceSequence
+	pending []byte
+	rerr    error
+}
+
+// SecureClient performs the initiating side of the handshake over conn and returns the resulting
+// secure connection
+func SecureClient(conn net.Conn, config *SecureConfig) (*SecureConn, error) {
+	if err := checkSecureConfig(config); err != nil {
+		return nil, err
+	}
+	staticPublic, err := NewPublicKey(config.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	ephemeralSecret, ephemeralPublic, err := NewKeyPair()
+	if err != nil {
+		return nil, err
+	}
+
+	msg1 := append([]byte{secureConnVersion}, ephemeralPublic...)
+	msg1 = append(msg1, staticPublic...)
+	if _, err := conn.Write(msg1); err != nil {
+		return nil, err
+	}
+
+	msg2 := make([]byte, 2*CryptoBoxPublicKeyBytes)
+	if _, err := io.ReadFull(conn, msg2); err != nil {
+		return nil, err
+	}
+	peerEphemeral := PublicKey(msg2[:CryptoBoxPublicKeyBytes])
+	peerStatic := PublicKey(msg2[CryptoBoxPublicKeyBytes:])
+	if err := verifyPeer(config, peerStatic); err != nil {
+		return nil, err
+	}
+
+	transcript, err := genericHash(64, nil, secureConnLabel, msg1, msg2)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newSecureConn(conn, transcript, peerStatic, true,
+		[2][]byte{ephemeralSecret, peerEphemeral},
+		[2][]byte{ephemeralSecret, peerStatic},
+		[2][]byte{config.SecretKey, peerEphemeral},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.readConfirmation(transcript); err != nil {
+		return nil, err
+	}
+	if err := s.writeRecord(transcript); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SecureServer performs the responding side of the handshake over conn and returns the resulting
+// secure connection
+func SecureServer(conn net.Conn, config *SecureConfig) (*SecureConn, error) {
+	if err := checkSecureConfig(config); err != nil {
+		return nil, err
+	}
+	staticPublic, err := NewPublicKey(config.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	msg1 := make([]byte, 1+2*CryptoBoxPublicKeyBytes)
+	if _, err := io.ReadFull(conn, msg1); err != nil {
+		return nil, err
+	}
+	if msg1[0] != secureConnVersion {
+		return nil, ErrHandshakeFailed
+	}
+	peerEphemeral := PublicKey(msg1[1 : 1+CryptoBoxPublicKeyBytes])
+	peerStatic := PublicKey(msg1[1+CryptoBoxPublicKeyBytes:])
+	if err := verifyPeer(config, peerStatic); err != nil {
+		return nil, err
+	}
+
+	ephemeralSecret, ephemeralPublic, err := NewKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	msg2 := append(append([]byte{}, ephemeralPublic...), staticPublic...)
+
+	transcript, err := genericHash(64, nil, secureConnLabel, msg1, msg2)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newSecureConn(conn, transcript, peerStatic, false,
+		[2][]byte{ephemeralSecret, peerEphemeral},
+		[2][]byte{config.SecretKey, peerEphemeral},
+		[2][]byte{ephemeralSecret, peerStatic},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Write(msg2); err != nil {
+		return nil, err
+	}
+	if err := s.writeRecord(transcript); err != nil {
+		return nil, err
+	}
+	if err := s.readConfirmation(transcript); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func verifyPeer(config *SecureConfig, peer PublicKey) error {
+	return config.VerifyPeer(append(PublicKey{}, peer...))
+}
+
+// newSecureConn derives the directional session keys from the handshake transcript and the
+// (secret key, public key) pairs for the ephemeral-ephemeral, initiator ephemeral-responder static
+// and initiator static-responder ephemeral Diffie-Hellman operations
+func newSecureConn(conn net.Conn, transcript []byte, peer PublicKey, initiator bool, dhs ...[2][]byte) (*SecureConn, error) {
+	var secrets [][]byte
+	for _, dh := range dhs {
+		secret, err := ScalarMult(dh[0], dh[1])
+		if err != nil {
+			return nil, ErrHandshakeFailed
+		}
+		secrets = append(secrets, secret)
+	}
+	master, err := genericHash(CryptoSecretBoxKeyBytes, transcript, secrets...)
+	if err != nil {
+		return nil, err
+	}
+	initiatorKey, err := genericHash(CryptoSecretBoxKeyBytes, master, []byte("initiator"))
+	if err != nil {
+		return nil, err
+	}
+	responderKey, err := genericHash(CryptoSecretBoxKeyBytes, master, []byte("responder"))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SecureConn{Conn: conn, peer: append(PublicKey{}, peer...)}
+	if initiator {
+		s.sendKey, s.recvKey = initiatorKey, responderKey
+	} else {
+		s.sendKey, s.recvKey = responderKey, initiatorKey
+	}
+	prefix := make([]byte, CryptoSecretBoxNonceBytes-NonceSequenceCounterBytes)
+	if s.sendSeq, err = NewNonceSequenceWithPrefix(prefix, nil); err != nil {
+		return nil, err
+	}
+	if s.recvSeq, err = NewNonceSequenceWithPrefix(prefix, nil); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *SecureConn) readConfirmation(transcript []byte) error {
+	confirmation, err := s.readRecord()
+	if err != nil {
+		return ErrHandshakeFailed
+	}
+	if !bytes.Equal(confirmation, transcript) {
+		return ErrHandshakeFailed
+	}
+	return nil
+}
+
+// PeerPublicKey returns the static public key of the other side of the connection
+func (s *SecureConn) PeerPublicKey() PublicKey {
+	return append(PublicKey{}, s.peer...)
+}
+
+// Read reads decrypted data from the connection
+func (s *SecureConn) Read(b []byte) (int, error) {
+	s.rmu.Lock()
+	defer s.rmu.Unlock()
+
+	for len(s.pending) == 0 {
+		if s.rerr != nil {
+			return 0, s.rerr
+		}
+		if len(b) == 0 {
+			return 0, nil
+		}
+		s.pending, s.rerr = s.readRecord()
+	}
+	n := copy(b, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+// Write encrypts data and writes it to the connection, splitting it into records of at most
+// SecureConnMaxRecordPayload bytes
+func (s *SecureConn) Write(b []byte) (int, error) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+
+	n := 0
+	for len(b) > 0 {
+		chunk := b[:min(len(b), SecureConnMaxRecordPayload)]
+		if err := s.writeRecord(chunk); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+		b = b[len(chunk):]
+	}
+	return n, nil
+}
+
+func (s *SecureConn) writeRecord(payload []byte) error {
+	nonce, err := s.sendSeq.Next()
+	if err != nil {
+		return err
+	}
+	sealed, err := SymmetricEncrypt(s.sendKey, payload, nonce)
+	if err != nil {
+		return err
+	}
+
+	record := make([]byte, 4, 4+len(sealed))
+	binary.BigEndian.PutUint32(record, uint32(len(sealed)))
+	record = append(record, sealed...)
+	_, err = s.Conn.Write(record)
+	return err
+}
+
+func (s *SecureConn) readRecord() ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(s.Conn, header); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header)
+	overhead := uint32(CryptoSecretBoxNonceBytes + CryptoSecretBoxMacBytes)
+	if size < overhead || size > overhead+SecureConnMaxRecordPayload {
+		return nil, ErrBadRecord
+	}
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(s.Conn, sealed); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	expected, err := s.recvSeq.Next()
+	if err != nil {
+		return nil, err
+	}
+	if expected.NotEqual(Nonce(sealed[:CryptoSecretBoxNonceBytes])) {
+		return nil, ErrBadRecord
+	}
+	payload, err := SymmetricDecrypt(s.recvKey, sealed)
+	if err != nil {
+		return nil, ErrBadRecord
+	}
+	return payload, nil
+}
@@ -0,0 +1,232 @@
+package bcl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func secureConnPair(t *testing.T, clientConfig, serverConfig *SecureConfig) (*SecureConn, *SecureConn, error, error) {
+	c, s := net.Pipe()
+	t.Cleanup(func() {
+		c.Close()
+		s.Close()
+	})
+
+	type result struct {
+		conn *SecureConn
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := SecureServer(s, serverConfig)
+		if err != nil {
+			s.Close()
+		}
+		done <- result{conn, err}
+	}()
+	client, clientErr := SecureClient(c, clientConfig)
+	if clientErr != nil {
+		c.Close()
+	}
+	server := <-done
+	return client, server.conn, clientErr, server.err
+}
+
+func TestSecureConnHandshake(t *testing.T) {
+	clientSecret, clientPublic, err := NewKeyPair()
+	assert.NoError(t, err)
+	serverSecret, serverPublic, err := NewKeyPair()
+	assert.NoError(t, err)
+	_, otherPublic, err := NewKeyPair()
+	assert.NoError(t, err)
+	errUnknownPeer := fmt.Errorf("unknown peer")
+
+	tests := []struct {
+		name         string
+		clientConfig *SecureConfig
+		serverConfig *SecureConfig
+		clientErr    bool
+		serverErr    bool
+	}{
+		{
+			name:         "TestSecureConnHandshake success",
+			clientConfig: &SecureConfig{SecretKey: clientSecret, VerifyPeer: AllowPeers(serverPublic)},
+			serverConfig: &SecureConfig{SecretKey: serverSecret, VerifyPeer: AllowPeers(otherPublic, clientPublic)},
+		},
+		{
+			name: "TestSecureConnHandshake success custom verify peer",
+			clientConfig: &SecureConfig{
+				SecretKey: clientSecret,
+				VerifyPeer: func(p PublicKey) error {
+					if p.NotEqual(serverPublic) {
+						return errUnknownPeer
+					}
+					return nil
+				},
+			},
+			serverConfig: &SecureConfig{SecretKey: serverSecret, VerifyPeer: AllowPeers(clientPublic)},
+		},
+		{
+			name:         "TestSecureConnHandshake fail rejected by server",
+			clientConfig: &SecureConfig{SecretKey: clientSecret, VerifyPeer: AllowPeers(serverPublic)},
+			serverConfig: &SecureConfig{
+				SecretKey: serverSecret,
+				VerifyPeer: func(p PublicKey) error {
+					return errUnknownPeer
+				},
+			},
+			clientErr: true,
+			serverErr: true,
+		},
+		{
+			name:         "TestSecureConnHandshake fail unknown server",
+			clientConfig: &SecureConfig{SecretKey: clientSecret, VerifyPeer: AllowPeers(otherPublic)},
+			serverConfig: &SecureConfig{SecretKey: serverSecret, VerifyPeer: AllowPeers(clientPublic)},
+			clientErr:    true,
+			serverErr:    true,
+		},
+		{
+			name:         "TestSecureConnHandshake fail bad secret key",
+			clientConfig: &SecureConfig{SecretKey: SecretKey{0x01}, VerifyPeer: AllowPeers(serverPublic)},
+			serverConfig: &SecureConfig{SecretKey: serverSecret, VerifyPeer: AllowPeers(clientPublic)},
+			clientErr:    true,
+			serverErr:    true,
+		},
+		{
+			name:         "TestSecureConnHandshake fail client without verify peer",
+			clientConfig: &SecureConfig{SecretKey: clientSecret},
+			serverConfig: &SecureConfig{SecretKey: serverSecret, VerifyPeer: AllowPeers(clientPublic)},
+			clientErr:    true,
+			serverErr:    true,
+		},
+		{
+			name:         "TestSecureConnHandshake fail server without verify peer",
+			clientConfig: &SecureConfig{SecretKey: clientSecret, VerifyPeer: AllowPeers(serverPublic)},
+			serverConfig: &SecureConfig{SecretKey: serverSecret},
+			clientErr:    true,
+			serverErr:    true,
+		},
+		{
+			name:         "TestSecureConnHandshake fail nil config",
+			clientConfig: nil,
+			serverConfig: &SecureConfig{SecretKey: serverSecret, VerifyPeer: AllowPeers(clientPublic)},
+			clientErr:    true,
+			serverErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server, clientErr, serverErr := secureConnPair(t, tt.clientConfig, tt.serverConfig)
+			assert.Equal(t, tt.clientErr, clientErr != nil)
+			assert.Equal(t, tt.serverErr, serverErr != nil)
+			if !tt.clientErr && !tt.serverErr {
+				assert.Equal(t, serverPublic, client.PeerPublicKey())
+				assert.Equal(t, clientPublic, server.PeerPublicKey())
+			}
+		})
+	}
+}
+
+func TestSecureConnConfig(t *testing.T) {
+	secretKey, err := NewSecretKey()
+	assert.NoError(t, err)
+	for _, config := range []*SecureConfig{nil, {SecretKey: secretKey}} {
+		_, err = SecureClient(nil, config)
+		assert.EqualError(t, err, ErrBadSecureConfig.Error())
+		_, err = SecureServer(nil, config)
+		assert.EqualError(t, err, ErrBadSecureConfig.Error())
+	}
+
+	_, peer, err := NewKeyPair()
+	assert.NoError(t, err)
+	_, other, err := NewKeyPair()
+	assert.NoError(t, err)
+	verify := AllowPeers(peer)
+	assert.NoError(t, verify(peer))
+	assert.EqualError(t, verify(other), ErrUnknownPeer.Error())
+	assert.EqualError(t, AllowPeers()(peer), ErrUnknownPeer.Error())
+}
+
+func TestSecureConnReadWrite(t *testing.T) {
+	clientSecret, clientPublic, err := NewKeyPair()
+	assert.NoError(t, err)
+	serverSecret, serverPublic, err := NewKeyPair()
+	assert.NoError(t, err)
+	client, server, clientErr, serverErr := secureConnPair(t,
+		&SecureConfig{SecretKey: clientSecret, VerifyPeer: AllowPeers(serverPublic)},
+		&SecureConfig{SecretKey: serverSecret, VerifyPeer: AllowPeers(clientPublic)},
+	)
+	assert.NoError(t, clientErr)
+	assert.NoError(t, serverErr)
+
+	msg := bytes.Repeat([]byte("bcl"), SecureConnMaxRecordPayload)
+	go func() {
+		client.Write(msg)
+		client.Close()
+	}()
+	got, err := io.ReadAll(server)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, got)
+}
+
+func TestSecureConnTamper(t *testing.T) {
+	clientSecret, clientPublic, err := NewKeyPair()
+	assert.NoError(t, err)
+	serverSecret, serverPublic, err := NewKeyPair()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		record func(client *SecureConn) []byte
+	}{
+		{
+			name: "TestSecureConnTamper fail forged record",
+			record: func(client *SecureConn) []byte {
+				sk, err := NewSecretKey()
+				assert.NoError(t, err)
+				n, err := client.sendSeq.Next()
+				assert.NoError(t, err)
+				c, err := SymmetricEncrypt(sk, Plaintext("hello"), n)
+				assert.NoError(t, err)
+				return append([]byte{0x00, 0x00, 0x00, byte(len(c))}, c...)
+			},
+		},
+		{
+			name: "TestSecureConnTamper fail out of order",
+			record: func(client *SecureConn) []byte {
+				_, err := client.sendSeq.Next()
+				assert.NoError(t, err)
+				n, err := client.sendSeq.Next()
+				assert.NoError(t, err)
+				c, err := SymmetricEncrypt(client.sendKey, Plaintext("hello"), n)
+				assert.NoError(t, err)
+				return append([]byte{0x00, 0x00, 0x00, byte(len(c))}, c...)
+			},
+		},
+		{
+			name: "TestSecureConnTamper fail record length",
+			record: func(client *SecureConn) []byte {
+				return []byte{0xff, 0xff, 0xff, 0xff}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server, clientErr, serverErr := secureConnPair(t,
+				&SecureConfig{SecretKey: clientSecret, VerifyPeer: AllowPeers(serverPublic)},
+				&SecureConfig{SecretKey: serverSecret, VerifyPeer: AllowPeers(clientPublic)},
+			)
+			assert.NoError(t, clientErr)
+			assert.NoError(t, serverErr)
+
+			go client.Conn.Write(tt.record(client))
+			_, err := server.Read(make([]byte, 16))
+			assert.EqualError(t, err, ErrBadRecord.Error())
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package bcl
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// Secret keys are split with Shamir's scheme over GF(256), independently for every byte of the
+// key: each byte is the constant term of a random polynomial of degree k-1, and share i holds the
+// values of all polynomials at x = i. Any k shares recover the key and fewer reveal nothing about
+// it. Field arithmetic avoids tables and branches so that its timing does not depend on secrets.
+//
+// Every share records the threshold, its index and a random set ID that lets Combine reject shares
+// from different splits, and ends with a checksum that catches transcription errors in a single
+// share. A short digest of the key and set ID, keyed with the key, is split along with the key in
+// the style of SLIP-39, so that Combine can detect a wrong result while fewer than k shares still
+// reveal nothing about the key
+
+const (
+	shareVersion       = 0x01
+	shareSetIDBytes    = 4
+	shareDigestBytes   = 4
+	shareChecksumBytes = 4
+	shareHeaderBytes   = 3 + shareSetIDBytes
+)
+
+// ShareBytes is the length of an encoded share
+var ShareBytes = shareHeaderBytes + CryptoSecretBoxKeyBytes + shareDigestBytes + shareChecksumBytes
+
+// ShareMnemonicWords is the number of words in the mnemonic encoding of a share
+var ShareMnemonicWords = (ShareBytes*8 + 10) / 11
+
+// Share is one of the shares of a secret key produced by Split
+type Share struct {
+	// Index is the x coordinate of the share, from 1 to 255
+	Index byte
+	// Threshold is the number of shares needed to recover the key
+	Threshold byte
+	// SetID is chosen at random for each split and is the same for all of its shares
+	SetID [shareSetIDBytes]byte
+	// Value holds one byte per byte of the key and its digest
+	Value []byte
+}
+
+// gfMul multiplies two elements of GF(256) modulo the AES polynomial x^8 + x^4 + x^3 + x + 1
+func gfMul(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		p ^= -(b & 1) & a
+		a = a<<1 ^ -(a>>7)&0x1b
+		b >>= 1
+	}
+	return p
+}
+
+// gfInv returns the multiplicative inverse of a non-zero element of GF(256) as a^254
+func gfInv(a byte) byte {
+	result, power := byte(1), a
+	for e := 254; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			result = gfMul(result, power)
+		}
+		power = gfMul(power, power)
+	}
+	return result
+}
+
+// shareDigest computes the digest of a secret key that is split along with it, keyed with the key
+// and bound to the set ID
+func shareDigest(secretKey SecretKey, setID [shareSetIDBytes]byte) ([]byte, error) {
+	return genericHash(shareDigestBytes, secretKey, []byte("bcl shamir digest"), setID[:])
+}
+
+// Split splits a secret key into n shares, any k of which recover it with Combine. It requires
+// 2 <= k <= n <= 255
+func Split(secretKey SecretKey, n, k int) ([]Share, error) {
+	if len(secretKey) != CryptoSecretBoxKeyBytes {
+		return nil, ErrBadSecretKeyLength
+	}
+	if k < 2 || k > n || n > 255 {
+		return nil, ErrBadShamirParams
+	}
+	var setID [shareSetIDBytes]byte
+	if err := randomBytes(nil, setID[:]); err != nil {
+		return nil, err
+	}
+	digest, err := shareDigest(secretKey, setID)
+	if err != nil {
+		return nil, err
+	}
+	secret := append(append([]byte{}, secretKey...), digest...)
+	defer clear(secret)
+
+	// coefficients[j*(k-1)+c] is the coefficient of x^(c+1) in the polynomial for secret byte j
+	coefficients := make([]byte, len(secret)*(k-1))
+	if err := randomBytes(nil, coefficients); err != nil {
+		return nil, err
+	}
+	defer clear(coefficients)
+
+	shares := make([]Share, n)
+	for i := range shares {
+		x := byte(i + 1)
+		value := make([]byte, len(secret))
+		for j := range value {
+			// Horner's rule, from the highest coefficient down to the secret byte
+			var y byte
+			for c := k - 2; c >= 0; c-- {
+				y = gfMul(y, x) ^ coefficients[j*(k-1)+c]
+			}
+			value[j] = gfMul(y, x) ^ secret[j]
+		}
+		shares[i] = Share{Index: x, Threshold: byte(k), SetID: setID, Value: value}
+	}
+	return shares, nil
+}
+
+// interpolate evaluates at x the polynomials passing through the supplied shares
+func interpolate(shares []Share, x byte) []byte {
+	out := make([]byte, len(shares[0].Value))
+	for i, si := range shares {
+		// Lagrange basis polynomial of share i at x; subtraction in GF(256) is XOR
+		basis := byte(1)
+		for m, sm := range shares {
+			if m != i {
+				basis = gfMul(basis, gfMul(x^sm.Index, gfInv(si.Index^sm.Index)))
+			}
+		}
+		for j := range out {
+			out[j] ^= gfMul(basis, si.Value[j])
+		}
+	}
+	return out
+}
+
+// Combine recovers a secret key from at least the threshold number of its shares. The key is
+// recovered from the first threshold shares, and any further shares must be consistent with it
+func Combine(shares []Share) (SecretKey, error) {
+	if len(shares) == 0 {
+		return nil, ErrNotEnoughShares
+	}
+	first := shares[0]
+	seen := make(map[byte]bool, len(shares))
+	for _, s := range shares {
+		if s.Index == 0 || s.Threshold < 2 || len(s.Value) != CryptoSecretBoxKeyBytes+shareDigestBytes {
+			return nil, ErrBadShare
+		}
+		if s.Threshold != first.Threshold || s.SetID != first.SetID {
+			return nil, ErrShareMismatch
+		}
+		if seen[s.Index] {
+			return nil, ErrDuplicateShare
+		}
+		seen[s.Index] = true
+	}
+	k := int(first.Threshold)
+	if len(shares) < k {
+		return nil, ErrNotEnoughShares
+	}
+
+	secret := interpolate(shares[:k], 0)
+	defer clear(secret)
+	secretKey := SecretKey(append([]byte{}, secret[:CryptoSecretBoxKeyBytes]...))
+	digest, err := shareDigest(secretKey, first.SetID)
+	if err != nil {
+		return nil, err
+	}
+	if !constantTimeEqual(digest, secret[CryptoSecretBoxKeyBytes:]) {
+		clear(secretKey)
+		return nil, ErrShareMismatch
+	}
+	for _, s := range shares[k:] {
+		if !constantTimeEqual(interpolate(shares[:k], s.Index), s.Value) {
+			return nil, ErrShareMismatch
+		}
+	}
+	return secretKey, nil
+}
+
+// Bytes encodes a share as its version, threshold, index, set ID, value and checksum
+func (s Share) Bytes() []byte {
+	out := []byte{shareVersion, s.Threshold, s.Index}
+	out = append(append(out, s.SetID[:]...), s.Value...)
+	return append(out, shareChecksum(out)...)
+}
+
+func shareChecksum(b []byte) []byte {
+	// an unkeyed BLAKE2b hash of a valid size cannot fail
+	h, _ := genericHash(shareChecksumBytes, nil, b)
+	return h
+}
+
+// ShareFromBytes decodes a share produced by Share.Bytes, verifying its checksum
+func ShareFromBytes(b []byte) (Share, error) {
+	if len(b) != ShareBytes || b[0] != shareVersion {
+		return Share{}, ErrBadShare
+	}
+	body := b[:len(b)-shareChecksumBytes]
+	if !constantTimeEqual(shareChecksum(body), b[len(body):]) {
+		return Share{}, ErrBadShareChecksum
+	}
+	s := Share{
+		Threshold: b[1],
+		Index:     b[2],
+		Value:     append([]byte{}, body[shareHeaderBytes:]...),
+	}
+	copy(s.SetID[:], b[3:shareHeaderBytes])
+	if s.Index == 0 || s.Threshold < 2 {
+		return Share{}, ErrBadShare
+	}
+	return s, nil
+}
+
+// ToBase64 encodes a share as a base64 string
+func (s Share) ToBase64() string {
+	return base64.StdEncoding.EncodeToString(s.Bytes())
+}
+
+// ShareFromBase64 decodes a share from a base64 string
+func ShareFromBase64(arg string) (Share, error) {
+	b, err := base64.StdEncoding.DecodeString(arg)
+	if err != nil {
+		return Share{}, err
+	}
+	return ShareFromBytes(b)
+}
+
+// ToMnemonic encodes a share as ShareMnemonicWords words from the BIP-39 English word list, for
+// writing down on paper
+func (s Share) ToMnemonic() string {
+	return strings.Join(bytesToWords(s.Bytes(), ShareMnemonicWords), " ")
+}
+
+// ShareFromMnemonic decodes a share produced by Share.ToMnemonic. Words may be separated by any
+// whitespace and are matched case-insensitively
+func ShareFromMnemonic(mnemonic string) (Share, error) {
+	b, err := wordsToBytes(mnemonic, ShareMnemonicWords, ShareBytes)
+	if err != nil {
+		return Share{}, err
+	}
+	return ShareFromBytes(b)
+}
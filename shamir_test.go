@@ -0,0 +1,170 @@
+package bcl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGFArithmetic(t *testing.T) {
+	// FIPS 197, section 4.2
+	assert.Equal(t, byte(0xc1), gfMul(0x57, 0x83))
+	assert.Equal(t, byte(0xfe), gfMul(0x57, 0x13))
+	// FIPS 197, section 5.1.1: {53} and {ca} are inverses
+	assert.Equal(t, byte(0xca), gfInv(0x53))
+	for a := 1; a < 256; a++ {
+		assert.Equal(t, byte(1), gfMul(byte(a), gfInv(byte(a))))
+	}
+}
+
+func TestSplitCombine(t *testing.T) {
+	secretKey, err := NewSecretKey()
+	assert.NoError(t, err)
+	tests := []struct {
+		name string
+		n, k int
+	}{
+		{name: "TestSplitCombine success 2 of 2", n: 2, k: 2},
+		{name: "TestSplitCombine success 3 of 5", n: 5, k: 3},
+		{name: "TestSplitCombine success 5 of 5", n: 5, k: 5},
+		{name: "TestSplitCombine success 2 of 255", n: 255, k: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares, err := Split(secretKey, tt.n, tt.k)
+			assert.NoError(t, err)
+			assert.Len(t, shares, tt.n)
+
+			// every window of k consecutive shares, and all shares together, recover the key
+			for i := 0; i+tt.k <= tt.n; i++ {
+				combined, err := Combine(shares[i : i+tt.k])
+				assert.NoError(t, err)
+				assert.True(t, secretKey.Equal(combined))
+			}
+			reversed := make([]Share, tt.n)
+			for i := range shares {
+				reversed[tt.n-1-i] = shares[i]
+			}
+			combined, err := Combine(reversed)
+			assert.NoError(t, err)
+			assert.True(t, secretKey.Equal(combined))
+
+			_, err = Combine(shares[:tt.k-1])
+			assert.EqualError(t, err, ErrNotEnoughShares.Error())
+		})
+	}
+}
+
+func TestSplitFail(t *testing.T) {
+	secretKey, err := NewSecretKey()
+	assert.NoError(t, err)
+	tests := []struct {
+		name      string
+		secretKey SecretKey
+		n, k      int
+		err       error
+	}{
+		{name: "TestSplit fail threshold 1", secretKey: secretKey, n: 3, k: 1, err: ErrBadShamirParams},
+		{name: "TestSplit fail threshold above n", secretKey: secretKey, n: 3, k: 4, err: ErrBadShamirParams},
+		{name: "TestSplit fail too many shares", secretKey: secretKey, n: 256, k: 2, err: ErrBadShamirParams},
+		{name: "TestSplit fail secret key length", secretKey: secretKey[1:], n: 3, k: 2, err: ErrBadSecretKeyLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Split(tt.secretKey, tt.n, tt.k)
+			assert.EqualError(t, err, tt.err.Error())
+		})
+	}
+}
+
+func TestCombineFail(t *testing.T) {
+	secretKey, err := NewSecretKey()
+	assert.NoError(t, err)
+	shares, err := Split(secretKey, 5, 3)
+	assert.NoError(t, err)
+	otherShares, err := Split(secretKey, 5, 3)
+	assert.NoError(t, err)
+	// set IDs are random, so two splits of the same key are told apart before interpolation
+	assert.NotEqual(t, shares[0].SetID, otherShares[0].SetID)
+	otherKey, err := NewSecretKey()
+	assert.NoError(t, err)
+	otherKeyShares, err := Split(otherKey, 5, 3)
+	assert.NoError(t, err)
+
+	corrupted := shares[2]
+	corrupted.Value = append([]byte{}, corrupted.Value...)
+	corrupted.Value[0] ^= 0x01
+	// a share from another key relabelled with this split's set ID is caught by the digest
+	forgedSetID := otherKeyShares[2]
+	forgedSetID.SetID = shares[0].SetID
+
+	tests := []struct {
+		name   string
+		shares []Share
+		err    error
+	}{
+		{name: "TestCombine fail no shares", shares: nil, err: ErrNotEnoughShares},
+		{name: "TestCombine fail duplicate", shares: []Share{shares[0], shares[1], shares[0]}, err: ErrDuplicateShare},
+		{name: "TestCombine fail different key", shares: []Share{shares[0], shares[1], otherKeyShares[2]}, err: ErrShareMismatch},
+		{name: "TestCombine fail different split", shares: []Share{shares[0], shares[1], otherShares[2]}, err: ErrShareMismatch},
+		{name: "TestCombine fail corrupted value", shares: []Share{shares[0], shares[1], corrupted}, err: ErrShareMismatch},
+		{name: "TestCombine fail forged set ID", shares: []Share{shares[0], shares[1], forgedSetID}, err: ErrShareMismatch},
+		{name: "TestCombine fail corrupted extra share", shares: []Share{shares[0], shares[1], shares[3], corrupted}, err: ErrShareMismatch},
+		{name: "TestCombine fail zero index", shares: []Share{{Threshold: 3, Value: shares[0].Value}}, err: ErrBadShare},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Combine(tt.shares)
+			assert.EqualError(t, err, tt.err.Error())
+		})
+	}
+}
+
+func TestShareEncoding(t *testing.T) {
+	secretKey, err := NewSecretKey()
+	assert.NoError(t, err)
+	shares, err := Split(secretKey, 3, 2)
+	assert.NoError(t, err)
+
+	fromBase64, err := ShareFromBase64(shares[0].ToBase64())
+	assert.NoError(t, err)
+	assert.Equal(t, shares[0], fromBase64)
+
+	mnemonic := shares[1].ToMnemonic()
+	assert.Len(t, strings.Fields(mnemonic), ShareMnemonicWords)
+	fromMnemonic, err := ShareFromMnemonic("  " + strings.ToUpper(mnemonic) + "\n")
+	assert.NoError(t, err)
+	assert.Equal(t, shares[1], fromMnemonic)
+
+	combined, err := Combine([]Share{fromBase64, fromMnemonic})
+	assert.NoError(t, err)
+	assert.True(t, secretKey.Equal(combined))
+
+	b := shares[2].Bytes()
+	assert.Len(t, b, ShareBytes)
+	for i := range b {
+		corrupted := append([]byte{}, b...)
+		corrupted[i] ^= 0x04
+		_, err := ShareFromBytes(corrupted)
+		assert.Error(t, err)
+	}
+	corrupted := append([]byte{}, b...)
+	corrupted[10] ^= 0x01
+	_, err = ShareFromBytes(corrupted)
+	assert.EqualError(t, err, ErrBadShareChecksum.Error())
+	_, err = ShareFromBytes(b[1:])
+	assert.EqualError(t, err, ErrBadShare.Error())
+
+	// swapping two words is caught by the checksum
+	words := strings.Fields(mnemonic)
+	words[3], words[4] = words[4], words[3]
+	if words[3] != words[4] {
+		_, err = ShareFromMnemonic(strings.Join(words, " "))
+		assert.EqualError(t, err, ErrBadShareChecksum.Error())
+	}
+	_, err = ShareFromMnemonic(strings.Join(words[1:], " "))
+	assert.EqualError(t, err, ErrBadMnemonic.Error())
+	_, err = ShareFromMnemonic(strings.Replace(mnemonic, strings.Fields(mnemonic)[0], "notaword", 1))
+	assert.EqualError(t, err, ErrBadMnemonic.Error())
+}
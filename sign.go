@@ -0,0 +1,168 @@
+package bcl
+
+/*
+int crypto_sign_seed_keypair(unsigned char *pk, unsigned char *sk, const unsigned char *seed);
+int crypto_sign_detached(unsigned char *sig, unsigned long long *siglen_p, const unsigned char *m, unsigned long long mlen, const unsigned char *sk);
+int crypto_sign_verify_detached(const unsigned char *sig, const unsigned char *m, unsigned long long mlen, const unsigned char *pk);
+int crypto_sign_ed25519_pk_to_curve25519(unsigned char *curve25519_pk, const unsigned char *ed25519_pk);
+int crypto_sign_ed25519_sk_to_curve25519(unsigned char *curve25519_sk, const unsigned char *ed25519_sk);
+*/
+import "C"
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// SigningKey is an Ed25519 secret key in libsodium's format, which is the 32 byte seed followed by
+// the corresponding verify key
+type SigningKey []byte
+
+// VerifyKey is an Ed25519 public key
+type VerifyKey []byte
+
+// NewSigningKeyPair returns a (signing key, verify key) keypair for use in signing and verification
+func NewSigningKeyPair() (SigningKey, VerifyKey, error) {
+	return NewSigningKeyPairFromReader(nil)
+}
+
+// NewSigningKeyPairFromReader returns a (signing key, verify key) keypair using randomness read from
+// r, or from the package-level random source if r is nil
+func NewSigningKeyPairFromReader(r io.Reader) (SigningKey, VerifyKey, error) {
+	seed := make([]byte, CryptoSignSeedBytes)
+	if err := randomBytes(r, seed); err != nil {
+		return nil, nil, err
+	}
+	return NewSigningKeyPairFromSeed(seed)
+}
+
+// NewSigningKeyPairFromSeed deterministically derives a (signing key, verify key) keypair from a
+// seed of length CryptoSignSeedBytes
+func NewSigningKeyPairFromSeed(seed []byte) (SigningKey, VerifyKey, error) {
+	if len(seed) != CryptoSignSeedBytes {
+		return nil, nil, ErrBadSeedLength
+	}
+	vk := make([]byte, CryptoSignPublicKeyBytes)
+	sk := make([]byte, CryptoSignSecretKeyBytes)
+	rc := C.crypto_sign_seed_keypair(ucharPtr(vk), ucharPtr(sk), ucharPtr(seed))
+	if rc != 0 {
+		return nil, nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+	}
+	return SigningKey(sk), VerifyKey(vk), nil
+}
+
+// SigningKeyFromBytes casts a signing key from a byte slice of length CryptoSignSecretKeyBytes
+func SigningKeyFromBytes(arg []byte) (SigningKey, error) {
+	if len(arg) != CryptoSignSecretKeyBytes {
+		return nil, ErrBadSigningKeyLength
+	}
+	return SigningKey(arg), nil
+}
+
+// SigningKeyFromBase64 casts a signing key from a base64 encoded string
+func SigningKeyFromBase64(arg string) (SigningKey, error) {
+	b, err := base64.StdEncoding.DecodeString(arg)
+	if err != nil {
+		return nil, err
+	}
+	return SigningKeyFromBytes(b)
+}
+
+// ToBase64 converts a signing key to a base64 encoded string
+func (s SigningKey) ToBase64() string {
+	return base64.StdEncoding.EncodeToString(s)
+}
+
+// Seed returns the 32 byte seed from which a signing key was derived
+func (s SigningKey) Seed() ([]byte, error) {
+	if len(s) != CryptoSignSecretKeyBytes {
+		return nil, ErrBadSigningKeyLength
+	}
+	return append([]byte{}, s[:CryptoSignSeedBytes]...), nil
+}
+
+// VerifyKey returns the verify key corresponding to a signing key
+func (s SigningKey) VerifyKey() (VerifyKey, error) {
+	if len(s) != CryptoSignSecretKeyBytes {
+		return nil, ErrBadSigningKeyLength
+	}
+	return append(VerifyKey{}, s[CryptoSignSeedBytes:]...), nil
+}
+
+// VerifyKeyFromBytes casts a verify key from a byte slice of length CryptoSignPublicKeyBytes
+func VerifyKeyFromBytes(arg []byte) (VerifyKey, error) {
+	if len(arg) != CryptoSignPublicKeyBytes {
+		return nil, ErrBadVerifyKeyLength
+	}
+	return VerifyKey(arg), nil
+}
+
+// VerifyKeyFromBase64 casts a verify key from a base64 encoded string
+func VerifyKeyFromBase64(arg string) (VerifyKey, error) {
+	b, err := base64.StdEncoding.DecodeString(arg)
+	if err != nil {
+		return nil, err
+	}
+	return VerifyKeyFromBytes(b)
+}
+
+// ToBase64 converts a verify key to a base64 encoded string
+func (v VerifyKey) ToBase64() string {
+	return base64.StdEncoding.EncodeToString(v)
+}
+
+// Sign returns a detached Ed25519 signature of a message using the supplied signing key
+func Sign(signingKey SigningKey, message []byte) ([]byte, error) {
+	if len(signingKey) != CryptoSignSecretKeyBytes {
+		return nil, ErrBadSigningKeyLength
+	}
+	sig := make([]byte, CryptoSignBytes)
+	rc := C.crypto_sign_detached(ucharPtr(sig), nil, ucharPtr(message), C.ulonglong(len(message)), ucharPtr(signingKey))
+	if rc != 0 {
+		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+	}
+	return sig, nil
+}
+
+// Verify checks a detached Ed25519 signature of a message using the supplied verify key
+func Verify(verifyKey VerifyKey, message, signature []byte) error {
+	if len(verifyKey) != CryptoSignPublicKeyBytes {
+		return ErrBadVerifyKeyLength
+	}
+	if len(signature) != CryptoSignBytes {
+		return ErrBadSignature
+	}
+	rc := C.crypto_sign_verify_detached(ucharPtr(signature), ucharPtr(message), C.ulonglong(len(message)), ucharPtr(verifyKey))
+	if rc != 0 {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// ToPublicKey converts a verify key to the X25519 public key of the same identity, for use with
+// AsymmetricEncrypt
+func (v VerifyKey) ToPublicKey() (PublicKey, error) {
+	if len(v) != CryptoSignPublicKeyBytes {
+		return nil, ErrBadVerifyKeyLength
+	}
+	out := make([]byte, CryptoBoxPublicKeyBytes)
+	rc := C.crypto_sign_ed25519_pk_to_curve25519(ucharPtr(out), ucharPtr(v))
+	if rc != 0 {
+		return nil, ErrBadVerifyKey
+	}
+	return PublicKey(out), nil
+}
+
+// ToSecretKey converts a signing key to the X25519 secret key of the same identity, for use with
+// AsymmetricDecrypt
+func (s SigningKey) ToSecretKey() (SecretKey, error) {
+	if len(s) != CryptoSignSecretKeyBytes {
+		return nil, ErrBadSigningKeyLength
+	}
+	out := make([]byte, CryptoSecretBoxKeyBytes)
+	rc := C.crypto_sign_ed25519_sk_to_curve25519(ucharPtr(out), ucharPtr(s))
+	if rc != 0 {
+		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+	}
+	return SecretKey(out), nil
+}
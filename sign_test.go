@@ -0,0 +1,119 @@
+package bcl
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	// RFC 8032 section 7.1, test 2
+	seed, _ := hex.DecodeString("4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb")
+	vector, _ := hex.DecodeString("3d4017c3e843895a92b70aa74d1b7ebc9c982ccf2ec4968cc0cd55f12af4660c")
+	message := []byte{0x72}
+	signature, _ := hex.DecodeString("92a009a9f0d4cab8720e820b5f642540a2b27b5416503f8fb3762223ebdb69da085ac1e43e15996e458f3613d0f11d8c387b2eaeb4302aeeb00d291612bb0c00")
+
+	sk, vk, err := NewSigningKeyPairFromSeed(seed)
+	assert.NoError(t, err)
+	assert.Equal(t, VerifyKey(vector), vk)
+	derivedKey, err := sk.VerifyKey()
+	assert.NoError(t, err)
+	assert.Equal(t, vk, derivedKey)
+	derivedSeed, err := sk.Seed()
+	assert.NoError(t, err)
+	assert.Equal(t, seed, derivedSeed)
+
+	sig, err := Sign(sk, message)
+	assert.NoError(t, err)
+	assert.Equal(t, signature, sig)
+
+	tests := []struct {
+		name      string
+		verifyKey VerifyKey
+		message   []byte
+		signature []byte
+		err       error
+	}{
+		{name: "TestSign success", verifyKey: vk, message: message, signature: signature, err: nil},
+		{name: "TestSign fail message", verifyKey: vk, message: []byte{0x73}, signature: signature, err: ErrBadSignature},
+		{name: "TestSign fail signature length", verifyKey: vk, message: message, signature: signature[1:], err: ErrBadSignature},
+		{name: "TestSign fail verify key length", verifyKey: vk[1:], message: message, signature: signature, err: ErrBadVerifyKeyLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.verifyKey, tt.message, tt.signature)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewSigningKeyPair(t *testing.T) {
+	sk, vk, err := NewSigningKeyPair()
+	assert.NoError(t, err)
+	assert.Equal(t, CryptoSignSecretKeyBytes, len(sk))
+	assert.Equal(t, CryptoSignPublicKeyBytes, len(vk))
+
+	sig, err := Sign(sk, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, Verify(vk, nil, sig))
+
+	sk2, err := SigningKeyFromBase64(sk.ToBase64())
+	assert.NoError(t, err)
+	assert.Equal(t, sk, sk2)
+	vk2, err := VerifyKeyFromBase64(vk.ToBase64())
+	assert.NoError(t, err)
+	assert.Equal(t, vk, vk2)
+
+	_, err = Sign(sk[1:], nil)
+	assert.EqualError(t, err, ErrBadSigningKeyLength.Error())
+	_, err = sk[1:].Seed()
+	assert.EqualError(t, err, ErrBadSigningKeyLength.Error())
+	_, err = sk[:CryptoSignSeedBytes].VerifyKey()
+	assert.EqualError(t, err, ErrBadSigningKeyLength.Error())
+	_, _, err = NewSigningKeyPairFromSeed(sk[:CryptoSignSeedBytes-1])
+	assert.EqualError(t, err, ErrBadSeedLength.Error())
+}
+
+func TestSigningKeyToX25519(t *testing.T) {
+	signingKey, verifyKey, err := NewSigningKeyPair()
+	assert.NoError(t, err)
+
+	sk, err := signingKey.ToSecretKey()
+	assert.NoError(t, err)
+	pk, err := verifyKey.ToPublicKey()
+	assert.NoError(t, err)
+	derived, err := NewPublicKey(sk)
+	assert.NoError(t, err)
+	assert.Equal(t, derived, pk)
+
+	m, err := PlaintextFromString("to an Ed25519 identity")
+	assert.NoError(t, err)
+	c, err := AsymmetricEncrypt(pk, m)
+	assert.NoError(t, err)
+	d, err := AsymmetricDecrypt(sk, c)
+	assert.NoError(t, err)
+	assert.Equal(t, m, d)
+
+	tests := []struct {
+		name      string
+		verifyKey VerifyKey
+		err       error
+	}{
+		{name: "TestSigningKeyToX25519 fail small order", verifyKey: make([]byte, CryptoSignPublicKeyBytes), err: ErrBadVerifyKey},
+		{name: "TestSigningKeyToX25519 fail length", verifyKey: verifyKey[1:], err: ErrBadVerifyKeyLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.verifyKey.ToPublicKey()
+			assert.EqualError(t, err, tt.err.Error())
+		})
+	}
+
+	_, err = signingKey[1:].ToSecretKey()
+	assert.EqualError(t, err, ErrBadSigningKeyLength.Error())
+}
@@ -0,0 +1,66 @@
+package bcl
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+)
+
+const sshEd25519 = "ssh-ed25519"
+
+func sshString(b []byte) []byte {
+	out := binary.BigEndian.AppendUint32(nil, uint32(len(b)))
+	return append(out, b...)
+}
+
+func readSSHString(b []byte) ([]byte, []byte, bool) {
+	if len(b) < 4 {
+		return nil, nil, false
+	}
+	n := binary.BigEndian.Uint32(b)
+	if uint64(len(b)-4) < uint64(n) {
+		return nil, nil, false
+	}
+	return b[4 : 4+n], b[4+n:], true
+}
+
+// ToOpenSSH formats a verify key as an OpenSSH "ssh-ed25519" public key line, as found in
+// authorized_keys and .pub files. The comment is omitted if empty
+func (v VerifyKey) ToOpenSSH(comment string) (string, error) {
+	if len(v) != CryptoSignPublicKeyBytes {
+		return "", ErrBadVerifyKeyLength
+	}
+	blob := append(sshString([]byte(sshEd25519)), sshString(v)...)
+	line := sshEd25519 + " " + base64.StdEncoding.EncodeToString(blob)
+	if comment != "" {
+		line += " " + comment
+	}
+	return line, nil
+}
+
+// VerifyKeyFromOpenSSH parses an OpenSSH "ssh-ed25519" public key line, returning the verify key
+// and the line's comment
+func VerifyKeyFromOpenSSH(line string) (VerifyKey, string, error) {
+	fields := strings.SplitN(strings.TrimSpace(line), " ", 3)
+	if len(fields) < 2 || fields[0] != sshEd25519 {
+		return nil, "", ErrBadOpenSSHKey
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return nil, "", ErrBadOpenSSHKey
+	}
+	algorithm, rest, ok := readSSHString(blob)
+	if !ok || !bytes.Equal(algorithm, []byte(sshEd25519)) {
+		return nil, "", ErrBadOpenSSHKey
+	}
+	key, rest, ok := readSSHString(rest)
+	if !ok || len(rest) > 0 || len(key) != CryptoSignPublicKeyBytes {
+		return nil, "", ErrBadOpenSSHKey
+	}
+	comment := ""
+	if len(fields) == 3 {
+		comment = strings.TrimSpace(fields[2])
+	}
+	return VerifyKey(key), comment, nil
+}
@@ -0,0 +1,49 @@
+package bcl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// generated by ssh-keygen -t ed25519
+const sshTestPublicKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGMHGgO1OxEw4UVce2DjYkYNT+Y4YTAMlmQtW+x+hMx5 alice@example.com"
+
+func TestOpenSSH(t *testing.T) {
+	vk, comment, err := VerifyKeyFromOpenSSH(sshTestPublicKey + "\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice@example.com", comment)
+	assert.Equal(t, CryptoSignPublicKeyBytes, len(vk))
+	line, err := vk.ToOpenSSH(comment)
+	assert.NoError(t, err)
+	assert.Equal(t, sshTestPublicKey, line)
+
+	_, vk, err = NewSigningKeyPair()
+	assert.NoError(t, err)
+	line, err = vk.ToOpenSSH("")
+	assert.NoError(t, err)
+	vk2, comment, err := VerifyKeyFromOpenSSH(line)
+	assert.NoError(t, err)
+	assert.Equal(t, "", comment)
+	assert.Equal(t, vk, vk2)
+
+	tests := []struct {
+		name string
+		line string
+	}{
+		{name: "TestOpenSSH fail empty", line: ""},
+		{name: "TestOpenSSH fail algorithm", line: "ssh-rsa AAAAC3NzaC1lZDI1NTE5AAAAIGMHGgO1OxEw4UVce2DjYkYNT+Y4YTAMlmQtW+x+hMx5"},
+		{name: "TestOpenSSH fail base64", line: "ssh-ed25519 not-base64"},
+		{name: "TestOpenSSH fail truncated", line: "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGMHGgO1OxEw4UVce2DjYkYNT+Y4YTAMlmQtW+x+"},
+		{name: "TestOpenSSH fail blob algorithm", line: "ssh-ed25519 AAAAB3NzaC1yc2EAAAAIGMHGgO1OxEw4UVce2DjYkYNT+Y4YTAMlmQtW+x+hMx5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := VerifyKeyFromOpenSSH(tt.line)
+			assert.EqualError(t, err, ErrBadOpenSSHKey.Error())
+		})
+	}
+
+	_, err = vk[1:].ToOpenSSH("")
+	assert.EqualError(t, err, ErrBadVerifyKeyLength.Error())
+}
@@ -1,19 +1,19 @@
 package bcl
 
 /*
-int crypto_secretbox(unsigned char *c, const unsigned char *m, unsigned long long mlen, const unsigned char *n, const unsigned char *k);
-int crypto_secretbox_open(unsigned char *m, const unsigned char *c, unsigned long long clen, const unsigned char *n, const unsigned char *k);
+int crypto_secretbox_easy(unsigned char *c, const unsigned char *m, unsigned long long mlen, const unsigned char *n, const unsigned char *k);
+int crypto_secretbox_open_easy(unsigned char *m, const unsigned char *c, unsigned long long clen, const unsigned char *n, const unsigned char *k);
 */
 import "C"
-import (
-	"fmt"
-	"unsafe"
-)
+import "fmt"
 
 // SymmetricEncrypt encrypts a plaintext using the supplied secret key (and an optional nonce, if
-// the supplied one is non-nil)
+// the supplied one is non-nil). Empty plaintexts are supported
 func SymmetricEncrypt(secretKey SecretKey, plaintext Plaintext, nonce Nonce) (Ciphertext, error) {
 	var err error
+	if len(secretKey) != CryptoSecretBoxKeyBytes {
+		return nil, ErrBadSecretKeyLength
+	}
 	if uint64(len(plaintext)) > CryptoSecretBoxMessageBytesMax {
 		return nil, ErrBadPlaintextLength
 	}
@@ -27,48 +27,60 @@ func SymmetricEncrypt(secretKey SecretKey, plaintext Plaintext, nonce Nonce) (Ci
 		return nil, ErrBadNonceLength
 	}
 
-	padded := make([]byte, CryptoSecretBoxZeroBytes+len(plaintext))
-	copy(padded[CryptoSecretBoxZeroBytes:], plaintext)
-
-	out := make([]byte, len(padded))
-	rc := C.crypto_secretbox(
-		(*C.uchar)(unsafe.Pointer(&out[0])),
-		(*C.uchar)(unsafe.Pointer(&padded[0])),
-		(C.ulonglong)(len(padded)),
-		(*C.uchar)(unsafe.Pointer(&nonce[0])),
-		(*C.uchar)(unsafe.Pointer(&secretKey[0])),
-	)
-	if rc != 0 {
-		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+	out := make([]byte, CryptoSecretBoxNonceBytes+CryptoSecretBoxMacBytes+len(plaintext))
+	if err := secretBoxSealInto(out, secretKey, nonce, plaintext); err != nil {
+		return nil, err
 	}
-
-	ret := append([]byte{}, nonce...)
-	ret = append(ret, out[CryptoSecretBoxBoxZeroBytes:]...)
-	return CiphertextFromBytes(ret)
+	return Ciphertext(out), nil
 }
 
 // SymmetricDecrypt decrypts a ciphertext using the supplied secret key
 func SymmetricDecrypt(secretKey SecretKey, ciphertext Ciphertext) (Plaintext, error) {
-	nonce := ciphertext[:CryptoSecretBoxNonceBytes]
-	ciphertextBody := ciphertext[CryptoSecretBoxNonceBytes:]
+	if len(secretKey) != CryptoSecretBoxKeyBytes {
+		return nil, ErrBadSecretKeyLength
+	}
+	if len(ciphertext) < CryptoSecretBoxNonceBytes+CryptoSecretBoxMacBytes {
+		return nil, ErrBadSymmetricCiphertextLength
+	}
 
-	paddedCipher := make([]byte, CryptoSecretBoxBoxZeroBytes+len(ciphertextBody))
-	copy(paddedCipher[CryptoSecretBoxBoxZeroBytes:], ciphertextBody)
+	out := make([]byte, len(ciphertext)-CryptoSecretBoxNonceBytes-CryptoSecretBoxMacBytes)
+	if err := secretBoxOpenInto(out, secretKey, ciphertext); err != nil {
+		return nil, err
+	}
+	return Plaintext(out), nil
+}
 
-	out := make([]byte, len(paddedCipher))
-	rc := C.crypto_secretbox_open(
-		(*C.uchar)(unsafe.Pointer(&out[0])),
-		(*C.uchar)(unsafe.Pointer(&paddedCipher[0])),
-		(C.ulonglong)(len(paddedCipher)),
-		(*C.uchar)(unsafe.Pointer(&nonce[0])),
-		(*C.uchar)(unsafe.Pointer(&secretKey[0])),
+// secretBoxSealInto writes the nonce, MAC and ciphertext of a plaintext into dst, which must be
+// exactly CryptoSecretBoxNonceBytes+CryptoSecretBoxMacBytes+len(plaintext) bytes long. Unlike
+// SymmetricEncrypt, it does not allocate
+func secretBoxSealInto(dst []byte, secretKey SecretKey, nonce Nonce, plaintext []byte) error {
+	copy(dst, nonce)
+	rc := C.crypto_secretbox_easy(
+		ucharPtr(dst[CryptoSecretBoxNonceBytes:]),
+		ucharPtr(plaintext),
+		C.ulonglong(len(plaintext)),
+		ucharPtr(nonce),
+		ucharPtr(secretKey),
 	)
 	if rc != 0 {
-		return nil, fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
+		return fmt.Errorf("unexpected nonzero return from libsodium: %d", int(rc))
 	}
+	return nil
+}
 
-	if len(out) < CryptoSecretBoxZeroBytes {
-		return nil, ErrBadDecryptionOutput
+// secretBoxOpenInto verifies and decrypts a ciphertext produced by SymmetricEncrypt into dst, which
+// must be exactly len(ciphertext)-CryptoSecretBoxNonceBytes-CryptoSecretBoxMacBytes bytes long
+func secretBoxOpenInto(dst []byte, secretKey SecretKey, ciphertext []byte) error {
+	body := ciphertext[CryptoSecretBoxNonceBytes:]
+	rc := C.crypto_secretbox_open_easy(
+		ucharPtr(dst),
+		ucharPtr(body),
+		C.ulonglong(len(body)),
+		ucharPtr(ciphertext),
+		ucharPtr(secretKey),
+	)
+	if rc != 0 {
+		return ErrDecryptionFailed
 	}
-	return PlaintextFromBytes(out[CryptoSecretBoxZeroBytes:])
+	return nil
 }
@@ -147,3 +147,78 @@ func TestSymmetricDecrypt(t *testing.T) {
 		})
 	}
 }
+
+func TestSymmetricEdgeCases(t *testing.T) {
+	sk, err := NewSecretKey()
+	assert.NoError(t, err)
+	other, err := NewSecretKey()
+	assert.NoError(t, err)
+	nonce, err := NewNonce()
+	assert.NoError(t, err)
+	valid, err := SymmetricEncrypt(sk, Plaintext("valid"), nil)
+	assert.NoError(t, err)
+	tampered := append(Ciphertext{}, valid...)
+	tampered[CryptoSecretBoxNonceBytes] ^= 0x01
+	overhead := CryptoSecretBoxNonceBytes + CryptoSecretBoxMacBytes
+
+	encryptTests := []struct {
+		name  string
+		sk    SecretKey
+		msg   Plaintext
+		nonce Nonce
+		err   error
+	}{
+		{name: "TestSymmetricEdgeCases encrypt success empty", sk: sk, msg: Plaintext{}, nonce: nil, err: nil},
+		{name: "TestSymmetricEdgeCases encrypt success nil", sk: sk, msg: nil, nonce: nonce, err: nil},
+		{name: "TestSymmetricEdgeCases encrypt fail nil key", sk: nil, msg: Plaintext("m"), nonce: nil, err: ErrBadSecretKeyLength},
+		{name: "TestSymmetricEdgeCases encrypt fail short key", sk: sk[1:], msg: Plaintext("m"), nonce: nil, err: ErrBadSecretKeyLength},
+		{name: "TestSymmetricEdgeCases encrypt fail long key", sk: append(append(SecretKey{}, sk...), 0x00), msg: Plaintext("m"), nonce: nil, err: ErrBadSecretKeyLength},
+		{name: "TestSymmetricEdgeCases encrypt fail empty nonce", sk: sk, msg: Plaintext("m"), nonce: Nonce{}, err: ErrBadNonceLength},
+		{name: "TestSymmetricEdgeCases encrypt fail short nonce", sk: sk, msg: Plaintext("m"), nonce: nonce[1:], err: ErrBadNonceLength},
+	}
+	for _, tt := range encryptTests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := SymmetricEncrypt(tt.sk, tt.msg, tt.nonce)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, overhead+len(tt.msg), len(c))
+			d, err := SymmetricDecrypt(tt.sk, c)
+			assert.NoError(t, err)
+			assert.NotNil(t, d)
+			assert.Equal(t, string(tt.msg), string(d))
+		})
+	}
+
+	decryptTests := []struct {
+		name string
+		sk   SecretKey
+		c    Ciphertext
+		err  error
+	}{
+		{name: "TestSymmetricEdgeCases decrypt success", sk: sk, c: valid, err: nil},
+		{name: "TestSymmetricEdgeCases decrypt fail nil", sk: sk, c: nil, err: ErrBadSymmetricCiphertextLength},
+		{name: "TestSymmetricEdgeCases decrypt fail empty", sk: sk, c: Ciphertext{}, err: ErrBadSymmetricCiphertextLength},
+		{name: "TestSymmetricEdgeCases decrypt fail shorter than nonce", sk: sk, c: valid[:CryptoSecretBoxNonceBytes-1], err: ErrBadSymmetricCiphertextLength},
+		{name: "TestSymmetricEdgeCases decrypt fail shorter than MAC", sk: sk, c: valid[:overhead-1], err: ErrBadSymmetricCiphertextLength},
+		{name: "TestSymmetricEdgeCases decrypt fail truncated", sk: sk, c: valid[:overhead], err: ErrDecryptionFailed},
+		{name: "TestSymmetricEdgeCases decrypt fail tampered", sk: sk, c: tampered, err: ErrDecryptionFailed},
+		{name: "TestSymmetricEdgeCases decrypt fail wrong key", sk: other, c: valid, err: ErrDecryptionFailed},
+		{name: "TestSymmetricEdgeCases decrypt fail nil key", sk: nil, c: valid, err: ErrBadSecretKeyLength},
+		{name: "TestSymmetricEdgeCases decrypt fail short key", sk: sk[:8], c: valid, err: ErrBadSecretKeyLength},
+	}
+	for _, tt := range decryptTests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := SymmetricDecrypt(tt.sk, tt.c)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				assert.Nil(t, d)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "valid", string(d))
+			}
+		})
+	}
+}
@@ -0,0 +1,356 @@
+package bcl
+
+/*
+int crypto_core_ed25519_is_valid_point(const unsigned char *p);
+int crypto_core_ed25519_add(unsigned char *r, const unsigned char *p, const unsigned char *q);
+int crypto_scalarmult_ed25519_noclamp(unsigned char *q, const unsigned char *n, const unsigned char *p);
+int crypto_sign_ed25519_pk_to_curve25519(unsigned char *curve25519_pk, const unsigned char *ed25519_pk);
+int crypto_core_hsalsa20(unsigned char *out, const unsigned char *in, const unsigned char *k, const unsigned char *c);
+int crypto_box_open_easy_afternm(unsigned char *m, const unsigned char *c, unsigned long long clen, const unsigned char *n, const unsigned char *k);
+*/
+import "C"
+import (
+	"encoding/base64"
+	"math/big"
+)
+
+// Threshold decryption splits the scalar of an X25519 secret key among n parties with Shamir's
+// scheme over the integers modulo the order l of the prime order subgroup, so that any k of them
+// can open a sealed box without the key ever being reconstructed. Sealed box ephemeral keys are
+// generated in the prime order subgroup, where multiplying by the clamped secret scalar is the
+// same as multiplying by its reduction modulo l. Each party multiplies the ephemeral key, mapped to
+// its Edwards form, by its share; the partial results are combined with Lagrange coefficients in
+// the exponent and mapped back to the X25519 shared secret, from which the box is opened as usual.
+//
+// Edwards points are used because libsodium only exposes point addition on that form. The sign of
+// the x coordinate lost by X25519 does not matter, as P and -P have the same Montgomery u
+// coordinate, and so do their multiples. Partial decryptions are not individually verifiable: a
+// bad partial makes the combined decryption fail authentication rather than produce a wrong
+// plaintext. ristretto255 and edwards25519 share the same group order, so the scalar arithmetic
+// of RistrettoScalar is reused for shares
+
+const (
+	thresholdShareVersion = 0x01
+	edwardsPointBytes     = 32
+)
+
+// ThresholdShareBytes is the length of an encoded threshold key share
+var ThresholdShareBytes = 3 + CryptoBoxPublicKeyBytes + CryptoCoreRistretto255ScalarBytes
+
+// PartialDecryptionBytes is the length of an encoded partial decryption
+var PartialDecryptionBytes = 2 + edwardsPointBytes
+
+// ThresholdShare is one party's share of an X25519 secret key
+type ThresholdShare struct {
+	// Index is the x coordinate of the share, from 1 to 255
+	Index byte
+	// Threshold is the number of shares needed to decrypt
+	Threshold byte
+	// PublicKey is the public key of the split secret key, which ciphertexts are encrypted to
+	PublicKey PublicKey
+	// Value is the share of the secret scalar
+	Value RistrettoScalar
+}
+
+// PartialDecryption is one party's contribution to decrypting a single sealed box
+type PartialDecryption struct {
+	Index     byte
+	Threshold byte
+	// Value is the ephemeral public key multiplied by the party's share, as an Edwards point
+	Value []byte
+}
+
+// scalarFromIndex returns the share index x as a scalar
+func scalarFromIndex(x byte) RistrettoScalar {
+	s := make(RistrettoScalar, CryptoCoreRistretto255ScalarBytes)
+	s[0] = x
+	return s
+}
+
+// ThresholdSplit splits a secret key into n shares, any k of which can decrypt ciphertexts
+// produced by AsymmetricEncrypt for its public key using PartialDecrypt and ThresholdDecrypt. It
+// requires 2 <= k <= n <= 255. The original key should be destroyed once the shares are
+// distributed
+func ThresholdSplit(secretKey SecretKey, n, k int) ([]ThresholdShare, error) {
+	publicKey, err := ScalarMultBase(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	if k < 2 || k > n || n > 255 {
+		return nil, ErrBadShamirParams
+	}
+
+	// the clamped X25519 scalar, reduced modulo l, is the constant term of the polynomial
+	wide := make([]byte, CryptoCoreRistretto255NonReducedScalarBytes)
+	copy(wide, secretKey)
+	wide[0] &= 248
+	wide[31] &= 127
+	wide[31] |= 64
+	defer clear(wide)
+	coefficients := make([]RistrettoScalar, k)
+	if coefficients[0], err = RistrettoScalarFromWideBytes(wide); err != nil {
+		return nil, err
+	}
+	for i := 1; i < k; i++ {
+		if coefficients[i], err = NewRistrettoScalar(); err != nil {
+			return nil, err
+		}
+	}
+	defer func() {
+		for _, c := range coefficients {
+			clear(c)
+		}
+	}()
+
+	shares := make([]ThresholdShare, n)
+	for i := range shares {
+		x := scalarFromIndex(byte(i + 1))
+		// Horner's rule, from the highest coefficient down to the secret scalar
+		value := coefficients[k-1]
+		for c := k - 2; c >= 0; c-- {
+			if value, err = value.Mul(x); err != nil {
+				return nil, err
+			}
+			if value, err = value.Add(coefficients[c]); err != nil {
+				return nil, err
+			}
+		}
+		shares[i] = ThresholdShare{Index: byte(i + 1), Threshold: byte(k), PublicKey: publicKey, Value: value}
+	}
+	return shares, nil
+}
+
+// fieldPrime is 2^255 - 19
+var fieldPrime = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+
+// montgomeryToEdwards maps an X25519 public key to the Edwards point with y = (u - 1) / (u + 1)
+// and a positive x coordinate, rejecting keys outside the prime order subgroup. Only public
+// values are mapped, so variable time arithmetic is acceptable
+func montgomeryToEdwards(publicKey PublicKey) ([]byte, error) {
+	le := make([]byte, len(publicKey))
+	for i := range publicKey {
+		le[len(le)-1-i] = publicKey[i]
+	}
+	u := new(big.Int).SetBytes(le)
+	u.Mod(u, fieldPrime)
+	denominator := new(big.Int).Add(u, big.NewInt(1))
+	denominator.Mod(denominator, fieldPrime)
+	if denominator.Sign() == 0 {
+		return nil, ErrDecryptionFailed
+	}
+	y := new(big.Int).Sub(u, big.NewInt(1))
+	y.Mul(y, new(big.Int).ModInverse(denominator, fieldPrime))
+	y.Mod(y, fieldPrime)
+
+	be := y.FillBytes(make([]byte, edwardsPointBytes))
+	point := make([]byte, edwardsPointBytes)
+	for i := range be {
+		point[len(point)-1-i] = be[i]
+	}
+	if C.crypto_core_ed25519_is_valid_point(ucharPtr(point)) != 1 {
+		return nil, ErrDecryptionFailed
+	}
+	return point, nil
+}
+
+// PartialDecrypt computes this share's contribution to decrypting a ciphertext produced by
+// AsymmetricEncrypt. It reveals nothing about the share or the plaintext on its own
+func (s ThresholdShare) PartialDecrypt(ciphertext Ciphertext) (PartialDecryption, error) {
+	if len(s.Value) != CryptoCoreRistretto255ScalarBytes || s.Index == 0 {
+		return PartialDecryption{}, ErrBadShare
+	}
+	if len(ciphertext) < CryptoBoxSealBytes {
+		return PartialDecryption{}, ErrBadCiphertextLength
+	}
+	ephemeral, err := montgomeryToEdwards(PublicKey(ciphertext[:CryptoBoxPublicKeyBytes]))
+	if err != nil {
+		return PartialDecryption{}, err
+	}
+	out := make([]byte, edwardsPointBytes)
+	if C.crypto_scalarmult_ed25519_noclamp(ucharPtr(out), ucharPtr(s.Value), ucharPtr(ephemeral)) != 0 {
+		return PartialDecryption{}, ErrDecryptionFailed
+	}
+	return PartialDecryption{Index: s.Index, Threshold: s.Threshold, Value: out}, nil
+}
+
+// lagrangeAtZero returns the Lagrange coefficient of index xi at zero for the supplied indices
+func lagrangeAtZero(xi byte, indices []byte) (RistrettoScalar, error) {
+	num, den := scalarFromIndex(1), scalarFromIndex(1)
+	var err error
+	for _, xm := range indices {
+		if xm == xi {
+			continue
+		}
+		if num, err = num.Mul(scalarFromIndex(xm)); err != nil {
+			return nil, err
+		}
+		diff, err := scalarFromIndex(xm).Sub(scalarFromIndex(xi))
+		if err != nil {
+			return nil, err
+		}
+		if den, err = den.Mul(diff); err != nil {
+			return nil, err
+		}
+	}
+	denInv, err := den.Invert()
+	if err != nil {
+		return nil, err
+	}
+	return num.Mul(denInv)
+}
+
+// combinePartials returns the X25519 shared secret of the ephemeral key and the split secret key,
+// the Montgomery form of the sum of lambda_i times partial_i
+func combinePartials(partials []PartialDecryption, indices []byte) ([]byte, error) {
+	var combined []byte
+	for _, p := range partials {
+		lambda, err := lagrangeAtZero(p.Index, indices)
+		if err != nil {
+			return nil, err
+		}
+		term := make([]byte, edwardsPointBytes)
+		if C.crypto_scalarmult_ed25519_noclamp(ucharPtr(term), ucharPtr(lambda), ucharPtr(p.Value)) != 0 {
+			return nil, ErrDecryptionFailed
+		}
+		if combined == nil {
+			combined = term
+			continue
+		}
+		if C.crypto_core_ed25519_add(ucharPtr(combined), ucharPtr(combined), ucharPtr(term)) != 0 {
+			return nil, ErrDecryptionFailed
+		}
+	}
+	shared := make([]byte, CryptoScalarMultBytes)
+	if C.crypto_sign_ed25519_pk_to_curve25519(ucharPtr(shared), ucharPtr(combined)) != 0 {
+		return nil, ErrDecryptionFailed
+	}
+	return shared, nil
+}
+
+// ThresholdDecrypt combines partial decryptions from at least the threshold number of shares to
+// open a ciphertext produced by AsymmetricEncrypt for publicKey. Only the first threshold partials
+// are used. A missing, wrong or mismatched partial gives ErrDecryptionFailed
+func ThresholdDecrypt(ciphertext Ciphertext, publicKey PublicKey, partials []PartialDecryption) (Plaintext, error) {
+	if len(publicKey) != CryptoBoxPublicKeyBytes {
+		return nil, ErrBadPublicKeyLength
+	}
+	if len(ciphertext) < CryptoBoxSealBytes {
+		return nil, ErrBadCiphertextLength
+	}
+	if len(partials) == 0 {
+		return nil, ErrNotEnoughShares
+	}
+	k := int(partials[0].Threshold)
+	if k < 2 {
+		return nil, ErrBadPartialDecryption
+	}
+	if len(partials) < k {
+		return nil, ErrNotEnoughShares
+	}
+	partials = partials[:k]
+	indices := make([]byte, k)
+	for i, p := range partials {
+		if p.Index == 0 || p.Threshold != partials[0].Threshold || len(p.Value) != edwardsPointBytes {
+			return nil, ErrBadPartialDecryption
+		}
+		for _, prev := range indices[:i] {
+			if prev == p.Index {
+				return nil, ErrDuplicateShare
+			}
+		}
+		indices[i] = p.Index
+	}
+
+	shared, err := combinePartials(partials, indices)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(shared)
+
+	// crypto_box_beforenm: HSalsa20 of the shared secret with a zero input
+	key := make([]byte, 32)
+	defer clear(key)
+	C.crypto_core_hsalsa20(ucharPtr(key), ucharPtr(make([]byte, 16)), ucharPtr(shared), nil)
+
+	// the sealed box nonce is the BLAKE2b hash of the ephemeral and recipient public keys
+	ephemeral := ciphertext[:CryptoBoxPublicKeyBytes]
+	nonce, err := genericHash(CryptoSecretBoxNonceBytes, nil, ephemeral, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	body := ciphertext[CryptoBoxPublicKeyBytes:]
+	out := make([]byte, len(body)-CryptoSecretBoxMacBytes)
+	rc := C.crypto_box_open_easy_afternm(ucharPtr(out), ucharPtr(body), C.ulonglong(len(body)), ucharPtr(nonce), ucharPtr(key))
+	if rc != 0 {
+		return nil, ErrDecryptionFailed
+	}
+	return Plaintext(out), nil
+}
+
+// Bytes encodes a threshold share as its version, threshold, index, public key and value
+func (s ThresholdShare) Bytes() []byte {
+	out := []byte{thresholdShareVersion, s.Threshold, s.Index}
+	return append(append(out, s.PublicKey...), s.Value...)
+}
+
+// ThresholdShareFromBytes decodes a threshold share produced by ThresholdShare.Bytes
+func ThresholdShareFromBytes(b []byte) (ThresholdShare, error) {
+	if len(b) != ThresholdShareBytes || b[0] != thresholdShareVersion || b[1] < 2 || b[2] == 0 {
+		return ThresholdShare{}, ErrBadShare
+	}
+	value, err := RistrettoScalarFromBytes(append([]byte{}, b[3+CryptoBoxPublicKeyBytes:]...))
+	if err != nil {
+		return ThresholdShare{}, ErrBadShare
+	}
+	return ThresholdShare{
+		Threshold: b[1],
+		Index:     b[2],
+		PublicKey: append(PublicKey{}, b[3:3+CryptoBoxPublicKeyBytes]...),
+		Value:     value,
+	}, nil
+}
+
+// ToBase64 encodes a threshold share as a base64 string
+func (s ThresholdShare) ToBase64() string {
+	return base64.StdEncoding.EncodeToString(s.Bytes())
+}
+
+// ThresholdShareFromBase64 decodes a threshold share from a base64 string
+func ThresholdShareFromBase64(arg string) (ThresholdShare, error) {
+	b, err := base64.StdEncoding.DecodeString(arg)
+	if err != nil {
+		return ThresholdShare{}, err
+	}
+	return ThresholdShareFromBytes(b)
+}
+
+// Bytes encodes a partial decryption as its threshold, index and value
+func (p PartialDecryption) Bytes() []byte {
+	return append([]byte{p.Threshold, p.Index}, p.Value...)
+}
+
+// PartialDecryptionFromBytes decodes a partial decryption produced by PartialDecryption.Bytes
+func PartialDecryptionFromBytes(b []byte) (PartialDecryption, error) {
+	if len(b) != PartialDecryptionBytes || b[0] < 2 || b[1] == 0 {
+		return PartialDecryption{}, ErrBadPartialDecryption
+	}
+	value := append([]byte{}, b[2:]...)
+	if C.crypto_core_ed25519_is_valid_point(ucharPtr(value)) != 1 {
+		return PartialDecryption{}, ErrBadPartialDecryption
+	}
+	return PartialDecryption{Threshold: b[0], Index: b[1], Value: value}, nil
+}
+
+// ToBase64 encodes a partial decryption as a base64 string
+func (p PartialDecryption) ToBase64() string {
+	return base64.StdEncoding.EncodeToString(p.Bytes())
+}
+
+// PartialDecryptionFromBase64 decodes a partial decryption from a base64 string
+func PartialDecryptionFromBase64(arg string) (PartialDecryption, error) {
+	b, err := base64.StdEncoding.DecodeString(arg)
+	if err != nil {
+		return PartialDecryption{}, err
+	}
+	return PartialDecryptionFromBytes(b)
+}
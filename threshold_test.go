@@ -0,0 +1,221 @@
+package bcl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func partialDecryptAll(t *testing.T, shares []ThresholdShare, ciphertext Ciphertext) []PartialDecryption {
+	partials := make([]PartialDecryption, len(shares))
+	for i, s := range shares {
+		p, err := s.PartialDecrypt(ciphertext)
+		assert.NoError(t, err)
+		partials[i] = p
+	}
+	return partials
+}
+
+func TestThresholdDecrypt(t *testing.T) {
+	secretKey, publicKey, err := NewKeyPair()
+	assert.NoError(t, err)
+	plaintext := Plaintext("escrowed under two-person control")
+	tests := []struct {
+		name string
+		n, k int
+	}{
+		{name: "TestThresholdDecrypt success 2 of 2", n: 2, k: 2},
+		{name: "TestThresholdDecrypt success 2 of 3", n: 3, k: 2},
+		{name: "TestThresholdDecrypt success 3 of 5", n: 5, k: 3},
+		{name: "TestThresholdDecrypt success 2 of 255", n: 255, k: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares, err := ThresholdSplit(secretKey, tt.n, tt.k)
+			assert.NoError(t, err)
+			assert.Len(t, shares, tt.n)
+			for _, s := range shares {
+				assert.True(t, s.PublicKey.Equal(publicKey))
+			}
+			ciphertext, err := AsymmetricEncrypt(publicKey, plaintext)
+			assert.NoError(t, err)
+			partials := partialDecryptAll(t, shares, ciphertext)
+
+			// every window of k consecutive partials, in either order, opens the box
+			for i := 0; i+tt.k <= tt.n; i++ {
+				window := partials[i : i+tt.k]
+				decrypted, err := ThresholdDecrypt(ciphertext, publicKey, window)
+				assert.NoError(t, err)
+				assert.Equal(t, plaintext, decrypted)
+
+				reversed := make([]PartialDecryption, tt.k)
+				for j := range window {
+					reversed[tt.k-1-j] = window[j]
+				}
+				decrypted, err = ThresholdDecrypt(ciphertext, publicKey, reversed)
+				assert.NoError(t, err)
+				assert.Equal(t, plaintext, decrypted)
+			}
+		})
+	}
+}
+
+func TestThresholdDecryptSharedSecret(t *testing.T) {
+	secretKey, publicKey, err := NewKeyPair()
+	assert.NoError(t, err)
+	shares, err := ThresholdSplit(secretKey, 3, 2)
+	assert.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		ciphertext, err := AsymmetricEncrypt(publicKey, Plaintext{byte(i)})
+		assert.NoError(t, err)
+		ephemeral := PublicKey(ciphertext[:CryptoBoxPublicKeyBytes])
+		expected, err := ScalarMult(secretKey, ephemeral)
+		assert.NoError(t, err)
+
+		partials := partialDecryptAll(t, shares[1:], ciphertext)
+		indices := []byte{partials[0].Index, partials[1].Index}
+		shared, err := combinePartials(partials, indices)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, shared)
+
+		decrypted, err := ThresholdDecrypt(ciphertext, publicKey, partials)
+		assert.NoError(t, err)
+		assert.Equal(t, Plaintext{byte(i)}, decrypted)
+	}
+}
+
+func TestThresholdDecryptFail(t *testing.T) {
+	secretKey, publicKey, err := NewKeyPair()
+	assert.NoError(t, err)
+	shares, err := ThresholdSplit(secretKey, 3, 2)
+	assert.NoError(t, err)
+	ciphertext, err := AsymmetricEncrypt(publicKey, Plaintext("secret"))
+	assert.NoError(t, err)
+	other, err := AsymmetricEncrypt(publicKey, Plaintext("other"))
+	assert.NoError(t, err)
+	partials := partialDecryptAll(t, shares, ciphertext)
+	otherPartials := partialDecryptAll(t, shares, other)
+
+	tampered := partials[1]
+	tampered.Value = append([]byte{}, tampered.Value...)
+	tampered.Value[0] ^= 0x01
+	threshold3 := partials[1]
+	threshold3.Threshold = 3
+	_, otherPublicKey, err := NewKeyPair()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		ciphertext Ciphertext
+		publicKey  PublicKey
+		partials   []PartialDecryption
+		err        error
+	}{
+		{name: "TestThresholdDecryptFail no partials", ciphertext: ciphertext, publicKey: publicKey, err: ErrNotEnoughShares},
+		{name: "TestThresholdDecryptFail too few partials", ciphertext: ciphertext, publicKey: publicKey, partials: partials[:1], err: ErrNotEnoughShares},
+		{name: "TestThresholdDecryptFail duplicate partial", ciphertext: ciphertext, publicKey: publicKey, partials: []PartialDecryption{partials[0], partials[0]}, err: ErrDuplicateShare},
+		{name: "TestThresholdDecryptFail threshold mismatch", ciphertext: ciphertext, publicKey: publicKey, partials: []PartialDecryption{partials[0], threshold3}, err: ErrBadPartialDecryption},
+		{name: "TestThresholdDecryptFail zero threshold", ciphertext: ciphertext, publicKey: publicKey, partials: []PartialDecryption{{Index: 1, Value: partials[0].Value}}, err: ErrBadPartialDecryption},
+		{name: "TestThresholdDecryptFail tampered partial", ciphertext: ciphertext, publicKey: publicKey, partials: []PartialDecryption{partials[0], tampered}, err: ErrDecryptionFailed},
+		{name: "TestThresholdDecryptFail partial for another ciphertext", ciphertext: ciphertext, publicKey: publicKey, partials: []PartialDecryption{partials[0], otherPartials[1]}, err: ErrDecryptionFailed},
+		{name: "TestThresholdDecryptFail wrong public key", ciphertext: ciphertext, publicKey: otherPublicKey, partials: partials[:2], err: ErrDecryptionFailed},
+		{name: "TestThresholdDecryptFail short ciphertext", ciphertext: ciphertext[:CryptoBoxSealBytes-1], publicKey: publicKey, partials: partials[:2], err: ErrBadCiphertextLength},
+		{name: "TestThresholdDecryptFail bad public key", ciphertext: ciphertext, publicKey: publicKey[:31], partials: partials[:2], err: ErrBadPublicKeyLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ThresholdDecrypt(tt.ciphertext, tt.publicKey, tt.partials)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestThresholdSplitFail(t *testing.T) {
+	secretKey, _, err := NewKeyPair()
+	assert.NoError(t, err)
+	tests := []struct {
+		name      string
+		secretKey SecretKey
+		n, k      int
+		err       error
+	}{
+		{name: "TestThresholdSplitFail k below 2", secretKey: secretKey, n: 3, k: 1, err: ErrBadShamirParams},
+		{name: "TestThresholdSplitFail k above n", secretKey: secretKey, n: 2, k: 3, err: ErrBadShamirParams},
+		{name: "TestThresholdSplitFail n above 255", secretKey: secretKey, n: 256, k: 2, err: ErrBadShamirParams},
+		{name: "TestThresholdSplitFail bad key", secretKey: secretKey[:31], n: 3, k: 2, err: ErrBadSecretKeyLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ThresholdSplit(tt.secretKey, tt.n, tt.k)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestPartialDecryptFail(t *testing.T) {
+	secretKey, publicKey, err := NewKeyPair()
+	assert.NoError(t, err)
+	shares, err := ThresholdSplit(secretKey, 2, 2)
+	assert.NoError(t, err)
+	ciphertext, err := AsymmetricEncrypt(publicKey, Plaintext("secret"))
+	assert.NoError(t, err)
+
+	// an ephemeral key of small order is outside the prime order subgroup
+	lowOrder := append(Ciphertext{}, ciphertext...)
+	copy(lowOrder, make([]byte, CryptoBoxPublicKeyBytes))
+
+	_, err = shares[0].PartialDecrypt(ciphertext[:CryptoBoxSealBytes-1])
+	assert.ErrorIs(t, err, ErrBadCiphertextLength)
+	_, err = shares[0].PartialDecrypt(lowOrder)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+	_, err = ThresholdShare{Index: 1, Threshold: 2}.PartialDecrypt(ciphertext)
+	assert.ErrorIs(t, err, ErrBadShare)
+}
+
+func TestThresholdShareEncoding(t *testing.T) {
+	secretKey, publicKey, err := NewKeyPair()
+	assert.NoError(t, err)
+	shares, err := ThresholdSplit(secretKey, 3, 2)
+	assert.NoError(t, err)
+	ciphertext, err := AsymmetricEncrypt(publicKey, Plaintext("secret"))
+	assert.NoError(t, err)
+
+	var partials []PartialDecryption
+	for _, s := range shares[:2] {
+		assert.Len(t, s.Bytes(), ThresholdShareBytes)
+		decoded, err := ThresholdShareFromBase64(s.ToBase64())
+		assert.NoError(t, err)
+		assert.Equal(t, s, decoded)
+
+		p, err := decoded.PartialDecrypt(ciphertext)
+		assert.NoError(t, err)
+		assert.Len(t, p.Bytes(), PartialDecryptionBytes)
+		decodedPartial, err := PartialDecryptionFromBase64(p.ToBase64())
+		assert.NoError(t, err)
+		assert.Equal(t, p, decodedPartial)
+		partials = append(partials, decodedPartial)
+	}
+	decrypted, err := ThresholdDecrypt(ciphertext, publicKey, partials)
+	assert.NoError(t, err)
+	assert.Equal(t, Plaintext("secret"), decrypted)
+
+	b := shares[0].Bytes()
+	badVersion := append([]byte{}, b...)
+	badVersion[0] = 0x02
+	badScalar := append([]byte{}, b...)
+	badScalar[len(badScalar)-1] = 0xff
+	for _, bad := range [][]byte{b[:len(b)-1], badVersion, badScalar} {
+		_, err = ThresholdShareFromBytes(bad)
+		assert.ErrorIs(t, err, ErrBadShare)
+	}
+	_, err = ThresholdShareFromBase64("not base64!")
+	assert.Error(t, err)
+
+	p := partials[0].Bytes()
+	badPoint := append([]byte{}, p...)
+	copy(badPoint[2:], make([]byte, edwardsPointBytes))
+	for _, bad := range [][]byte{p[:len(p)-1], badPoint} {
+		_, err = PartialDecryptionFromBytes(bad)
+		assert.ErrorIs(t, err, ErrBadPartialDecryption)
+	}
+}